@@ -0,0 +1,65 @@
+// Command papgen generates a Go package from a BMF PAP XML file. It is meant
+// to run through go generate, see internal/tax/native.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/papgen"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "papgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	papFile := flag.String("pap", "", "PAP XML file to generate from")
+	out := flag.String("out", "", "output file (default: stdout)")
+	pkg := flag.String("package", "", "package name (default: derived from the PAP file name)")
+	fn := flag.String("func", "", "name of the tax function (default: derived from the PAP file name)")
+	flag.Parse()
+
+	if *papFile == "" {
+		flag.Usage()
+		return fmt.Errorf("-pap is required")
+	}
+
+	papData, err := bmf.LoadPAPFile(*papFile)
+	if err != nil {
+		return err
+	}
+
+	defaultPkg, defaultFn := papgen.NamesFor(*papFile)
+	opts := papgen.Options{
+		Package: defaultPkg,
+		Func:    defaultFn,
+		Source:  filepath.Base(*papFile),
+	}
+	if *pkg != "" {
+		opts.Package = *pkg
+	}
+	if *fn != "" {
+		opts.Func = *fn
+	}
+
+	source, err := papgen.Generate(papData, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *papFile, err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(*out, source, 0o644)
+}
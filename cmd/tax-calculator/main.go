@@ -4,10 +4,34 @@ import (
 	"fmt"
 	"os"
 
+	"tax-calculator/internal/cli"
 	"tax-calculator/internal/main/views"
+	"tax-calculator/internal/tax/bmf"
 )
 
 func main() {
+	// Subcommands such as steuergo pap lint; without one the TUI starts
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	// Record the traffic with the BMF as fixtures, or replay it offline
+	opts, err := bmf.FixtureOptionsFromEnv()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Otherwise keep the answers of the BMF on disk, so repeated calculations
+	// and comparisons do not ask it again. Answers of a stand-in such as
+	// steuergo mock-bmf must not be taken for the BMF's later.
+	if len(opts) == 0 && os.Getenv(bmf.APIBaseURLEnv) == "" {
+		if cache, err := bmf.OpenDefaultCache(); err == nil {
+			opts = append(opts, bmf.WithCache(cache))
+		}
+	}
+	bmf.DefaultClient = bmf.NewClient(opts...)
+
 	if err := views.Start(); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+// runCache inspects and purges the disk cache of BMF answers
+func runCache(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return runCacheList(args[1:], stdout, stderr)
+	case "purge":
+		return runCachePurge(args[1:], stdout, stderr)
+	}
+	return fmt.Errorf("unknown cache command %q: %w", args[0], errUsage)
+}
+
+// runCacheList lists the cached responses and PAPs
+func runCacheList(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("cache list", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errUsage
+	}
+
+	cache, err := bmf.OpenDefaultCache()
+	if err != nil {
+		return err
+	}
+	entries, err := cache.Entries()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if entry.Err != nil {
+			fmt.Fprintf(stdout, "%-9s  %-12s  %-19s  %s: %v\n", entry.Kind, "unreadable", "", entry.Description, entry.Err)
+		} else {
+			fmt.Fprintf(stdout, "%-9s  %-12s  %s  %s\n", entry.Kind, entry.Version, entry.Stored.Local().Format(time.DateTime), entry.Description)
+		}
+		size += entry.Size
+	}
+	fmt.Fprintf(stdout, "%s, %d bytes in %s\n", plural(len(entries), "entry"), size, cache.Dir())
+	return nil
+}
+
+// runCachePurge removes cached responses, PAPs or both
+func runCachePurge(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("cache purge", stderr)
+	responses := flags.Bool("responses", false, "purge the cached responses only")
+	paps := flags.Bool("paps", false, "purge the cached PAPs only")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errUsage
+	}
+
+	var kinds []bmf.CacheKind
+	if *responses {
+		kinds = append(kinds, bmf.CachedResponse)
+	}
+	if *paps {
+		kinds = append(kinds, bmf.CachedPAP)
+	}
+
+	cache, err := bmf.OpenDefaultCache()
+	if err != nil {
+		return err
+	}
+	removed, err := cache.Purge(kinds...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "removed %s from %s\n", plural(removed, "entry"), cache.Dir())
+	return nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+)
+
+func TestCacheListAndPurge(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(bmf.CacheDirEnv, dir)
+
+	version, err := bmf.DefaultPAPRegistry.Resolve(2025, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+	query, err := bmf.BuildQuery(req, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bmf.NewCache(dir).StoreResponse(bmf.NewClient().Endpoint(version), query, version, req, []byte(`<lohnsteuer jahr="2025"/>`)); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"cache", "list"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, expected := range []string{"responses  2025Version1  ", "  LZZ=1 RE4=5000000 STKL=1\n", "1 entry, ", " bytes in " + dir} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q in the output, got %q", expected, stdout.String())
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"cache", "purge", "-paps"}, "removed 0 entries from " + dir},
+		{[]string{"cache", "purge"}, "removed 1 entry from " + dir},
+		{[]string{"cache", "list"}, "0 entries, 0 bytes in " + dir},
+	}
+	for _, tt := range tests {
+		stdout.Reset()
+		if code := Run(tt.args, &stdout, &stderr); code != 0 {
+			t.Errorf("%v: expected exit code 0, got %d", tt.args, code)
+		}
+		if !strings.Contains(stdout.String(), tt.expected) {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.expected, stdout.String())
+		}
+	}
+}
+
+func TestCacheListUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(bmf.CacheDirEnv, dir)
+
+	broken := filepath.Join(dir, string(bmf.CachedResponse), "broken.json")
+	if err := os.MkdirAll(filepath.Dir(broken), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(broken, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"cache", "list"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, expected := range []string{"responses  unreadable  ", broken + ": ", "1 entry, "} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q in the output, got %q", expected, stdout.String())
+		}
+	}
+}
+
+func TestCacheUsage(t *testing.T) {
+	for _, args := range [][]string{{"cache"}, {"cache", "frobnicate"}, {"cache", "list", "x"}} {
+		var stdout, stderr bytes.Buffer
+		if code := Run(args, &stdout, &stderr); code != 2 || !strings.Contains(stderr.String(), "usage: steuergo cache list") {
+			t.Errorf("%v: expected the usage, got %d: %q", args, code, stderr.String())
+		}
+	}
+}
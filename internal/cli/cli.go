@@ -0,0 +1,95 @@
+// Package cli implements the subcommands of steuergo. Without a subcommand
+// steuergo starts the TUI.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// command is a subcommand of steuergo. It gets the arguments after its name
+// and has a usage line for each of its forms.
+type command struct {
+	usage []string
+	run   func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"cache":    {usage: []string{"cache list", "cache purge [-responses] [-paps]"}, run: runCache},
+	"mock-bmf": {usage: []string{"mock-bmf [-addr ADDR] [-latency DURATION] [-error-rate RATE] [-error-status CODE] [-malformed-rate RATE] [-q]"}, run: runMockBMF},
+	"pap":      {usage: []string{"pap lint [-year YEAR] [FILE...]", "pap diff OLD NEW"}, run: runPAP},
+	"selftest": {usage: []string{"selftest [-year YEAR] [-tables DIR] [-v]"}, run: runSelftest},
+}
+
+// errFailed is returned by a command that has already reported why it
+// failed, so Run only sets the exit code
+var errFailed = errors.New("failed")
+
+// Run runs the subcommand named by args[0] and returns the exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+			usage(stdout)
+			return 0
+		}
+		fmt.Fprintf(stderr, "steuergo: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	err := cmd.run(args[1:], stdout, stderr)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		for i, line := range cmd.usage {
+			if i == 0 {
+				fmt.Fprintf(stderr, "usage: steuergo %s\n", line)
+			} else {
+				fmt.Fprintf(stderr, "       steuergo %s\n", line)
+			}
+		}
+		return 2
+	case errors.Is(err, errFailed):
+		return 1
+	}
+	fmt.Fprintf(stderr, "steuergo %s: %v\n", args[0], err)
+	return 1
+}
+
+// errUsage makes Run print the usage of the command
+var errUsage = errors.New("usage")
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: steuergo [command]")
+	fmt.Fprintln(w, "\nWithout a command steuergo starts the calculator. Commands:")
+	for _, name := range names {
+		for _, line := range commands[name].usage {
+			fmt.Fprintf(w, "  steuergo %s\n", line)
+		}
+	}
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("steuergo "+name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
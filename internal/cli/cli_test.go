@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		{[]string{"help"}, 0, "steuergo pap lint"},
+		{[]string{"help"}, 0, "steuergo pap diff"},
+		{[]string{"help"}, 0, "steuergo selftest"},
+		{[]string{"frobnicate"}, 2, `unknown command "frobnicate"`},
+		{[]string{"pap"}, 2, "usage: steuergo pap"},
+		{[]string{"pap", "frobnicate"}, 2, "usage: steuergo pap"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := Run(tt.args, &stdout, &stderr)
+		if code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d", tt.args, tt.code, code)
+		}
+		if output := stdout.String() + stderr.String(); !strings.Contains(output, tt.expected) {
+			t.Errorf("%v: expected %q in the output, got %q", tt.args, tt.expected, output)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+// interrupted returns a context that ends when steuergo is interrupted;
+// tests replace it to stop the server
+var interrupted = func() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// runMockBMF serves a stand-in for the BMF interface that calculates with
+// the local PAPs until it is interrupted
+func runMockBMF(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("mock-bmf", stderr)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	latency := flags.Duration("latency", 0, "delay every answer, e.g. 2s")
+	errorRate := flags.Float64("error-rate", 0, "share of requests answered with an HTTP error, 0 to 1")
+	errorStatus := flags.Int("error-status", http.StatusServiceUnavailable, "status of the injected HTTP errors")
+	malformedRate := flags.Float64("malformed-rate", 0, "share of answers with their XML cut off, 0 to 1")
+	quiet := flags.Bool("q", false, "do not log the requests")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errUsage
+	}
+	for name, rate := range map[string]float64{"-error-rate": *errorRate, "-malformed-rate": *malformedRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %g", name, rate)
+		}
+	}
+	if *errorStatus < 400 || *errorStatus > 599 {
+		return fmt.Errorf("-error-status must be an HTTP error status, got %d", *errorStatus)
+	}
+
+	opts := []bmf.MockOption{bmf.WithFaults(bmf.MockFaults{
+		Latency:       *latency,
+		ErrorRate:     *errorRate,
+		ErrorStatus:   *errorStatus,
+		MalformedRate: *malformedRate,
+	})}
+	if !*quiet {
+		opts = append(opts, bmf.WithRequestLog(stdout))
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: bmf.NewMockServer(opts...)}
+
+	baseURL := fmt.Sprintf("http://%s/interface", listener.Addr())
+	fmt.Fprintf(stdout, "serving the BMF interface at %s\n", baseURL)
+	fmt.Fprintf(stdout, "use it with %s=%s\n", bmf.APIBaseURLEnv, baseURL)
+
+	ctx, stop := interrupted()
+	defer stop()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		return err
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
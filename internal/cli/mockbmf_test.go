@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+)
+
+// syncBuffer is written by the server while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMockBMF(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	saved := interrupted
+	interrupted = func() (context.Context, context.CancelFunc) { return ctx, cancel }
+	t.Cleanup(func() { interrupted = saved })
+
+	var stdout, stderr syncBuffer
+	exited := make(chan int)
+	go func() {
+		exited <- Run([]string{"mock-bmf", "-addr", "127.0.0.1:0"}, &stdout, &stderr)
+	}()
+
+	address := regexp.MustCompile(`at (http://\S+/interface)\n`)
+	var baseURL string
+	for start := time.Now(); baseURL == "" && time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if match := address.FindStringSubmatch(stdout.String()); match != nil {
+			baseURL = match[1]
+		}
+	}
+	if baseURL == "" {
+		t.Fatalf("Expected the server to start, got %q %q", stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), bmf.APIBaseURLEnv+"="+baseURL) {
+		t.Errorf("Expected a hint at %s, got %q", bmf.APIBaseURLEnv, stdout.String())
+	}
+
+	client := bmf.NewClient(bmf.WithBaseURL(baseURL), bmf.WithRetryPolicy(bmf.NoRetry))
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, KVZ: 2.5, Year: 2025}
+	response, err := client.CalculateTax(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	outputs, err := response.TaxOutputs()
+	if err != nil || outputs.LSTLZZ == 0 {
+		t.Errorf("Expected Lohnsteuer, got %+v, %v", outputs, err)
+	}
+
+	cancel()
+	select {
+	case code := <-exited:
+		if code != 0 {
+			t.Errorf("Expected exit code 0, got %d: %s", code, stderr.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to stop")
+	}
+	if !strings.Contains(stdout.String(), "GET /interface/2025Version1.xhtml?") {
+		t.Errorf("Expected the request in the log, got %q", stdout.String())
+	}
+}
+
+func TestMockBMFErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		{[]string{"mock-bmf", "extra"}, 2, "usage: steuergo mock-bmf"},
+		{[]string{"mock-bmf", "-error-rate", "2"}, 1, "-error-rate must be between 0 and 1, got 2"},
+		{[]string{"mock-bmf", "-malformed-rate", "-0.5"}, 1, "-malformed-rate must be between 0 and 1"},
+		{[]string{"mock-bmf", "-error-status", "200"}, 1, "-error-status must be an HTTP error status, got 200"},
+		{[]string{"mock-bmf", "-latency", "soon"}, 1, "invalid value \"soon\" for flag -latency"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := Run(tt.args, &stdout, &stderr); code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d", tt.args, tt.code, code)
+		}
+		if !strings.Contains(stderr.String(), tt.expected) {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.expected, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+// runPAP runs the tools working on PAP files
+func runPAP(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "lint":
+		return runPAPLint(args[1:], stdout, stderr)
+	case "diff":
+		return runPAPDiff(args[1:], stdout, stderr)
+	}
+	return fmt.Errorf("unknown pap command %q: %w", args[0], errUsage)
+}
+
+// runPAPLint lints PAP files, or the PAPs of the supported years
+func runPAPLint(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("pap lint", stderr)
+	year := flags.Int("year", 0, "lint the PAPs of one tax year only (default: all supported years)")
+	quiet := flags.Bool("q", false, "report errors only, no warnings")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	type target struct {
+		name string
+		load func() (*bmf.PAPData, error)
+	}
+	var targets []target
+
+	for _, file := range flags.Args() {
+		targets = append(targets, target{name: filepath.Base(file), load: func() (*bmf.PAPData, error) {
+			return bmf.LoadPAPFile(file)
+		}})
+	}
+	if len(targets) == 0 {
+		years := bmf.DefaultPAPRegistry.Years()
+		if *year != 0 {
+			years = []int{*year}
+		}
+		for _, y := range years {
+			if _, err := bmf.DefaultPAPRegistry.Version(y); err != nil {
+				return err
+			}
+			for _, version := range bmf.DefaultPAPRegistry.Versions(y) {
+				targets = append(targets, target{name: version.Version, load: func() (*bmf.PAPData, error) {
+					return bmf.DefaultPAPRegistry.LoadVersion(version)
+				}})
+			}
+		}
+	}
+
+	failed := false
+	for _, t := range targets {
+		papData, err := t.load()
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", t.name, err)
+			failed = true
+			continue
+		}
+
+		errors, warnings := 0, 0
+		for _, issue := range bmf.Lint(papData) {
+			if issue.Severity == bmf.LintError {
+				errors++
+			} else {
+				warnings++
+				if *quiet {
+					continue
+				}
+			}
+			fmt.Fprintf(stdout, "%s: %s\n", t.name, issue)
+		}
+		fmt.Fprintf(stdout, "%s: %s, %s\n", t.name, plural(errors, "error"), plural(warnings, "warning"))
+		failed = failed || errors > 0
+	}
+
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// runPAPDiff reports what changed between two PAPs, each given as a tax year,
+// a PAP version such as 2024Version1 or a file
+func runPAPDiff(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("pap diff", stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errUsage
+	}
+
+	oldName, old, err := loadPAPArg(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	newName, new, err := loadPAPArg(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	diff := bmf.DiffPAP(old, new)
+	fmt.Fprintf(stdout, "%s → %s\n", oldName, newName)
+
+	if len(diff.Variables) > 0 {
+		fmt.Fprintln(stdout, "\nVariables:")
+		for _, v := range diff.Variables {
+			fmt.Fprintf(stdout, "  %s %s %s: %s\n", v.Kind, v.Section, v.Name, change(v.Kind, v.Old, v.New))
+		}
+	}
+	if len(diff.Constants) > 0 {
+		fmt.Fprintln(stdout, "\nConstants:")
+		for _, c := range diff.Constants {
+			fmt.Fprintf(stdout, "  %s %s: %s\n", c.Kind, c.Name, change(c.Kind, c.Old, c.New))
+		}
+	}
+	if len(diff.Parameters) > 0 {
+		fmt.Fprintln(stdout, "\nParameters:")
+		for _, p := range diff.Parameters {
+			fmt.Fprintf(stdout, "  %s %s in %s: %s\n", p.Kind, p.Name, p.Method, change(p.Kind, p.Old, p.New))
+		}
+	}
+	if len(diff.Methods) > 0 {
+		fmt.Fprintln(stdout, "\nMethods:")
+		for _, m := range diff.Methods {
+			name := m.Name
+			if m.OldName != "" && m.OldName != m.Name {
+				name = m.OldName + " → " + m.Name
+			}
+			fmt.Fprintf(stdout, "  %s %s\n", m.Kind, name)
+			for _, line := range m.Lines {
+				fmt.Fprintf(stdout, "      %s %s\n", line.Kind, line.Text)
+			}
+		}
+	}
+
+	fmt.Fprintf(stdout, "\n%s\n", plural(diff.Len(), "change"))
+	return nil
+}
+
+// change renders the values of a change, the old one for removals and the
+// new one for additions
+func change(kind bmf.ChangeKind, old, new string) string {
+	switch kind {
+	case bmf.Added:
+		return new
+	case bmf.Removed:
+		return old
+	}
+	return old + " → " + new
+}
+
+// versionArg matches a PAP version name such as 2024Version1
+var versionArg = regexp.MustCompile(`^(\d{4})Version\d+$`)
+
+// loadPAPArg loads the current PAP of a tax year, a PAP version or a file and
+// returns its name
+func loadPAPArg(arg string) (string, *bmf.PAPData, error) {
+	var version bmf.PAPVersion
+	var err error
+	if year, yearErr := strconv.Atoi(arg); yearErr == nil && len(arg) == 4 {
+		version, err = bmf.DefaultPAPRegistry.Version(year)
+	} else if match := versionArg.FindStringSubmatch(arg); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		version, err = bmf.DefaultPAPRegistry.Resolve(year, arg)
+	} else {
+		papData, err := bmf.LoadPAPFile(arg)
+		return filepath.Base(arg), papData, err
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	papData, err := bmf.DefaultPAPRegistry.LoadVersion(version)
+	return version.Version, papData, err
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	if stem, ok := strings.CutSuffix(noun, "y"); ok {
+		return fmt.Sprintf("%d %sies", n, stem)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
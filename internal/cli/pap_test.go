@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+func TestPAPLintEmbedded(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"pap", "lint", "-year", "2024"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	expected := "2024Version1: 0 errors, 0 warnings\n2024Version2: 0 errors, 0 warnings\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestPAPLintFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Broken.xml")
+	src := `<PAP name="Broken">
+		<VARIABLES><INTERNALS><INTERNAL name="ZVE" type="BigDecimal"/></INTERNALS></VARIABLES>
+		<CONSTANTS/>
+		<METHODS>
+			<MAIN><EVAL exec="ZVE = ZVE.add(WVFRB)"/></MAIN>
+			<METHOD name="MOLD"/>
+		</METHODS>
+	</PAP>`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"pap", "lint", path}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a PAP with errors, got %d", code)
+	}
+	for _, expected := range []string{
+		`Broken.xml: MAIN: error: undefined variable WVFRB in "ZVE = ZVE.add(WVFRB)"`,
+		"Broken.xml: MOLD: warning: method MOLD is never called",
+		"Broken.xml: 1 error, 1 warning",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q in the output, got %q", expected, stdout.String())
+		}
+	}
+
+	// Warnings are left out with -q
+	stdout.Reset()
+	Run([]string{"pap", "lint", "-q", path}, &stdout, &stderr)
+	if strings.Contains(stdout.String(), "never called") {
+		t.Errorf("Expected no warnings with -q, got %q", stdout.String())
+	}
+}
+
+func TestPAPLintUnsupportedYear(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"pap", "lint", "-year", "1999"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unsupported tax year 1999") {
+		t.Errorf("Expected the unsupported year in the error, got %q", stderr.String())
+	}
+}
+
+func TestPAPDiff(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"pap", "diff", "2024Version1", "2025"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, expected := range []string{
+		"2024Version1 → 2025Version1\n",
+		"  - INPUT VMT: BigDecimal\n",
+		"  ~ GFB in MPARA: 11604 → 12096\n",
+		"  ~ UPTAB24 → UPTAB25\n",
+		"  - MVMT\n",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q in the output, got %q", expected, stdout.String())
+		}
+	}
+
+	stdout.Reset()
+	Run([]string{"pap", "diff", "2025", "2025Version1"}, &stdout, &stderr)
+	if expected := "2025Version1 → 2025Version1\n\n0 changes\n"; stdout.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestPAPDiffErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		{[]string{"pap", "diff", "2025"}, 2, "usage: steuergo pap lint"},
+		{[]string{"pap", "diff", "1999", "2025"}, 1, "unsupported tax year 1999"},
+		{[]string{"pap", "diff", "2024Version9", "2025"}, 1, `unknown PAP version "2024Version9"`},
+		{[]string{"pap", "diff", "2025", "missing.xml"}, 1, "missing.xml"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := Run(tt.args, &stdout, &stderr); code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d", tt.args, tt.code, code)
+		}
+		if !strings.Contains(stderr.String(), tt.expected) {
+			t.Errorf("%v: expected %q in the error, got %q", tt.args, tt.expected, stderr.String())
+		}
+	}
+}
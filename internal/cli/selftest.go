@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/calculation"
+)
+
+// runSelftest runs the Prüftabelle of every PAP version through the local
+// engine and reports each output that differs from the table. A version
+// without a table fails.
+func runSelftest(args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("selftest", stderr)
+	year := flags.Int("year", 0, "test the PAPs of one tax year only (default: all supported years)")
+	tables := flags.String("tables", "", "read the tables from `DIR`, one VERSION.csv per PAP version (default: the embedded ones)")
+	verbose := flags.Bool("v", false, "list the cases that pass as well")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errUsage
+	}
+
+	years := bmf.DefaultPAPRegistry.Years()
+	if *year != 0 {
+		if _, err := bmf.DefaultPAPRegistry.Version(*year); err != nil {
+			return err
+		}
+		years = []int{*year}
+	}
+
+	calculator := calculation.GetLocalTaxCalculator()
+	if err := calculator.Initialize(); err != nil {
+		return err
+	}
+
+	failed := false
+	for _, y := range years {
+		for _, version := range bmf.DefaultPAPRegistry.Versions(y) {
+			if err := selftestVersion(calculator, version, *tables, *verbose, stdout); err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", version.Version, err)
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// selftestVersion runs the test table of one PAP version, from the embedded
+// ones or those in dir, with the PAP the local engine uses, e.g. one from
+// STEUERGO_PAP_DIR
+func selftestVersion(calculator *calculation.LocalTaxCalculator, version bmf.PAPVersion, dir string, verbose bool, stdout io.Writer) error {
+	papData, err := bmf.DefaultPAPRegistry.LoadVersion(version)
+	if err != nil {
+		return err
+	}
+	var table *bmf.TestTable
+	if dir != "" {
+		table, err = bmf.LoadTestTableDir(dir, version, papData)
+	} else {
+		table, err = bmf.LoadTestTable(version, papData)
+	}
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for _, result := range calculator.RunTestTable(version, table) {
+		prefix := fmt.Sprintf("%s line %d (%s)", version.Version, result.Case.Line, result.Case)
+		switch {
+		case result.Err != nil:
+			fmt.Fprintf(stdout, "%s: %v\n", prefix, result.Err)
+		case len(result.Mismatches) > 0:
+			for _, mismatch := range result.Mismatches {
+				fmt.Fprintf(stdout, "%s: %s\n", prefix, mismatch)
+			}
+		default:
+			if verbose {
+				fmt.Fprintf(stdout, "%s: ok\n", prefix)
+			}
+			continue
+		}
+		failures++
+	}
+
+	fmt.Fprintf(stdout, "%s: %s, %d failed\n", version.Version, plural(len(table.Cases), "case"), failures)
+	if failures > 0 {
+		return fmt.Errorf("%s of the test table failed", plural(failures, "case"))
+	}
+	return nil
+}
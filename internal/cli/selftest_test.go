@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+// writeTestTable writes the test table of 2025Version1 to a directory
+func writeTestTable(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "2025Version1.csv"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// Without the Prüftabellen of the BMF there is nothing to test against
+func TestSelftestWithoutTables(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"selftest", "-year", "2025"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if expected := "2025Version1: no test table for PAP 2025Version1\n"; !strings.Contains(stderr.String(), expected) {
+		t.Errorf("Expected %q, got %q", expected, stderr.String())
+	}
+}
+
+func TestSelftestTablesDir(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+	dir := writeTestTable(t, "# no tax on no wage\nLZZ,STKL,RE4,LSTLZZ,SOLZLZZ\n1,1,0,0,0\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"selftest", "-year", "2025", "-tables", dir, "-v"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	for _, expected := range []string{"2025Version1 line 3 (LZZ=1 STKL=1 RE4=0): ok\n", "2025Version1: 1 case, 0 failed\n"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected %q in the output, got %q", expected, stdout.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "2024") {
+		t.Errorf("Expected only 2025 with -year, got %q", stdout.String())
+	}
+}
+
+func TestSelftestMismatch(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+	dir := writeTestTable(t, "LZZ,STKL,RE4,LSTLZZ,SOLZLZZ\n1,1,0,100,0\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"selftest", "-year", "2025", "-tables", dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if expected := "2025Version1 line 2 (LZZ=1 STKL=1 RE4=0): LSTLZZ expected 100, got 0\n"; !strings.Contains(stdout.String(), expected) {
+		t.Errorf("Expected %q in the output, got %q", expected, stdout.String())
+	}
+	if expected := "2025Version1: 1 case of the test table failed\n"; !strings.Contains(stderr.String(), expected) {
+		t.Errorf("Expected %q, got %q", expected, stderr.String())
+	}
+}
+
+func TestSelftestErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"selftest", "-year", "1999"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "unsupported tax year 1999") {
+		t.Errorf("Expected the unsupported year, got %d: %q", code, stderr.String())
+	}
+	if code := Run([]string{"selftest", "2025"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected the usage for an argument, got %d", code)
+	}
+}
@@ -1,16 +1,30 @@
 package bmf
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
-	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"tax-calculator/internal/tax/models"
 )
 
-const (
-	BaseURL = "http://www.bmf-steuerrechner.de/interface/2025Version1.xhtml"
-	APICode = "extS2025"
-)
+// APIBaseURL is the BMF interface; each PAP version has its own page below it
+const APIBaseURL = "http://www.bmf-steuerrechner.de/interface"
+
+// APIBaseURLEnv points clients at another interface than APIBaseURL, e.g.
+// http://localhost:8080/interface for steuergo mock-bmf
+const APIBaseURLEnv = "STEUERGO_BMF_URL"
+
+// DefaultAPIBaseURL returns the interface named by APIBaseURLEnv, or APIBaseURL
+func DefaultAPIBaseURL() string {
+	if baseURL := os.Getenv(APIBaseURLEnv); baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+	return APIBaseURL
+}
 
 type TaxCalculationResponse struct {
 	XMLName     xml.Name `xml:"lohnsteuer"`
@@ -40,24 +54,32 @@ type Output struct {
 	Type  string `xml:"type,attr"`
 }
 
-func CalculateTax(req models.TaxRequest) (*TaxCalculationResponse, error) {
-	url := fmt.Sprintf("%s?code=%s&LZZ=%d&RE4=%d&STKL=%d",
-		BaseURL, APICode, req.Period, req.Income, req.TaxClass)
-	resp, err := http.Get(url)
+// BuildQuery encodes a request for the BMF interface of a PAP version. Only
+// the inputs that version's PAP declares are sent.
+func BuildQuery(req models.TaxRequest, version PAPVersion) (url.Values, error) {
+	papData, err := DefaultPAPRegistry.LoadVersion(version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %s", resp.Status)
+	declared := make(map[string]bool, len(papData.Variables.Inputs.Input))
+	for _, input := range papData.Variables.Inputs.Input {
+		declared[input.Name] = true
 	}
-	var taxResponse TaxCalculationResponse
-	if err := xml.NewDecoder(resp.Body).Decode(&taxResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode XML response: %w", err)
+
+	query := url.Values{}
+	query.Set("code", version.APICode)
+	for _, input := range RequestInputs(req) {
+		if declared[input.Name] {
+			query.Set(input.Name, input.String())
+		}
 	}
+	return query, nil
+}
 
-	return &taxResponse, nil
+// CalculateTax asks the BMF interface using DefaultClient
+func CalculateTax(req models.TaxRequest) (*TaxCalculationResponse, error) {
+	return DefaultClient.CalculateTax(context.Background(), req)
 }
 
 func MustParseInt(s string) int {
@@ -67,4 +89,59 @@ func MustParseInt(s string) int {
 		return 0
 	}
 	return result
-}
\ No newline at end of file
+}
+
+// FormatOutputValue formats an output of the PAP as the BMF interface
+// returns it, a whole number in the unit of the output
+func FormatOutputValue(value interface{}) string {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case Decimal:
+		// The PAP already rounds its outputs to whole cents
+		return v.Truncate().String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// TaxOutputs returns the outputs of the response as typed values
+func (r *TaxCalculationResponse) TaxOutputs() (models.TaxOutputs, error) {
+	values := make(map[string]string, len(r.Outputs.Output))
+	for _, output := range r.Outputs.Output {
+		values[output.Name] = output.Value
+	}
+	return models.ParseTaxOutputs(values)
+}
+
+// OutputValues returns the outputs of the response by name, as Decimals
+// where they are numbers and as their text otherwise
+func (r *TaxCalculationResponse) OutputValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(r.Outputs.Output))
+	for _, output := range r.Outputs.Output {
+		if value, err := ParseDecimal(output.Value); err == nil {
+			values[output.Name] = value
+		} else {
+			values[output.Name] = output.Value
+		}
+	}
+	return values
+}
+
+// ValidationErrors returns the inputs the BMF rejected. The interface echoes
+// every input with a status, "ok" unless it is invalid, and explains a
+// rejected request in its information.
+func (r *TaxCalculationResponse) ValidationErrors() models.ValidationErrors {
+	var errs models.ValidationErrors
+	for _, input := range r.Inputs.Input {
+		status := strings.TrimSpace(input.Status)
+		if status == "" || strings.EqualFold(status, "ok") {
+			continue
+		}
+		errs = append(errs, models.ValidationError{Field: input.Name, Value: input.Value, Message: status})
+	}
+	if information := strings.TrimSpace(r.Information); len(errs) > 0 && information != "" {
+		errs = append(errs, models.ValidationError{Message: information})
+	}
+	return errs
+}
@@ -1,7 +1,9 @@
 package bmf
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -42,31 +44,16 @@ func (m *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return nil, fmt.Errorf("no mock implementation")
 }
 
+// defaultAPICode is the API code of the default year's PAP
+func defaultAPICode() string {
+	version, _ := DefaultPAPRegistry.Version(DefaultYear)
+	return version.APICode
+}
+
 // Helper function to test CalculateTax with a custom client
 func calculateTaxWithClient(client *http.Client, baseURL string, req models.TaxRequest) (*TaxCalculationResponse, error) {
-	url := fmt.Sprintf("%s?code=%s&LZZ=%d&RE4=%d&STKL=%d",
-		baseURL, APICode, req.Period, req.Income, req.TaxClass)
-	httpReq, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %s", resp.Status)
-	}
-
-	var taxResponse TaxCalculationResponse
-	if err := xml.NewDecoder(resp.Body).Decode(&taxResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode XML response: %w", err)
-	}
-
-	return &taxResponse, nil
+	return NewClient(WithHTTPClient(client), WithBaseURL(baseURL), WithRetryPolicy(NoRetry)).
+		CalculateTax(context.Background(), req)
 }
 
 func TestCalculateTax(t *testing.T) {
@@ -74,8 +61,8 @@ func TestCalculateTax(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify the request parameters
 		query := r.URL.Query()
-		if code := query.Get("code"); code != APICode {
-			t.Errorf("Expected code=%s, got %s", APICode, code)
+		if code := query.Get("code"); code != defaultAPICode() {
+			t.Errorf("Expected code=%s, got %s", defaultAPICode(), code)
 		}
 
 		lzz := query.Get("LZZ")
@@ -189,3 +176,163 @@ func TestCalculateTaxServerError(t *testing.T) {
 		t.Errorf("Expected error about API status, got: %v", err)
 	}
 }
+
+func TestCalculateTaxUnsupportedYear(t *testing.T) {
+	req := models.TaxRequest{
+		Period:   models.Year,
+		Income:   5000000,
+		TaxClass: models.TaxClass1,
+		Year:     1999,
+	}
+
+	if _, err := CalculateTax(req); !errors.Is(err, ErrUnsupportedYear) {
+		t.Errorf("Expected ErrUnsupportedYear before any request is made, got: %v", err)
+	}
+}
+
+func TestBuildQuery(t *testing.T) {
+	req := models.TaxRequest{
+		Period:   models.Year,
+		Income:   5000000,
+		TaxClass: models.TaxClass3,
+		KVZ:      2.5,
+		ZKF:      2,
+		R:        1,
+		PKV:      1,
+		PKPV:     400,
+		ENTSCH:   100000,
+	}
+
+	version2025, err := DefaultPAPRegistry.Version(2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := BuildQuery(req, version2025)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := map[string]string{
+		"code": "extS2025",
+		"LZZ":  "1",
+		"RE4":  "5000000",
+		"STKL": "3",
+		"KVZ":  "2.50",
+		"ZKF":  "2.0",
+		"R":    "1",
+		"PKV":  "1",
+		"PKPV": "40000",
+	}
+	for name, want := range expected {
+		if got := query.Get(name); got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+	if query.Has("ENTSCH") {
+		t.Error("Expected ENTSCH not to be sent to the 2025 interface, which does not know it")
+	}
+
+	version2024, err := DefaultPAPRegistry.Version(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err = BuildQuery(req, version2024)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if query.Get("code") != "extS2024" || query.Get("ENTSCH") != "100000" {
+		t.Errorf("Expected the 2024 code and ENTSCH in the 2024 query, got %v", query)
+	}
+}
+
+// The typed outputs know every output of the embedded PAPs
+func TestTaxOutputsCoverPAPs(t *testing.T) {
+	for _, version := range papVersions {
+		papData, err := LoadPAPFile("./" + version.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, output := range papData.Variables.Outputs.Output {
+			if _, ok := models.LookupOutputField(output.Name); !ok {
+				t.Errorf("%s: output %s is missing from models.OutputFields", version.Version, output.Name)
+			}
+		}
+	}
+}
+
+// recordedBMF holds answers recorded from the BMF interface
+const recordedBMF = "testdata/bmf"
+
+func TestCalculateTaxRecordedBMF(t *testing.T) {
+	fixtures, err := LoadFixtures(recordedBMF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Skip("No answers of the BMF recorded in " + recordedBMF)
+	}
+
+	client := NewClient(WithReplay(recordedBMF), WithRetryPolicy(NoRetry))
+	for _, fixture := range fixtures {
+		t.Run(fixture.URL, func(t *testing.T) {
+			version, _, err := fixture.Calculation()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := client.get(context.Background(), "http://bmf.invalid"+fixture.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response TaxCalculationResponse
+			if err := xml.Unmarshal(data, &response); err != nil {
+				t.Fatalf("Failed to decode XML response: %v", err)
+			}
+			if errs := response.ValidationErrors(); len(errs) > 0 {
+				t.Fatalf("The BMF rejected the request: %v", errs)
+			}
+			if _, err := response.TaxOutputs(); err != nil {
+				t.Errorf("TaxOutputs: %v", err)
+			}
+
+			papData, err := DefaultPAPRegistry.LoadVersion(version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs := make(map[string]bool)
+			for _, output := range papData.Variables.Outputs.Output {
+				outputs[output.Name] = true
+			}
+			for _, output := range response.Outputs.Output {
+				if !outputs[output.Name] {
+					t.Errorf("Output %s is not an output of PAP %s", output.Name, version.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	response := &TaxCalculationResponse{
+		Information: "Eingabe fehlerhaft",
+		Inputs: Inputs{Input: []Input{
+			{Name: "LZZ", Value: "1", Status: "ok"},
+			{Name: "RE4", Value: "5000000", Status: " OK "},
+			{Name: "STKL", Value: "1"},
+		}},
+	}
+	if errs := response.ValidationErrors(); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+
+	response.Inputs.Input = append(response.Inputs.Input, Input{Name: "PKV", Value: "3", Status: "Wert ungültig"})
+	errs := response.ValidationErrors()
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errs)
+	}
+	if err, ok := errs.Field("PKV"); !ok || err.Value != "3" || err.Message != "Wert ungültig" {
+		t.Errorf("Expected the error of PKV, got %+v", err)
+	}
+	if errs[1].Field != "" || errs[1].Message != "Eingabe fehlerhaft" {
+		t.Errorf("Expected the information as the last error, got %+v", errs[1])
+	}
+}
@@ -0,0 +1,278 @@
+package bmf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tax-calculator/internal/tax/models"
+)
+
+// CacheDirEnv names the directory of the disk cache instead of steuergo in
+// the user cache directory
+const CacheDirEnv = "STEUERGO_CACHE_DIR"
+
+// CacheKind tells what a cache entry holds
+type CacheKind string
+
+const (
+	// CachedResponse is an answer of the BMF interface to a TaxRequest
+	CachedResponse CacheKind = "responses"
+	// CachedPAP is a PAP file downloaded from the BMF
+	CachedPAP CacheKind = "paps"
+)
+
+// Cache keeps answers of the BMF on disk, one JSON file per entry in a
+// directory per CacheKind. The answer for a request never changes for a
+// PAP version, so responses are kept until they are purged; downloaded PAPs
+// are revalidated with their ETag and Last-Modified date. Errors reading or
+// writing the cache only cost another request.
+type Cache struct {
+	dir string
+}
+
+// NewCache returns a cache in dir, which is created on the first write
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultCacheDir returns the directory named by CacheDirEnv, or steuergo
+// in the user cache directory
+func DefaultCacheDir() (string, error) {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no cache directory: %w", err)
+	}
+	return filepath.Join(dir, "steuergo"), nil
+}
+
+// OpenDefaultCache returns the cache in DefaultCacheDir
+func OpenDefaultCache() (*Cache, error) {
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewCache(dir), nil
+}
+
+// Dir returns the directory of the cache
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// cacheEntry is the file of an entry. Key is what the file name is the hash
+// of, so a lookup can tell it found the right entry.
+type cacheEntry struct {
+	Key     string             `json:"key"`
+	Version string             `json:"version,omitempty"`
+	Request *models.TaxRequest `json:"request,omitempty"`
+	Stored  time.Time          `json:"stored"`
+	Header  map[string]string  `json:"header,omitempty"`
+	Data    string             `json:"data"`
+}
+
+func (c *Cache) path(kind CacheKind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, string(kind), hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) load(kind CacheKind, key string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(kind, key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *Cache) store(kind CacheKind, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path(kind, entry.Key), data)
+}
+
+// writeFileAtomic writes to a temporary file first, so a concurrent reader
+// never sees half of it, creating the directory if needed
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// responseKey is the URL a request goes to: the endpoint of the interface,
+// which names the client's base URL and the PAP version, and the query
+// BuildQuery encodes, with its keys sorted. Requests that send the same
+// query share an entry, and answers of another interface such as steuergo
+// mock-bmf never pass for the BMF's.
+func responseKey(endpoint string, query url.Values) string {
+	return endpoint + "?" + query.Encode()
+}
+
+// Response returns the cached answer of an endpoint to a query
+func (c *Cache) Response(endpoint string, query url.Values) (*TaxCalculationResponse, bool) {
+	entry, ok := c.load(CachedResponse, responseKey(endpoint, query))
+	if !ok {
+		return nil, false
+	}
+	var response TaxCalculationResponse
+	if err := xml.Unmarshal([]byte(entry.Data), &response); err != nil {
+		return nil, false
+	}
+	return &response, true
+}
+
+// StoreResponse caches the XML an endpoint answered a query with. The
+// version and request it was built from describe the entry.
+func (c *Cache) StoreResponse(endpoint string, query url.Values, version PAPVersion, req models.TaxRequest, data []byte) error {
+	return c.store(CachedResponse, &cacheEntry{
+		Key:     responseKey(endpoint, query),
+		Version: version.Version,
+		Request: &req,
+		Stored:  time.Now(),
+		Data:    string(data),
+	})
+}
+
+// papValidators are the headers of a download a later request sends back to
+// learn whether it changed
+var papValidators = map[string]string{"ETag": "If-None-Match", "Last-Modified": "If-Modified-Since"}
+
+// pap returns a cached download by its URL
+func (c *Cache) pap(url string) (*cacheEntry, bool) {
+	return c.load(CachedPAP, url)
+}
+
+// storePAP caches a download with its validators
+func (c *Cache) storePAP(version PAPVersion, url string, data []byte, header map[string]string) error {
+	return c.store(CachedPAP, &cacheEntry{
+		Key:     url,
+		Version: version.Version,
+		Stored:  time.Now(),
+		Header:  header,
+		Data:    string(data),
+	})
+}
+
+// CacheEntry describes an entry of the cache
+type CacheEntry struct {
+	Kind    CacheKind
+	Version string
+	// The inputs of a request that differ from an empty one, or the URL of
+	// a PAP
+	Description string
+	Stored      time.Time
+	Size        int64
+	// Why the entry could not be read, which makes it a miss; its
+	// Description is then the file
+	Err error
+}
+
+// Entries lists the entries of the cache, oldest first. Entries that cannot
+// be read are listed with their error after the others.
+func (c *Cache) Entries() ([]CacheEntry, error) {
+	var entries []CacheEntry
+	for _, kind := range []CacheKind{CachedResponse, CachedPAP} {
+		files, err := filepath.Glob(filepath.Join(c.dir, string(kind), "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			var entry cacheEntry
+			if err == nil {
+				err = json.Unmarshal(data, &entry)
+			}
+			if err != nil {
+				entries = append(entries, CacheEntry{Kind: kind, Description: file, Size: int64(len(data)), Err: err})
+				continue
+			}
+
+			description := entry.Key
+			if entry.Request != nil {
+				description = describeRequest(*entry.Request)
+			}
+			entries = append(entries, CacheEntry{
+				Kind:        kind,
+				Version:     entry.Version,
+				Description: description,
+				Stored:      entry.Stored,
+				Size:        int64(len(data)),
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if (entries[i].Err == nil) != (entries[j].Err == nil) {
+			return entries[i].Err == nil
+		}
+		return entries[i].Stored.Before(entries[j].Stored)
+	})
+	return entries, nil
+}
+
+// describeRequest lists the inputs of a request that differ from those of an
+// empty one, e.g. "LZZ=1 RE4=5000000 STKL=1"
+func describeRequest(req models.TaxRequest) string {
+	defaults := RequestInputs(models.TaxRequest{})
+	var inputs []string
+	for i, input := range RequestInputs(req) {
+		if value := input.String(); value != defaults[i].String() {
+			inputs = append(inputs, input.Name+"="+value)
+		}
+	}
+	return strings.Join(inputs, " ")
+}
+
+// Purge removes the entries of the given kinds, or all of them, and returns
+// how many it removed
+func (c *Cache) Purge(kinds ...CacheKind) (int, error) {
+	if len(kinds) == 0 {
+		kinds = []CacheKind{CachedResponse, CachedPAP}
+	}
+
+	removed := 0
+	for _, kind := range kinds {
+		files, err := filepath.Glob(filepath.Join(c.dir, string(kind), "*.json"))
+		if err != nil {
+			return removed, err
+		}
+		for _, file := range files {
+			if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
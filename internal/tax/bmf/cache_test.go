@@ -0,0 +1,208 @@
+package bmf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"tax-calculator/internal/tax/models"
+)
+
+func TestCacheResponses(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	cache := NewCache(t.TempDir())
+	client := NewClient(WithBaseURL(server.URL), WithCache(cache))
+
+	requests := []models.TaxRequest{
+		clientRequest,
+		clientRequest,
+		// The default year is the same request
+		{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1},
+		// The 2025 PAP has no ENTSCH, so the same query is sent
+		{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, Year: 2025, ENTSCH: 12},
+	}
+	for _, req := range requests {
+		result, err := client.CalculateTax(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(result.Outputs.Output) != 1 || result.Outputs.Output[0].Value != "123456" {
+			t.Errorf("Unexpected response: %+v", result)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected 1 request to the BMF, got %d", got)
+	}
+
+	other := clientRequest
+	other.Income++
+	if _, err := client.CalculateTax(context.Background(), other); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Expected another request for another income, got %d", got)
+	}
+
+	// The cache outlives the client
+	client = NewClient(WithBaseURL(server.URL), WithCache(NewCache(cache.Dir())))
+	if _, err := client.CalculateTax(context.Background(), clientRequest); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Expected the answer from disk, got %d requests", got)
+	}
+
+	// Another interface has answers of its own
+	var otherCalls int32
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&otherCalls, 1)
+		w.Write([]byte(mockResponse))
+	}))
+	defer otherServer.Close()
+	client = NewClient(WithBaseURL(otherServer.URL), WithCache(cache))
+	if _, err := client.CalculateTax(context.Background(), clientRequest); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&otherCalls); got != 1 {
+		t.Errorf("Expected a request to the other interface, got %d", got)
+	}
+}
+
+func TestCacheFetchPAP(t *testing.T) {
+	var calls, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Dec 2024 10:00:00 GMT")
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") != "" {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(nestedPAP))
+	}))
+
+	version, err := DefaultPAPRegistry.Resolve(2024, "2024Version2")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	client := NewClient(WithPAPSourceURL(server.URL), WithCache(NewCache(t.TempDir())), WithRetryPolicy(NoRetry))
+
+	for i := 0; i < 2; i++ {
+		pap, err := client.FetchPAP(context.Background(), version)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if pap.Name == "" {
+			t.Error("Expected a parsed PAP")
+		}
+	}
+	if calls != 2 || notModified != 1 {
+		t.Errorf("Expected a download and a revalidation, got %d requests and %d not modified", calls, notModified)
+	}
+
+	// Without the BMF the cached PAP is used
+	server.Close()
+	if _, err := client.FetchPAP(context.Background(), version); err != nil {
+		t.Errorf("Expected the cached PAP, got: %v", err)
+	}
+}
+
+func TestCacheEntriesAndPurge(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"))
+
+	entries, err := cache.Entries()
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty cache, got %v (%v)", entries, err)
+	}
+	if removed, err := cache.Purge(); err != nil || removed != 0 {
+		t.Errorf("Expected nothing to purge, got %d (%v)", removed, err)
+	}
+
+	version, _ := DefaultPAPRegistry.Resolve(2025, "")
+	query, err := BuildQuery(clientRequest, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "https://example.com/interface/2025Version1.xhtml"
+	if err := cache.StoreResponse(endpoint, query, version, clientRequest, []byte(mockResponse)); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if err := cache.storePAP(version, "https://example.com/Lohnsteuer2025.xml.xhtml", []byte(nestedPAP), nil); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	entries, err = cache.Entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v (%v)", entries, err)
+	}
+	if entries[0].Kind != CachedResponse || entries[0].Version != "2025Version1" || entries[0].Description != "LZZ=1 RE4=5000000 STKL=1" || entries[0].Size == 0 {
+		t.Errorf("Unexpected response entry: %+v", entries[0])
+	}
+	if entries[1].Kind != CachedPAP || entries[1].Description != "https://example.com/Lohnsteuer2025.xml.xhtml" {
+		t.Errorf("Unexpected PAP entry: %+v", entries[1])
+	}
+
+	if removed, err := cache.Purge(CachedResponse); err != nil || removed != 1 {
+		t.Errorf("Expected 1 response purged, got %d (%v)", removed, err)
+	}
+	if _, ok := cache.Response(endpoint, query); ok {
+		t.Error("Expected the response to be gone")
+	}
+	if removed, err := cache.Purge(); err != nil || removed != 1 {
+		t.Errorf("Expected the PAP purged, got %d (%v)", removed, err)
+	}
+}
+
+func TestCacheIgnoresBrokenEntries(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	version, _ := DefaultPAPRegistry.Resolve(2025, "")
+
+	query, err := BuildQuery(clientRequest, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "https://example.com/interface/2025Version1.xhtml"
+	path := cache.path(CachedResponse, responseKey(endpoint, query))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Response(endpoint, query); ok {
+		t.Error("Expected a broken entry to be a miss")
+	}
+
+	// The listing goes on past it and reports it last
+	if err := cache.storePAP(version, "https://example.com/Lohnsteuer2025.xml.xhtml", []byte(nestedPAP), nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := cache.Entries()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v (%v)", entries, err)
+	}
+	if entries[0].Kind != CachedPAP || entries[0].Err != nil {
+		t.Errorf("Expected the PAP entry first, got %+v", entries[0])
+	}
+	if entries[1].Err == nil || entries[1].Description != path {
+		t.Errorf("Expected the broken entry with its file and error, got %+v", entries[1])
+	}
+}
+
+func TestDefaultCacheDir(t *testing.T) {
+	t.Setenv(CacheDirEnv, "/tmp/steuergo-cache")
+	if dir, err := DefaultCacheDir(); err != nil || dir != "/tmp/steuergo-cache" {
+		t.Errorf("Expected the directory from %s, got %q (%v)", CacheDirEnv, dir, err)
+	}
+}
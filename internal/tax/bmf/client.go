@@ -0,0 +1,400 @@
+package bmf
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tax-calculator/internal/tax/models"
+)
+
+const (
+	// PAPSourceBaseURL is where the BMF publishes the PAP XML files
+	PAPSourceBaseURL = "https://www.bmf-steuerrechner.de/javax.faces.resource/daten/xmls"
+
+	// DefaultTimeout bounds a single request to the BMF
+	DefaultTimeout = 15 * time.Second
+)
+
+// RetryPolicy controls how often a failed request is repeated. Only network
+// errors and 429/5xx answers are retried; the wait doubles after every
+// attempt, up to MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     4 * time.Second,
+}
+
+// NoRetry makes a single attempt
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// Client talks to the BMF web service
+type Client struct {
+	httpClient   *http.Client
+	baseURL      string
+	papSourceURL string
+	timeout      time.Duration
+	retry        RetryPolicy
+	cache        *Cache
+
+	// Fixture directories, see WithRecording and WithReplay
+	record string
+	replay string
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBaseURL points the client at another interface, e.g. a test server
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithPAPSourceURL sets where FetchPAP downloads PAP files from
+func WithPAPSourceURL(sourceURL string) ClientOption {
+	return func(c *Client) {
+		c.papSourceURL = strings.TrimRight(sourceURL, "/")
+	}
+}
+
+// WithTimeout bounds each attempt; zero means no limit besides the context
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithCache keeps the answers of the BMF in a Cache: responses to requests it
+// answered before, and downloaded PAPs, which are revalidated and used as
+// they are when the BMF cannot be reached. Nil disables the cache.
+func WithCache(cache *Cache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		httpClient:   http.DefaultClient,
+		baseURL:      DefaultAPIBaseURL(),
+		papSourceURL: PAPSourceBaseURL,
+		timeout:      DefaultTimeout,
+		retry:        DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch {
+	case c.replay != "" && c.record != "":
+		err := fmt.Errorf("%w (recording to %s, replaying from %s)", ErrRecordAndReplay, c.record, c.replay)
+		c.httpClient = withTransport(c.httpClient, refusal{err: err})
+	case c.replay != "":
+		c.httpClient = withTransport(c.httpClient, &replayer{dir: c.replay})
+	case c.record != "":
+		transport := c.httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		c.httpClient = withTransport(c.httpClient, &recorder{dir: c.record, transport: transport})
+	}
+	return c
+}
+
+// withTransport returns a copy of an HTTP client sending its requests
+// through another transport
+func withTransport(httpClient *http.Client, transport http.RoundTripper) *http.Client {
+	copied := *httpClient
+	copied.Transport = transport
+	return &copied
+}
+
+// RecordDirEnv and ReplayDirEnv name fixture directories the app records
+// its traffic with the BMF to, or replays it from
+const (
+	RecordDirEnv = "STEUERGO_BMF_RECORD"
+	ReplayDirEnv = "STEUERGO_BMF_REPLAY"
+)
+
+// FixtureOptionsFromEnv returns WithRecording or WithReplay for the
+// directory in RecordDirEnv or ReplayDirEnv, if one is set. Both set is an
+// ErrRecordAndReplay.
+func FixtureOptionsFromEnv() ([]ClientOption, error) {
+	record, replay := os.Getenv(RecordDirEnv), os.Getenv(ReplayDirEnv)
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("%w: set %s or %s, not both", ErrRecordAndReplay, RecordDirEnv, ReplayDirEnv)
+	case record != "":
+		return []ClientOption{WithRecording(record)}, nil
+	case replay != "":
+		return []ClientOption{WithReplay(replay)}, nil
+	}
+	return nil, nil
+}
+
+// WithRecording keeps every request to the BMF and its answer as a Fixture
+// in dir, to replay them later with WithReplay. Combined with WithReplay,
+// every request fails with ErrRecordAndReplay.
+func WithRecording(dir string) ClientOption {
+	return func(c *Client) {
+		c.record = dir
+	}
+}
+
+// WithReplay answers every request from the fixtures in dir and never asks
+// the BMF. A request without a fixture fails with ErrNoFixture. Combined with
+// WithRecording, every request fails with ErrRecordAndReplay.
+func WithReplay(dir string) ClientOption {
+	return func(c *Client) {
+		c.replay = dir
+	}
+}
+
+// DefaultClient is used by the package-level functions
+var DefaultClient = NewClient()
+
+// Endpoint returns the URL of the interface for a PAP version
+func (c *Client) Endpoint(version PAPVersion) string {
+	return fmt.Sprintf("%s/%s.xhtml", c.baseURL, version.Version)
+}
+
+// CalculateTax asks the BMF interface for the tax on a request. If the BMF
+// rejects inputs of the request, the error is a models.ValidationErrors.
+func (c *Client) CalculateTax(ctx context.Context, req models.TaxRequest) (*TaxCalculationResponse, error) {
+	version, err := ResolvePAPVersion(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := BuildQuery(req, version)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := c.Endpoint(version)
+	if c.cache != nil {
+		if cached, ok := c.cache.Response(endpoint, query); ok {
+			return cached, nil
+		}
+	}
+
+	data, err := c.get(ctx, endpoint+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var taxResponse TaxCalculationResponse
+	if err := xml.Unmarshal(data, &taxResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode XML response: %w", err)
+	}
+	// A rejected request answers with zeros, which must not pass for a result
+	if errs := taxResponse.ValidationErrors(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	if c.cache != nil {
+		c.cache.StoreResponse(endpoint, query, version, req, data)
+	}
+	return &taxResponse, nil
+}
+
+// FetchPAP downloads and parses the PAP of a version from the BMF
+func (c *Client) FetchPAP(ctx context.Context, version PAPVersion) (*PAPData, error) {
+	url := fmt.Sprintf("%s/%s.xhtml", c.papSourceURL, filepath.Base(version.File))
+	if c.cache == nil {
+		data, err := c.get(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch XML: %w", err)
+		}
+		return ParsePAP(data)
+	}
+
+	// Ask for the PAP only if it changed since it was cached
+	cached, ok := c.cache.pap(url)
+	header := make(http.Header)
+	if ok {
+		for validator, conditional := range papValidators {
+			if value := cached.Header[validator]; value != "" {
+				header.Set(conditional, value)
+			}
+		}
+	}
+
+	resp, err := c.fetch(ctx, url, header)
+	switch {
+	case err != nil && ok:
+		return ParsePAP([]byte(cached.Data))
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch XML: %w", err)
+	case resp.notModified:
+		// Only conditional requests, sent for a cached PAP, get a 304
+		return ParsePAP([]byte(cached.Data))
+	}
+
+	papData, err := ParsePAP(resp.data)
+	if err != nil {
+		return nil, err
+	}
+	validators := make(map[string]string)
+	for validator := range papValidators {
+		if value := resp.header.Get(validator); value != "" {
+			validators[validator] = value
+		}
+	}
+	c.cache.storePAP(version, url, resp.data, validators)
+	return papData, nil
+}
+
+// statusError is a non-200 answer
+type statusError struct {
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("API request failed with status: %s", e.status)
+}
+
+func (e *statusError) temporary() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+// response is a successful answer; notModified is set for a 304 to a
+// conditional request
+type response struct {
+	data        []byte
+	header      http.Header
+	notModified bool
+}
+
+// get fetches a URL, retrying temporary failures per the retry policy
+func (c *Client) get(ctx context.Context, url string) ([]byte, error) {
+	resp, err := c.fetch(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.data, nil
+}
+
+// fetch is get sending extra headers and returning those of the answer
+func (c *Client) fetch(ctx context.Context, url string, header http.Header) (*response, error) {
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := c.retry.InitialBackoff
+
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		var resp *response
+		resp, err = c.getOnce(ctx, url, header)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= attempts || !retryable(ctx, err) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if c.retry.MaxBackoff > 0 && backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+
+	if attempt > 1 {
+		return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+	}
+	return nil, err
+}
+
+func (c *Client) getOnce(ctx context.Context, url string, header http.Header) (*response, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	for name, values := range header {
+		httpReq.Header[name] = values
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && len(header) > 0 {
+		return &response{header: resp.Header, notModified: true}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{status: resp.Status, code: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &response{data: data, header: resp.Header}, nil
+}
+
+// retryable reports whether another attempt could succeed. The caller giving
+// up, an unknown host, a missing fixture and a client told to both record
+// and replay are final; a timed-out attempt is not.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrNoFixture) || errors.Is(err, ErrRecordAndReplay) {
+		return false
+	}
+
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.temporary()
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+package bmf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tax-calculator/internal/tax/models"
+)
+
+const mockResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<lohnsteuer jahr="2025">
+	<information>Mock tax response</information>
+	<ausgaben>
+		<ausgabe name="LSTLZZ" value="123456" type="STANDARD"/>
+	</ausgaben>
+</lohnsteuer>`
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+var clientRequest = models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, Year: 2025}
+
+func TestClientCalculateTax(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2025Version1.xhtml" {
+			t.Errorf("Expected path /2025Version1.xhtml, got %s", r.URL.Path)
+		}
+		if code := r.URL.Query().Get("code"); code != "extS2025" {
+			t.Errorf("Expected code extS2025, got %s", code)
+		}
+		w.Write([]byte(mockResponse))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL+"/"), WithHTTPClient(server.Client()))
+	result, err := client.CalculateTax(context.Background(), clientRequest)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Year != "2025" || len(result.Outputs.Output) != 1 {
+		t.Errorf("Unexpected response: %+v", result)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		status        int
+		expectedCalls int32
+		expectError   bool
+	}{
+		{"server error then success", 2, http.StatusInternalServerError, 3, false},
+		{"rate limited then success", 1, http.StatusTooManyRequests, 2, false},
+		{"server error every time", 5, http.StatusBadGateway, 3, true},
+		{"bad request is final", 5, http.StatusBadRequest, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(atomic.AddInt32(&calls, 1)) <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Write([]byte(mockResponse))
+			}))
+			defer server.Close()
+
+			client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+			_, err := client.CalculateTax(context.Background(), clientRequest)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error %v, got: %v", tt.expectError, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "API request failed with status") {
+				t.Errorf("Expected the status in the error, got: %v", err)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, got)
+			}
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(WithBaseURL(server.URL), WithTimeout(20*time.Millisecond), WithRetryPolicy(NoRetry))
+
+	start := time.Now()
+	_, err := client.CalculateTax(context.Background(), clientRequest)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the timeout to end the request, took %s", elapsed)
+	}
+}
+
+func TestClientCancelledContext(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewClient(WithBaseURL(server.URL), WithRetryPolicy(fastRetry))
+	if _, err := client.CalculateTax(ctx, clientRequest); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Errorf("Expected no request after cancellation, got %d", got)
+	}
+}
+
+func TestClientFetchPAP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/Lohnsteuer2024Version2.xml.xhtml" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(nestedPAP))
+	}))
+	defer server.Close()
+
+	version, err := DefaultPAPRegistry.Resolve(2024, "2024Version2")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	client := NewClient(WithPAPSourceURL(server.URL))
+	pap, err := client.FetchPAP(context.Background(), version)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if pap.Name == "" {
+		t.Error("Expected a parsed PAP")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		expected bool
+	}{
+		{"server error", context.Background(), &statusError{code: http.StatusServiceUnavailable}, true},
+		{"client error", context.Background(), &statusError{code: http.StatusNotFound}, false},
+		{"unknown host", context.Background(), &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"connection refused", context.Background(), errors.New("connection refused"), true},
+		{"cancelled by caller", cancelled, errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.ctx, tt.err); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClientCalculateTaxValidationErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<lohnsteuer jahr="2025">
+	<information>Fehlerhafte Eingabe</information>
+	<eingaben>
+		<eingabe name="LZZ" value="1" status="ok"/>
+		<eingabe name="KRV" value="7" status="Eingabe ist nicht zulässig"/>
+	</eingaben>
+	<ausgaben>
+		<ausgabe name="LSTLZZ" value="0" type="STANDARD"/>
+	</ausgaben>
+</lohnsteuer>`))
+	}))
+	defer server.Close()
+
+	cache := NewCache(t.TempDir())
+	client := NewClient(WithBaseURL(server.URL+"/"), WithHTTPClient(server.Client()), WithCache(cache))
+	result, err := client.CalculateTax(context.Background(), clientRequest)
+	if result != nil {
+		t.Errorf("Expected no result, got %+v", result)
+	}
+
+	var errs models.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected validation errors, got: %v", err)
+	}
+	expected := models.ValidationErrors{
+		{Field: "KRV", Value: "7", Message: "Eingabe ist nicht zulässig"},
+		{Message: "Fehlerhafte Eingabe"},
+	}
+	if len(errs) != len(expected) || errs[0] != expected[0] || errs[1] != expected[1] {
+		t.Errorf("Expected %+v, got %+v", expected, errs)
+	}
+
+	// The zeros of a rejected request are not kept
+	if entries, _ := cache.Entries(); len(entries) != 0 {
+		t.Errorf("Expected an empty cache, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,640 @@
+package bmf
+
+import (
+	"context"
+	"fmt"
+)
+
+// code is a PAP compiled for execution. Every variable and constant has a
+// slot in a flat slice, literals and constant subexpressions are evaluated
+// once at compile time and EXECUTE statements point straight at the method
+// they call.
+type code struct {
+	names   []string
+	initial []interface{}
+	slots   map[string]int
+	inputs  []compiledInput
+	outputs []int
+	main    *compiledMethod
+}
+
+type compiledInput struct {
+	name      string
+	valueType string
+	slot      int
+}
+
+type compiledMethod struct {
+	name string
+	body []compiledStatement
+}
+
+// compiledStatement is one statement of a compiled method. Which fields are
+// used depends on the kind: EXECUTE calls method, EVAL stores value in
+// target, IF runs then or els depending on cond, and COMPARE stores cond in
+// target.
+type compiledStatement struct {
+	kind   OperationType
+	source Statement
+
+	method *compiledMethod
+	target int
+	value  evalFunc
+	cond   condFunc
+
+	then []compiledStatement
+	els  []compiledStatement
+}
+
+// frame holds the variables of one run of compiled code
+type frame struct {
+	slots []interface{}
+	guard guard
+}
+
+type evalFunc func(f *frame) (interface{}, error)
+
+type condFunc func(f *frame) (bool, error)
+
+// Compile checks a PAP and turns it into a program that runs compiled code.
+// Unlike NewProgram it fails up front on calls to unknown methods and on
+// statements the evaluator cannot run.
+func Compile(papData *PAPData, opts ...ProgramOption) (*Program, error) {
+	program := NewProgram(papData, opts...)
+
+	compiled, err := compile(papData)
+	if err != nil {
+		name := papData.Name
+		if name == "" {
+			name = "PAP"
+		}
+		return nil, fmt.Errorf("failed to compile %s: %w", name, err)
+	}
+	program.code = compiled
+
+	return program, nil
+}
+
+type compiler struct {
+	code    *code
+	methods map[string]*compiledMethod
+}
+
+func compile(papData *PAPData) (*code, error) {
+	if len(papData.Methods.Main) == 0 {
+		return nil, fmt.Errorf("no main method found in XML data")
+	}
+
+	c := &compiler{
+		code:    &code{slots: make(map[string]int)},
+		methods: make(map[string]*compiledMethod),
+	}
+
+	// Slots are taken in the order in which the interpreter resolves names
+	for _, input := range papData.Variables.Inputs.Input {
+		slot := c.declare(input.Name, initialValue(input.Type, input.Default))
+		c.code.inputs = append(c.code.inputs, compiledInput{name: input.Name, valueType: input.Type, slot: slot})
+	}
+	for _, output := range papData.Variables.Outputs.Output {
+		c.code.outputs = append(c.code.outputs, c.declare(output.Name, initialValue(output.Type, output.Default)))
+	}
+	for _, internal := range papData.Variables.Internals.Internal {
+		c.declare(internal.Name, initialValue(internal.Type, internal.Default))
+	}
+	for _, constant := range papData.Constants.Constant {
+		c.declare(constant.Name, parseValue(constant.Type, constant.Value))
+	}
+
+	// Create every method before compiling bodies, so calls can refer to
+	// methods defined further down
+	for _, method := range papData.Methods.Method {
+		if _, ok := c.methods[method.Name]; !ok {
+			c.methods[method.Name] = &compiledMethod{name: method.Name}
+		}
+	}
+
+	for _, method := range papData.Methods.Method {
+		compiled := c.methods[method.Name]
+		if compiled.body != nil {
+			continue
+		}
+		body, err := c.statements(method.Statements)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", method.Name, err)
+		}
+		compiled.body = body
+	}
+
+	main, err := c.statements(papData.Methods.Main[0].Statements)
+	if err != nil {
+		return nil, fmt.Errorf("MAIN: %w", err)
+	}
+	c.code.main = &compiledMethod{name: "MAIN", body: main}
+
+	return c.code, nil
+}
+
+// declare gives a variable its slot. A name declared twice keeps its first slot.
+func (c *compiler) declare(name string, initial interface{}) int {
+	if slot, ok := c.code.slots[name]; ok {
+		return slot
+	}
+	slot := len(c.code.names)
+	c.code.slots[name] = slot
+	c.code.names = append(c.code.names, name)
+	c.code.initial = append(c.code.initial, initial)
+	return slot
+}
+
+// slot returns the slot of a variable. Undeclared names get an empty slot:
+// assigning creates them like the interpreter's internal variables, and
+// reading them before that fails at run time.
+func (c *compiler) slot(name string) int {
+	return c.declare(name, nil)
+}
+
+func (c *compiler) statements(statements []Statement) ([]compiledStatement, error) {
+	compiled := make([]compiledStatement, 0, len(statements))
+	for _, statement := range statements {
+		if _, ok := statement.(*BausteinFinishStatement); ok {
+			continue
+		}
+		s, err := c.statement(statement)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, s)
+	}
+	return compiled, nil
+}
+
+func (c *compiler) statement(statement Statement) (compiledStatement, error) {
+	compiled := compiledStatement{kind: statement.Kind(), source: statement}
+
+	switch s := statement.(type) {
+	case *ExecuteStatement:
+		method, ok := c.methods[s.Method]
+		if !ok {
+			return compiled, fmt.Errorf("method %s not found", s.Method)
+		}
+		compiled.method = method
+
+	case *EvalStatement:
+		if s.Assignment != nil {
+			value, _, err := c.expr(s.Assignment.Value)
+			if err != nil {
+				return compiled, fmt.Errorf("%q: %w", s.Exec, err)
+			}
+
+			switch target := s.Assignment.Target.(type) {
+			case *Ident:
+				compiled.target = c.slot(target.Name)
+				compiled.value = value
+			case *IndexExpr:
+				array, ok := target.Array.(*Ident)
+				if !ok {
+					return compiled, fmt.Errorf("unsupported assignment target %s", target.String())
+				}
+				index, _, err := c.expr(target.Index)
+				if err != nil {
+					return compiled, fmt.Errorf("%q: %w", s.Exec, err)
+				}
+				compiled.target = c.slot(array.Name)
+				compiled.value = element(c.variable(array.Name), index, value)
+			default:
+				return compiled, fmt.Errorf("unsupported assignment target %s", target.String())
+			}
+			break
+		}
+
+		left, right := c.operand(s.Left), c.operand(s.Right)
+		op := s.Op
+		compiled.target = c.slot(s.Target)
+		if op == EvalAssign {
+			compiled.value = func(f *frame) (interface{}, error) {
+				value, err := right(f)
+				if err != nil {
+					return nil, fmt.Errorf("right operand error: %w", err)
+				}
+				return value, nil
+			}
+			break
+		}
+		compiled.value = func(f *frame) (interface{}, error) {
+			leftVal, rightVal, err := operands(f, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return applyOperation(leftVal, rightVal, op)
+		}
+
+	case *IfStatement:
+		if s.Condition != nil {
+			cond, err := c.condition(s.Condition)
+			if err != nil {
+				return compiled, fmt.Errorf("%q: %w", s.Expr, err)
+			}
+			compiled.cond = cond
+		} else {
+			compiled.cond = c.comparison(s.Left, s.Right, s.Op)
+		}
+
+		var err error
+		if compiled.then, err = c.statements(s.Then); err != nil {
+			return compiled, err
+		}
+		if compiled.els, err = c.statements(s.Else); err != nil {
+			return compiled, err
+		}
+
+	case *CompareStatement:
+		compiled.target = c.slot(s.Target)
+		compiled.cond = c.comparison(s.Left, s.Right, s.Op)
+
+	default:
+		return compiled, fmt.Errorf("unsupported statement %T", statement)
+	}
+
+	return compiled, nil
+}
+
+// element compiles the value stored by an assignment to an array element:
+// the array with the element at index replaced
+func element(array, index, value evalFunc) evalFunc {
+	return func(f *frame) (interface{}, error) {
+		v, err := value(f)
+		if err != nil {
+			return nil, err
+		}
+		current, err := array(f)
+		if err != nil {
+			return nil, err
+		}
+		i, err := index(f)
+		if err != nil {
+			return nil, err
+		}
+		return setIndex(current, i, v)
+	}
+}
+
+// operand compiles a left or right attribute of a legacy element, which is
+// a variable name or a literal
+func (c *compiler) operand(name string) evalFunc {
+	if _, declared := c.code.slots[name]; !declared {
+		if value, ok := literalValue(name); ok {
+			return constant(value)
+		}
+	}
+	return c.variable(name)
+}
+
+func operands(f *frame, left, right evalFunc) (interface{}, interface{}, error) {
+	leftVal, err := left(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("left operand error: %w", err)
+	}
+	rightVal, err := right(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("right operand error: %w", err)
+	}
+	return leftVal, rightVal, nil
+}
+
+func (c *compiler) comparison(left, right string, op ComparisonOperator) condFunc {
+	leftVal, rightVal := c.operand(left), c.operand(right)
+	return func(f *frame) (bool, error) {
+		l, r, err := operands(f, leftVal, rightVal)
+		if err != nil {
+			return false, err
+		}
+		return compareValues(l, r, op)
+	}
+}
+
+func (c *compiler) condition(expr Expr) (condFunc, error) {
+	value, _, err := c.expr(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *frame) (bool, error) {
+		result, err := value(f)
+		if err != nil {
+			return false, err
+		}
+		b, ok := result.(bool)
+		if !ok {
+			return false, fmt.Errorf("condition %s is not boolean: %T", expr.String(), result)
+		}
+		return b, nil
+	}, nil
+}
+
+func (c *compiler) variable(name string) evalFunc {
+	slot := c.slot(name)
+	return func(f *frame) (interface{}, error) {
+		if value := f.slots[slot]; value != nil {
+			return value, nil
+		}
+		return nil, fmt.Errorf("variable %s not found", name)
+	}
+}
+
+func constant(value interface{}) evalFunc {
+	return func(*frame) (interface{}, error) {
+		return value, nil
+	}
+}
+
+// expr compiles an expression. The second result reports whether it does
+// not depend on any variable; such expressions are evaluated right away.
+func (c *compiler) expr(expr Expr) (evalFunc, bool, error) {
+	fn, isConst, err := c.exprNode(expr)
+	if err != nil || !isConst {
+		return fn, isConst, err
+	}
+
+	// Errors such as a division by zero are left for run time, where the
+	// interpreter would report them too
+	if value, err := fn(nil); err == nil {
+		return constant(value), true, nil
+	}
+	return fn, false, nil
+}
+
+func (c *compiler) exprNode(expr Expr) (evalFunc, bool, error) {
+	switch e := expr.(type) {
+	case *NumberLit:
+		value, err := parseNumberLiteral(e.Text)
+		if err != nil {
+			return nil, false, err
+		}
+		return constant(value), true, nil
+
+	case *BoolLit:
+		return constant(e.Value), true, nil
+
+	case *Ident:
+		if e.Name == "BigDecimal" {
+			return constant(classRef{name: e.Name}), true, nil
+		}
+		return c.variable(e.Name), false, nil
+
+	case *FieldAccess:
+		target, isConst, err := c.expr(e.Target)
+		if err != nil {
+			return nil, false, err
+		}
+		name := e.Name
+		return func(f *frame) (interface{}, error) {
+			value, err := target(f)
+			if err != nil {
+				return nil, err
+			}
+			return staticField(value, name)
+		}, isConst, nil
+
+	case *MethodCall:
+		receiver, receiverConst, err := c.expr(e.Receiver)
+		if err != nil {
+			return nil, false, err
+		}
+		args, argsConst, err := c.exprs(e.Args)
+		if err != nil {
+			return nil, false, err
+		}
+		method := e.Method
+		return func(f *frame) (interface{}, error) {
+			value, err := receiver(f)
+			if err != nil {
+				return nil, err
+			}
+			argValues, err := evalAll(f, args)
+			if err != nil {
+				return nil, err
+			}
+			return callMethod(value, method, argValues)
+		}, receiverConst && argsConst, nil
+
+	case *NewObject:
+		args, isConst, err := c.exprs(e.Args)
+		if err != nil {
+			return nil, false, err
+		}
+		typeName := e.Type
+		return func(f *frame) (interface{}, error) {
+			argValues, err := evalAll(f, args)
+			if err != nil {
+				return nil, err
+			}
+			return newObject(typeName, argValues)
+		}, isConst, nil
+
+	case *IndexExpr:
+		array, arrayConst, err := c.expr(e.Array)
+		if err != nil {
+			return nil, false, err
+		}
+		index, indexConst, err := c.expr(e.Index)
+		if err != nil {
+			return nil, false, err
+		}
+		return func(f *frame) (interface{}, error) {
+			arrayValue, err := array(f)
+			if err != nil {
+				return nil, err
+			}
+			i, err := index(f)
+			if err != nil {
+				return nil, err
+			}
+			return indexValue(arrayValue, i)
+		}, arrayConst && indexConst, nil
+
+	case *UnaryExpr:
+		operand, isConst, err := c.expr(e.Operand)
+		if err != nil {
+			return nil, false, err
+		}
+		op := e.Op
+		return func(f *frame) (interface{}, error) {
+			value, err := operand(f)
+			if err != nil {
+				return nil, err
+			}
+			return unaryOp(op, value)
+		}, isConst, nil
+
+	case *BinaryExpr:
+		left, leftConst, err := c.expr(e.Left)
+		if err != nil {
+			return nil, false, err
+		}
+		right, rightConst, err := c.expr(e.Right)
+		if err != nil {
+			return nil, false, err
+		}
+		return binaryFunc(e.Op, left, right), leftConst && rightConst, nil
+
+	case *ArrayLit:
+		elements, isConst, err := c.exprs(e.Elements)
+		if err != nil {
+			return nil, false, err
+		}
+		return func(f *frame) (interface{}, error) {
+			return evalAll(f, elements)
+		}, isConst, nil
+	}
+
+	return nil, false, fmt.Errorf("unsupported expression %T", expr)
+}
+
+func (c *compiler) exprs(exprs []Expr) ([]evalFunc, bool, error) {
+	compiled := make([]evalFunc, len(exprs))
+	allConst := true
+	for i, expr := range exprs {
+		fn, isConst, err := c.expr(expr)
+		if err != nil {
+			return nil, false, err
+		}
+		compiled[i] = fn
+		allConst = allConst && isConst
+	}
+	return compiled, allConst, nil
+}
+
+func binaryFunc(op string, left, right evalFunc) evalFunc {
+	if op == "&&" || op == "||" {
+		// Short-circuit boolean operators like Java does
+		return func(f *frame) (interface{}, error) {
+			leftValue, err := left(f)
+			if err != nil {
+				return nil, err
+			}
+			leftBool, ok := leftValue.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator %s requires boolean operands, got %T", op, leftValue)
+			}
+			if (op == "&&" && !leftBool) || (op == "||" && leftBool) {
+				return leftBool, nil
+			}
+			rightValue, err := right(f)
+			if err != nil {
+				return nil, err
+			}
+			return binaryOp(op, leftValue, rightValue)
+		}
+	}
+
+	return func(f *frame) (interface{}, error) {
+		leftValue, err := left(f)
+		if err != nil {
+			return nil, err
+		}
+		rightValue, err := right(f)
+		if err != nil {
+			return nil, err
+		}
+		return binaryOp(op, leftValue, rightValue)
+	}
+}
+
+func evalAll(f *frame, exprs []evalFunc) ([]interface{}, error) {
+	values := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		value, err := expr(f)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// run executes the compiled code on a set of inputs and returns the outputs
+func (c *code) run(ctx context.Context, limits Limits, inputs map[string]interface{}) (map[string]interface{}, error) {
+	ctx, cancel := limits.context(ctx)
+	defer cancel()
+
+	f := &frame{slots: make([]interface{}, len(c.initial)), guard: newGuard(ctx, limits)}
+	copy(f.slots, c.initial)
+
+	// Bring inputs to their declared types, e.g. an int RE4 to BigDecimal
+	for _, input := range c.inputs {
+		value, ok := inputs[input.name]
+		if !ok {
+			continue
+		}
+		converted, err := convertValue(input.valueType, value)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: %w", input.name, err)
+		}
+		f.slots[input.slot] = converted
+	}
+
+	if err := f.execute(c.main.body, c.main.name); err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]interface{}, len(c.outputs))
+	for _, slot := range c.outputs {
+		if value := f.slots[slot]; value != nil {
+			outputs[c.names[slot]] = value
+		}
+	}
+	return outputs, nil
+}
+
+// execute runs a block of compiled statements; errors read like the
+// interpreter's
+func (f *frame) execute(statements []compiledStatement, methodName string) error {
+	for i := range statements {
+		s := &statements[i]
+		if err := f.guard.step(methodName, s.source); err != nil {
+			return err
+		}
+
+		switch s.kind {
+		case OpExecute:
+			if err := f.guard.enter(methodName, s.source); err != nil {
+				return err
+			}
+			err := f.execute(s.method.body, s.method.name)
+			f.guard.leave()
+			if err != nil {
+				return callError(s.method.name, err)
+			}
+
+		case OpEval:
+			value, err := s.value(f)
+			if err != nil {
+				if eval := s.source.(*EvalStatement); eval.Assignment != nil {
+					return fmt.Errorf("evaluation error in method %s: failed to evaluate %q: %w", methodName, eval.Exec, err)
+				}
+				return fmt.Errorf("evaluation error in method %s: %w", methodName, err)
+			}
+			f.slots[s.target] = value
+
+		case OpIf:
+			result, err := s.cond(f)
+			if err != nil {
+				return fmt.Errorf("if condition error in method %s: %w", methodName, err)
+			}
+			branch := s.els
+			if result {
+				branch = s.then
+			}
+			if err := f.execute(branch, methodName); err != nil {
+				return err
+			}
+
+		case OpCompare:
+			result, err := s.cond(f)
+			if err != nil {
+				return fmt.Errorf("comparison error in method %s: %w", methodName, err)
+			}
+			f.slots[s.target] = result
+		}
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package bmf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"tax-calculator/internal/tax/models"
+)
+
+func TestCompileMatchesInterpreter(t *testing.T) {
+	t.Setenv(PAPDirEnv, "")
+	registry := NewPAPRegistry()
+
+	requests := []models.TaxRequest{}
+	for taxClass := models.TaxClass1; taxClass <= models.TaxClass6; taxClass++ {
+		for _, income := range []int{0, 1200000, 4500000, 9000000, 30000000} {
+			requests = append(requests, models.TaxRequest{Period: models.Year, Income: income, TaxClass: taxClass, KVZ: 2.5})
+		}
+	}
+	requests = append(requests,
+		models.TaxRequest{Period: models.Month, Income: 450000, TaxClass: models.TaxClass3, R: 1, ZKF: 1.5, PVS: 1, PVZ: 1},
+		models.TaxRequest{Period: models.Year, Income: 6000000, TaxClass: models.TaxClass1, PKV: 1, PKPV: 350, ALTER1: 1, AJAHR: 2020},
+		models.TaxRequest{Period: models.Year, Income: 2400000, TaxClass: models.TaxClass4, VBEZ: 1200000, VJAHR: 2019, ZMVB: 12},
+	)
+
+	for _, version := range registry.versions {
+		papData, err := registry.LoadVersion(version)
+		if err != nil {
+			t.Fatalf("%s: %v", version.Version, err)
+		}
+
+		compiled, err := Compile(papData)
+		if err != nil {
+			t.Fatalf("%s: expected the embedded PAP to compile, got: %v", version.Version, err)
+		}
+		interpreted := NewProgram(papData)
+
+		for _, req := range requests {
+			inputs := make(map[string]interface{})
+			for _, input := range RequestInputs(req) {
+				inputs[input.Name] = input.Value
+			}
+
+			want, err := interpreted.Calculate(inputs)
+			if err != nil {
+				t.Fatalf("%s: interpreter failed: %v", version.Version, err)
+			}
+			got, err := compiled.Calculate(inputs)
+			if err != nil {
+				t.Fatalf("%s: compiled code failed: %v", version.Version, err)
+			}
+
+			if len(got) != len(want) {
+				t.Errorf("%s: expected %d outputs, got %d", version.Version, len(want), len(got))
+			}
+			for name, value := range want {
+				if fmt.Sprint(got[name]) != fmt.Sprint(value) {
+					t.Errorf("%s %+v: %s: expected %v, got %v", version.Version, req, name, value, got[name])
+				}
+			}
+		}
+	}
+}
+
+func TestCompileNestedBranches(t *testing.T) {
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(nestedPAP), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	program, err := Compile(&papData)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !program.Compiled() {
+		t.Error("Expected a compiled program")
+	}
+
+	tests := []struct {
+		stkl     int
+		krv      int
+		expected int
+	}{
+		{stkl: 1, krv: 0, expected: 11},
+		{stkl: 2, krv: 1, expected: 12},
+		{stkl: 3, krv: 0, expected: 1022},
+	}
+
+	for _, tt := range tests {
+		outputs, err := program.Calculate(map[string]interface{}{"STKL": tt.stkl, "KRV": tt.krv})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if outputs["RESULT"] != tt.expected || outputs["CALLS"] != 1 {
+			t.Errorf("STKL=%d KRV=%d: expected %d after 1 call, got %v", tt.stkl, tt.krv, tt.expected, outputs)
+		}
+	}
+}
+
+func TestCompileArrays(t *testing.T) {
+	src := `<PAP name="Arrays">
+		<VARIABLES>
+			<INPUTS><INPUT name="K" type="int"/></INPUTS>
+			<OUTPUTS><OUTPUT name="SUM" type="BigDecimal"/></OUTPUTS>
+			<INTERNALS><INTERNAL name="H" type="BigDecimal[]" default="{BigDecimal.ONE, BigDecimal.ONE, BigDecimal.ONE}"/></INTERNALS>
+		</VARIABLES>
+		<CONSTANTS>
+			<CONSTANT name="TAB" type="BigDecimal[]" value="{BigDecimal.valueOf (0), BigDecimal.valueOf (10), BigDecimal.valueOf (20)}"/>
+		</CONSTANTS>
+		<METHODS>
+			<MAIN>
+				<EVAL exec="H[K] = TAB[K].add(H[K])"/>
+				<EVAL exec="TAB[K] = BigDecimal.ZERO"/>
+				<EVAL exec="SUM = H[0].add(H[1]).add(H[2]).add(TAB[2])"/>
+			</MAIN>
+		</METHODS>
+	</PAP>`
+
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(src), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	compiled, err := Compile(&papData)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Writes stay within a calculation: the next one starts from the
+	// declared defaults and the constant table again
+	tests := []struct {
+		k        int
+		expected string
+	}{
+		{k: 1, expected: "33"},
+		{k: 2, expected: "23"},
+		{k: 1, expected: "33"},
+	}
+
+	for name, program := range map[string]*Program{"compiled": compiled, "interpreted": NewProgram(&papData)} {
+		for _, tt := range tests {
+			outputs, err := program.Calculate(map[string]interface{}{"K": tt.k})
+			if err != nil {
+				t.Fatalf("%s: expected no error, got: %v", name, err)
+			}
+			if got := outputs["SUM"].(Decimal).String(); got != tt.expected {
+				t.Errorf("%s K=%d: expected SUM %s, got %s", name, tt.k, tt.expected, got)
+			}
+		}
+
+		_, err := program.Calculate(map[string]interface{}{"K": 3})
+		if err == nil || !strings.Contains(err.Error(), "array index 3 out of bounds") {
+			t.Errorf("%s: expected an out of bounds error, got: %v", name, err)
+		}
+	}
+}
+
+func TestCompileDoubles(t *testing.T) {
+	src := `<PAP name="Doubles">
+		<VARIABLES>
+			<INPUTS><INPUT name="f" type="double" default="1.0"/></INPUTS>
+			<OUTPUTS>
+				<OUTPUT name="EXACT" type="BigDecimal"/>
+				<OUTPUT name="SHORT" type="BigDecimal"/>
+			</OUTPUTS>
+		</VARIABLES>
+		<METHODS>
+			<MAIN>
+				<EVAL exec="EXACT = new BigDecimal(f)"/>
+				<EVAL exec="SHORT = BigDecimal.valueOf(f)"/>
+			</MAIN>
+		</METHODS>
+	</PAP>`
+
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(src), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	compiled, err := Compile(&papData)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// new BigDecimal(double) keeps the binary expansion, valueOf does not
+	for name, program := range map[string]*Program{"compiled": compiled, "interpreted": NewProgram(&papData)} {
+		outputs, err := program.Calculate(map[string]interface{}{"f": 0.1})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", name, err)
+		}
+		if got := outputs["EXACT"].(Decimal).String(); got != "0.1000000000000000055511151231257827021181583404541015625" {
+			t.Errorf("%s: expected the exact value of 0.1, got %s", name, got)
+		}
+		if got := outputs["SHORT"].(Decimal).String(); got != "0.1" {
+			t.Errorf("%s: expected 0.1, got %s", name, got)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		methods  string
+		expected string
+	}{
+		{"no main", `<METHOD name="MA"/>`, "no main method"},
+		{"unknown method", `<MAIN><EXECUTE method="MISSING"/></MAIN>`, "method MISSING not found"},
+		{"unknown method in method", `<MAIN/><METHOD name="MA"><EXECUTE method="MB"/></METHOD>`, "method MA: method MB not found"},
+		{"unsupported target", `<MAIN><EVAL exec="X[0][1] = 1"/></MAIN>`, "unsupported assignment target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var papData PAPData
+			src := `<PAP name="Broken"><VARIABLES/><CONSTANTS/><METHODS>` + tt.methods + `</METHODS></PAP>`
+			if err := xml.Unmarshal([]byte(src), &papData); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			_, err := Compile(&papData)
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.expected) || !strings.Contains(err.Error(), "Broken") {
+				t.Errorf("Expected error about %q in Broken, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestCompileRuntimeErrors(t *testing.T) {
+	src := `<PAP name="Runtime">
+		<VARIABLES>
+			<INPUTS><INPUT name="A" type="int"/></INPUTS>
+			<OUTPUTS><OUTPUT name="B" type="int"/></OUTPUTS>
+		</VARIABLES>
+		<CONSTANTS/>
+		<METHODS>
+			<MAIN><EXECUTE method="MCALC"/></MAIN>
+			<METHOD name="MCALC">
+				<IF expr="A == 1"><THEN><EVAL exec="B = UNSET + 1"/></THEN></IF>
+				<IF expr="A == 2"><THEN><EVAL exec="B = 10 / (A - 2)"/></THEN></IF>
+				<EVAL exec="UNSET = 5"/>
+				<EVAL exec="B = UNSET + A"/>
+			</METHOD>
+		</METHODS>
+	</PAP>`
+
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(src), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	program, err := Compile(&papData)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	outputs, err := program.Calculate(map[string]interface{}{"A": 3})
+	if err != nil || outputs["B"] != 8 {
+		t.Errorf("Expected B = 8, got %v (%v)", outputs["B"], err)
+	}
+
+	_, err = program.Calculate(map[string]interface{}{"A": 1})
+	if err == nil || err.Error() != `error executing method MCALC: evaluation error in method MCALC: failed to evaluate "B = UNSET + 1": variable UNSET not found` {
+		t.Errorf("Expected the interpreter's error for an unset variable, got: %v", err)
+	}
+
+	if _, err := program.Calculate(map[string]interface{}{"A": 2}); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("Expected a division by zero error, got: %v", err)
+	}
+
+	if _, err := program.Calculate(map[string]interface{}{"A": "x"}); err == nil || !strings.Contains(err.Error(), "input A") {
+		t.Errorf("Expected an input conversion error, got: %v", err)
+	}
+}
+
+func BenchmarkProgramCalculate(b *testing.B) {
+	papData, err := LoadPAP(DefaultYear)
+	if err != nil {
+		b.Fatal(err)
+	}
+	compiled, err := Compile(papData)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	programs := map[string]*Program{
+		"interpreted": NewProgram(papData),
+		"compiled":    compiled,
+	}
+	for name, program := range programs {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				inputs := map[string]interface{}{"LZZ": 1, "STKL": 1, "KVZ": 2.5, "RE4": 2000000 + i%8000000}
+				if _, err := program.Calculate(inputs); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
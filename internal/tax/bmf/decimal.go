@@ -0,0 +1,410 @@
+package bmf
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an immutable arbitrary-precision decimal number with an explicit
+// scale, reproducing the semantics of java.math.BigDecimal that the PAP
+// relies on: the value is unscaled × 10^-scale.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+var (
+	bigTen      = big.NewInt(10)
+	DecimalZero = NewDecimal(0, 0)
+	DecimalOne  = NewDecimal(1, 0)
+	DecimalTen  = NewDecimal(10, 0)
+)
+
+// NewDecimal returns unscaled × 10^-scale
+func NewDecimal(unscaled int64, scale int) Decimal {
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// DecimalFromInt returns an integer as a Decimal with scale 0
+func DecimalFromInt(i int) Decimal {
+	return NewDecimal(int64(i), 0)
+}
+
+// DecimalFromFloat converts a double the way BigDecimal.valueOf(double) does,
+// from the shortest representation Double.toString gives: at least one
+// fraction digit, and exponent notation from 1e7 on and below 1e-3, which
+// makes e.g. 1e7 the scale -6 decimal 1.0E7
+func DecimalFromFloat(f float64) Decimal {
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-3 || abs >= 1e7) {
+		mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+		exp, _ := strconv.Atoi(exponent)
+		return MustParseDecimal(fmt.Sprintf("%sE%d", mantissa, exp))
+	}
+
+	text := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(text, ".") {
+		text += ".0"
+	}
+	return MustParseDecimal(text)
+}
+
+// DecimalFromFloatExact converts a double the way new BigDecimal(double)
+// does, to the exact value of its binary representation, e.g. 0.1 to
+// 0.1000000000000000055511151231257827021181583404541015625
+func DecimalFromFloatExact(f float64) (Decimal, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return Decimal{}, fmt.Errorf("invalid double %v", f)
+	}
+	r := new(big.Rat).SetFloat64(f)
+
+	// The denominator is 2^scale, and n/2^scale = n×5^scale/10^scale
+	scale := r.Denom().BitLen() - 1
+	unscaled := new(big.Int).Exp(big.NewInt(5), big.NewInt(int64(scale)), nil)
+	unscaled.Mul(unscaled, r.Num())
+	return Decimal{unscaled: unscaled, scale: scale}, nil
+}
+
+// ParseDecimal parses a plain or exponent notation number the way
+// new BigDecimal(String) does, keeping the scale of the literal
+func ParseDecimal(s string) (Decimal, error) {
+	text := strings.TrimSpace(s)
+	if text == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	exponent := 0
+	if idx := strings.IndexAny(text, "eE"); idx >= 0 {
+		if _, err := fmt.Sscanf(text[idx+1:], "%d", &exponent); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		text = text[:idx]
+	}
+
+	scale := 0
+	if idx := strings.IndexByte(text, '.'); idx >= 0 {
+		scale = len(text) - idx - 1
+		text = text[:idx] + text[idx+1:]
+	}
+
+	digits := strings.TrimLeft(text, "+-")
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	unscaled, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{unscaled: unscaled, scale: scale - exponent}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on invalid input
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) bigInt() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Unscaled returns a copy of the unscaled value
+func (d Decimal) Unscaled() *big.Int {
+	return new(big.Int).Set(d.bigInt())
+}
+
+func (d Decimal) Sign() int {
+	return d.bigInt().Sign()
+}
+
+// Rat returns the exact value as a rational number
+func (d Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.bigInt())
+	if d.scale > 0 {
+		return r.Quo(r, new(big.Rat).SetInt(pow10(d.scale)))
+	}
+	return r.Mul(r, new(big.Rat).SetInt(pow10(-d.scale)))
+}
+
+// rescaled returns the unscaled value of d at a scale not smaller than its
+// own. At d's own scale that is d's value itself, which must not be modified.
+func (d Decimal) rescaled(scale int) *big.Int {
+	if scale == d.scale {
+		return d.bigInt()
+	}
+	return new(big.Int).Mul(d.bigInt(), pow10(scale-d.scale))
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	scale := maxInt(d.scale, o.scale)
+	return Decimal{unscaled: new(big.Int).Add(d.rescaled(scale), o.rescaled(scale)), scale: scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	scale := maxInt(d.scale, o.scale)
+	return Decimal{unscaled: new(big.Int).Sub(d.rescaled(scale), o.rescaled(scale)), scale: scale}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.bigInt(), o.bigInt()), scale: d.scale + o.scale}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.bigInt()), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.bigInt()), scale: d.scale}
+}
+
+// Cmp compares the numeric values and ignores the scale, like compareTo
+func (d Decimal) Cmp(o Decimal) int {
+	scale := maxInt(d.scale, o.scale)
+	return d.rescaled(scale).Cmp(o.rescaled(scale))
+}
+
+func (d Decimal) Max(o Decimal) Decimal {
+	if d.Cmp(o) >= 0 {
+		return d
+	}
+	return o
+}
+
+func (d Decimal) Min(o Decimal) Decimal {
+	if d.Cmp(o) <= 0 {
+		return d
+	}
+	return o
+}
+
+// Div is divide(BigDecimal): the exact quotient with the preferred scale
+// d.scale - o.scale, or an error if the expansion does not terminate
+func (d Decimal) Div(o Decimal) (Decimal, error) {
+	if o.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("division by zero")
+	}
+
+	preferred := d.scale - o.scale
+	if d.Sign() == 0 {
+		return Decimal{unscaled: new(big.Int), scale: preferred}, nil
+	}
+
+	quotient := new(big.Rat).Quo(d.Rat(), o.Rat())
+
+	// The quotient terminates only if its denominator is built from 2s and 5s
+	denominator := new(big.Int).Set(quotient.Denom())
+	twos, fives := 0, 0
+	for denominator.Bit(0) == 0 {
+		denominator.Rsh(denominator, 1)
+		twos++
+	}
+	five := big.NewInt(5)
+	remainder := new(big.Int)
+	for {
+		q, r := new(big.Int).QuoRem(denominator, five, remainder)
+		if r.Sign() != 0 {
+			break
+		}
+		denominator = q
+		fives++
+	}
+	if denominator.Cmp(big.NewInt(1)) != 0 {
+		return Decimal{}, fmt.Errorf("non-terminating decimal expansion; no exact representable decimal result")
+	}
+
+	scale := maxInt(twos, fives)
+	if twos == 0 && fives == 0 {
+		// Integral quotient: trailing zeros may be stripped down to the preferred scale
+		numerator := new(big.Int).Set(quotient.Num())
+		for scale > preferred {
+			q, r := new(big.Int).QuoRem(numerator, bigTen, remainder)
+			if r.Sign() != 0 {
+				break
+			}
+			numerator = q
+			scale--
+		}
+	}
+	scale = maxInt(scale, preferred)
+
+	scaled := new(big.Rat).Mul(quotient, scaleFactor(scale))
+	return Decimal{unscaled: new(big.Int).Set(scaled.Num()), scale: scale}, nil
+}
+
+// DivScale is divide(BigDecimal, scale, roundingMode)
+func (d Decimal) DivScale(o Decimal, scale int, mode int) (Decimal, error) {
+	if o.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("division by zero")
+	}
+
+	// d / o × 10^scale as a fraction of integers: du × 10^exponent / ou
+	num, den := d.bigInt(), o.bigInt()
+	if exponent := scale + o.scale - d.scale; exponent >= 0 {
+		num = new(big.Int).Mul(num, pow10(exponent))
+	} else {
+		den = new(big.Int).Mul(den, pow10(-exponent))
+	}
+
+	unscaled, err := roundQuotient(num, den, mode)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{unscaled: unscaled, scale: scale}, nil
+}
+
+// DivRound is divide(BigDecimal, roundingMode), which keeps the scale of d
+func (d Decimal) DivRound(o Decimal, mode int) (Decimal, error) {
+	return d.DivScale(o, d.scale, mode)
+}
+
+// SetScale is setScale(scale, roundingMode)
+func (d Decimal) SetScale(scale int, mode int) (Decimal, error) {
+	if scale >= d.scale {
+		return Decimal{unscaled: d.rescaled(scale), scale: scale}, nil
+	}
+
+	unscaled, err := roundQuotient(d.bigInt(), pow10(d.scale-scale), mode)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{unscaled: unscaled, scale: scale}, nil
+}
+
+// Remainder is remainder(BigDecimal): d - o × trunc(d / o)
+func (d Decimal) Remainder(o Decimal) (Decimal, error) {
+	if o.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("division by zero")
+	}
+
+	scale := maxInt(d.scale, o.scale)
+	remainder := new(big.Int).Rem(d.rescaled(scale), o.rescaled(scale))
+	return Decimal{unscaled: remainder, scale: scale}, nil
+}
+
+// Truncate returns the integral part as a big.Int, rounding towards zero
+func (d Decimal) Truncate() *big.Int {
+	if d.scale <= 0 {
+		return new(big.Int).Set(d.rescaled(0))
+	}
+	return new(big.Int).Quo(d.bigInt(), pow10(d.scale))
+}
+
+// IntValue is intValue()/longValue(): the integral part, truncated towards zero
+func (d Decimal) IntValue() int {
+	return int(d.Truncate().Int64())
+}
+
+// String returns the plain representation with exactly Scale() fraction digits
+func (d Decimal) String() string {
+	if d.scale <= 0 {
+		return d.rescaled(0).String()
+	}
+
+	digits := new(big.Int).Abs(d.bigInt()).String()
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	point := len(digits) - d.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// roundQuotient divides num by den and rounds the result to an integer using
+// one of the Java BigDecimal rounding modes
+func roundQuotient(num, den *big.Int, mode int) (*big.Int, error) {
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient, nil
+	}
+
+	sign := num.Sign() * den.Sign()
+
+	// Compare twice the remainder with the divisor to find the half
+	half := new(big.Int).Abs(remainder)
+	half.Lsh(half, 1)
+	halfCmp := half.Cmp(new(big.Int).Abs(den))
+
+	awayFromZero := false
+	switch mode {
+	case RoundUp:
+		awayFromZero = true
+	case RoundDown:
+		awayFromZero = false
+	case RoundCeiling:
+		awayFromZero = sign > 0
+	case RoundFloor:
+		awayFromZero = sign < 0
+	case RoundHalfUp:
+		awayFromZero = halfCmp >= 0
+	case RoundHalfDown:
+		awayFromZero = halfCmp > 0
+	case RoundHalfEven:
+		awayFromZero = halfCmp > 0 || (halfCmp == 0 && quotient.Bit(0) == 1)
+	case RoundUnnecessary:
+		return nil, fmt.Errorf("rounding necessary")
+	default:
+		return nil, fmt.Errorf("unknown rounding mode %d", mode)
+	}
+
+	if awayFromZero {
+		quotient.Add(quotient, big.NewInt(int64(sign)))
+	}
+	return quotient, nil
+}
+
+// powersOfTen caches the small powers the PAP needs all the time
+var powersOfTen = func() []*big.Int {
+	powers := make([]*big.Int, 40)
+	powers[0] = big.NewInt(1)
+	for i := 1; i < len(powers); i++ {
+		powers[i] = new(big.Int).Mul(powers[i-1], bigTen)
+	}
+	return powers
+}()
+
+// pow10 returns 10^n, or 1 for n <= 0. The result is shared and must not be
+// modified.
+func pow10(n int) *big.Int {
+	if n <= 0 {
+		return powersOfTen[0]
+	}
+	if n < len(powersOfTen) {
+		return powersOfTen[n]
+	}
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func scaleFactor(scale int) *big.Rat {
+	if scale >= 0 {
+		return new(big.Rat).SetInt(pow10(scale))
+	}
+	return new(big.Rat).SetFrac(big.NewInt(1), pow10(-scale))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,303 @@
+package bmf
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		scale    int
+	}{
+		{"0", "0", 0},
+		{"0.0", "0.0", 1},
+		{"123.45", "123.45", 2},
+		{"-0.093", "-0.093", 3},
+		{"+7", "7", 0},
+		{"1.5E3", "1500", -2},
+		{"25E-2", "0.25", 2},
+		{"123456789012345678901234567890.12", "123456789012345678901234567890.12", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := ParseDecimal(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if d.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, d)
+			}
+
+			if d.Scale() != tt.scale {
+				t.Errorf("Expected scale %d, got %d", tt.scale, d.Scale())
+			}
+		})
+	}
+
+	for _, input := range []string{"", "-", "1.2.3", "abc", "1e", "12x"} {
+		if _, err := ParseDecimal(input); err == nil {
+			t.Errorf("Expected error for %q, got none", input)
+		}
+	}
+}
+
+func TestDecimalArithmeticScale(t *testing.T) {
+	a := MustParseDecimal("1.50")
+	b := MustParseDecimal("0.125")
+
+	tests := []struct {
+		name     string
+		result   Decimal
+		expected string
+	}{
+		{"add", a.Add(b), "1.625"},
+		{"subtract", a.Sub(b), "1.375"},
+		{"multiply", a.Mul(b), "0.18750"},
+		{"negate", a.Neg(), "-1.50"},
+		{"abs", a.Neg().Abs(), "1.50"},
+		{"max", a.Max(b), "1.50"},
+		{"min", a.Min(b), "0.125"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.result.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, tt.result)
+			}
+		})
+	}
+
+	if MustParseDecimal("2.0").Cmp(MustParseDecimal("2.00")) != 0 {
+		t.Error("Expected compareTo to ignore the scale")
+	}
+}
+
+func TestDecimalDiv(t *testing.T) {
+	tests := []struct {
+		dividend string
+		divisor  string
+		expected string
+	}{
+		{"100", "4", "25"},
+		{"1", "8", "0.125"},
+		{"19.99", "100", "0.1999"},
+		{"5000000", "100", "50000"},
+		{"1.00", "2", "0.50"},
+		{"0.00", "7", "0.00"},
+		{"-3", "0.5", "-6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dividend+"/"+tt.divisor, func(t *testing.T) {
+			result, err := MustParseDecimal(tt.dividend).Div(MustParseDecimal(tt.divisor))
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if result.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+
+	if _, err := DecimalOne.Div(NewDecimal(3, 0)); err == nil {
+		t.Error("Expected error for non-terminating expansion")
+	}
+
+	if _, err := DecimalOne.Div(DecimalZero); err == nil {
+		t.Error("Expected error for division by zero")
+	}
+}
+
+func TestDecimalRoundingModes(t *testing.T) {
+	values := []string{"5.5", "2.5", "1.6", "1.1", "1.0", "-1.0", "-1.1", "-1.6", "-2.5", "-5.5"}
+
+	// Results for each value, following the table in the java.math.RoundingMode docs
+	tests := []struct {
+		name     string
+		mode     int
+		expected []string
+	}{
+		{"UP", RoundUp, []string{"6", "3", "2", "2", "1", "-1", "-2", "-2", "-3", "-6"}},
+		{"DOWN", RoundDown, []string{"5", "2", "1", "1", "1", "-1", "-1", "-1", "-2", "-5"}},
+		{"CEILING", RoundCeiling, []string{"6", "3", "2", "2", "1", "-1", "-1", "-1", "-2", "-5"}},
+		{"FLOOR", RoundFloor, []string{"5", "2", "1", "1", "1", "-1", "-2", "-2", "-3", "-6"}},
+		{"HALF_UP", RoundHalfUp, []string{"6", "3", "2", "1", "1", "-1", "-1", "-2", "-3", "-6"}},
+		{"HALF_DOWN", RoundHalfDown, []string{"5", "2", "2", "1", "1", "-1", "-1", "-2", "-2", "-5"}},
+		{"HALF_EVEN", RoundHalfEven, []string{"6", "2", "2", "1", "1", "-1", "-1", "-2", "-2", "-6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, value := range values {
+				result, err := MustParseDecimal(value).SetScale(0, tt.mode)
+				if err != nil {
+					t.Fatalf("%s: expected no error, got: %v", value, err)
+				}
+
+				if result.String() != tt.expected[i] {
+					t.Errorf("%s: expected %s, got %s", value, tt.expected[i], result)
+				}
+			}
+		})
+	}
+
+	if _, err := MustParseDecimal("1.1").SetScale(0, RoundUnnecessary); err == nil {
+		t.Error("Expected error for ROUND_UNNECESSARY with a discarded fraction")
+	}
+
+	if result, err := MustParseDecimal("1.0").SetScale(0, RoundUnnecessary); err != nil || result.String() != "1" {
+		t.Errorf("Expected 1 without error, got %s, %v", result, err)
+	}
+
+	if _, err := DecimalOne.SetScale(0, 42); err != nil {
+		t.Errorf("Expected no rounding and no error for an exact value, got: %v", err)
+	}
+
+	if _, err := MustParseDecimal("1.5").SetScale(0, 42); err == nil {
+		t.Error("Expected error for unknown rounding mode")
+	}
+}
+
+func TestDecimalDivScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   func() (Decimal, error)
+		expected string
+	}{
+		{
+			name:     "divide with scale",
+			result:   func() (Decimal, error) { return DecimalFromInt(5000000).DivScale(DecimalFromInt(12), 2, RoundDown) },
+			expected: "416666.66",
+		},
+		{
+			name:     "divide keeping the dividend scale",
+			result:   func() (Decimal, error) { return MustParseDecimal("10.00").DivRound(DecimalFromInt(3), RoundHalfUp) },
+			expected: "3.33",
+		},
+		{
+			name:     "negative half up",
+			result:   func() (Decimal, error) { return DecimalFromInt(-5).DivScale(DecimalFromInt(2), 0, RoundHalfUp) },
+			expected: "-3",
+		},
+		{
+			name:     "negative scale",
+			result:   func() (Decimal, error) { return DecimalFromInt(12345).DivScale(DecimalOne, -2, RoundDown) },
+			expected: "12300",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.result()
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if result.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDecimalRemainderAndIntValue(t *testing.T) {
+	remainder, err := MustParseDecimal("-7.5").Remainder(DecimalFromInt(2))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if remainder.String() != "-1.5" {
+		t.Errorf("Expected -1.5, got %s", remainder)
+	}
+
+	if _, err := DecimalOne.Remainder(DecimalZero); err == nil {
+		t.Error("Expected error for remainder by zero")
+	}
+
+	if v := MustParseDecimal("-41666.99").IntValue(); v != -41666 {
+		t.Errorf("Expected -41666, got %d", v)
+	}
+
+	if v := MustParseDecimal("1.5E3").IntValue(); v != 1500 {
+		t.Errorf("Expected 1500, got %d", v)
+	}
+
+	var zero Decimal
+	if zero.String() != "0" || zero.Sign() != 0 {
+		t.Errorf("Expected zero value to be 0, got %s", zero)
+	}
+}
+
+func TestDecimalFromFloat(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{0.32, "0.32"},
+		{5, "5.0"},
+		{0.093, "0.093"},
+		{-2.5, "-2.5"},
+		{96600, "96600.0"},
+		{9999999, "9999999.0"},
+		{0.001, "0.001"},
+		{0, "0.0"},
+	}
+
+	for _, tt := range tests {
+		if d := DecimalFromFloat(tt.input); d.String() != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, d)
+		}
+	}
+
+	// Double.toString switches to exponent notation, e.g. 1.0E7 and 1.0E-4,
+	// and the scale follows it
+	exponents := []struct {
+		input    float64
+		expected string
+		scale    int
+	}{
+		{1e7, "10000000", -6},
+		{-1e7, "-10000000", -6},
+		{1.25e8, "125000000", -6},
+		{1e-4, "0.00010", 5},
+		{1.5e-5, "0.000015", 6},
+	}
+	for _, tt := range exponents {
+		d := DecimalFromFloat(tt.input)
+		if d.String() != tt.expected || d.Scale() != tt.scale {
+			t.Errorf("Expected %s with scale %d for %v, got %s with scale %d", tt.expected, tt.scale, tt.input, d, d.Scale())
+		}
+	}
+}
+
+func TestDecimalFromFloatExact(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{0.1, "0.1000000000000000055511151231257827021181583404541015625"},
+		{0.5, "0.5"},
+		{-2.5, "-2.5"},
+		{100, "100"},
+		{0, "0"},
+	}
+
+	for _, tt := range tests {
+		d, err := DecimalFromFloatExact(tt.input)
+		if err != nil {
+			t.Fatalf("Expected no error for %v, got: %v", tt.input, err)
+		}
+		if d.String() != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, d)
+		}
+	}
+
+	if _, err := DecimalFromFloatExact(math.NaN()); err == nil {
+		t.Error("Expected an error for NaN")
+	}
+}
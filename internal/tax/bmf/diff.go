@@ -0,0 +1,400 @@
+package bmf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind tells how an element of a PAP changed from one version to the next
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "+"
+	Removed ChangeKind = "-"
+	Changed ChangeKind = "~"
+)
+
+// PAPDiff lists what changed from one PAP to another, e.g. from one tax year
+// to the next
+type PAPDiff struct {
+	Variables  []VariableChange
+	Constants  []ValueChange
+	Parameters []ValueChange
+	Methods    []MethodChange
+}
+
+// VariableChange is an input, output or internal variable that was added,
+// removed or got another type or default
+type VariableChange struct {
+	Kind    ChangeKind
+	Section string // INPUT, OUTPUT or INTERNAL
+	Name    string
+	Old     string // type and default, e.g. "int = 1"
+	New     string
+}
+
+// ValueChange is a constant, or a parameter: a variable a method sets to a
+// constant value, such as the Grundfreibetrag GFB in MPARA. An element of a
+// table that changed is reported on its own, e.g. TAB1[3].
+type ValueChange struct {
+	Kind   ChangeKind
+	Method string // only for parameters
+	Name   string
+	Old    string
+	New    string
+}
+
+// MethodChange is a method that was added, removed or whose statements
+// changed. A method renamed for the new year, e.g. UPTAB24 to UPTAB25, is
+// compared with its predecessor.
+type MethodChange struct {
+	Kind    ChangeKind
+	Name    string
+	OldName string // differs from Name if the method was renamed
+	Lines   []LineChange
+}
+
+// LineChange is a statement added to or removed from a method, indented by
+// its nesting in IF blocks
+type LineChange struct {
+	Kind ChangeKind
+	Text string
+}
+
+// Len returns the number of changes
+func (d *PAPDiff) Len() int {
+	return len(d.Variables) + len(d.Constants) + len(d.Parameters) + len(d.Methods)
+}
+
+// DiffPAP compares two PAPs
+func DiffPAP(old, new *PAPData) *PAPDiff {
+	diff := &PAPDiff{}
+
+	sections := []struct {
+		name     string
+		old, new []declaredVariable
+	}{
+		{"INPUT", declared(old.Variables.Inputs.Input), declared(new.Variables.Inputs.Input)},
+		{"OUTPUT", declared(old.Variables.Outputs.Output), declared(new.Variables.Outputs.Output)},
+		{"INTERNAL", declared(old.Variables.Internals.Internal), declared(new.Variables.Internals.Internal)},
+	}
+	for _, section := range sections {
+		diff.Variables = append(diff.Variables, diffVariables(section.name, section.old, section.new)...)
+	}
+
+	diff.Constants = diffConstants(old.Constants.Constant, new.Constants.Constant)
+
+	renamed := pairMethods(old, new)
+	diff.Methods = diffMethods(old, new, renamed)
+	// The parameters of methods that were added or removed as a whole are
+	// reported with their method
+	whole := make(map[string]bool)
+	for _, method := range diff.Methods {
+		if method.Kind != Changed {
+			whole[method.Name] = true
+		}
+	}
+	for _, change := range diffValues(parameters(old, renamed), parameters(new, nil)) {
+		if !whole[change.Method] {
+			diff.Parameters = append(diff.Parameters, change)
+		}
+	}
+
+	return diff
+}
+
+// declaredVariable is a variable of any section
+type declaredVariable struct {
+	Name    string
+	Type    string
+	Default string
+}
+
+func declared[T InputVariable | OutputVariable | InternalVariable](variables []T) []declaredVariable {
+	converted := make([]declaredVariable, len(variables))
+	for i, v := range variables {
+		converted[i] = declaredVariable(v)
+	}
+	return converted
+}
+
+func (v declaredVariable) declaration() string {
+	if v.Default == "" {
+		return v.Type
+	}
+	return v.Type + " = " + v.Default
+}
+
+func diffVariables(section string, old, new []declaredVariable) []VariableChange {
+	var changes []VariableChange
+
+	newByName := make(map[string]declaredVariable, len(new))
+	for _, v := range new {
+		newByName[v.Name] = v
+	}
+	oldNames := make(map[string]bool, len(old))
+	for _, v := range old {
+		oldNames[v.Name] = true
+		n, ok := newByName[v.Name]
+		switch {
+		case !ok:
+			changes = append(changes, VariableChange{Kind: Removed, Section: section, Name: v.Name, Old: v.declaration()})
+		case n.declaration() != v.declaration():
+			changes = append(changes, VariableChange{Kind: Changed, Section: section, Name: v.Name, Old: v.declaration(), New: n.declaration()})
+		}
+	}
+	for _, v := range new {
+		if !oldNames[v.Name] {
+			changes = append(changes, VariableChange{Kind: Added, Section: section, Name: v.Name, New: v.declaration()})
+		}
+	}
+	return changes
+}
+
+// namedValue is a constant or parameter with its value as the engines read it
+type namedValue struct {
+	method string
+	name   string
+	value  interface{}
+}
+
+func diffConstants(old, new []PAPConstant) []ValueChange {
+	values := func(constants []PAPConstant) []namedValue {
+		named := make([]namedValue, len(constants))
+		for i, c := range constants {
+			named[i] = namedValue{name: c.Name, value: parseValue(c.Type, c.Value)}
+		}
+		return named
+	}
+	return diffValues(values(old), values(new))
+}
+
+func diffValues(old, new []namedValue) []ValueChange {
+	var changes []ValueChange
+
+	key := func(v namedValue) string { return v.method + " " + v.name }
+	newByKey := make(map[string]namedValue, len(new))
+	for _, v := range new {
+		newByKey[key(v)] = v
+	}
+	oldKeys := make(map[string]bool, len(old))
+	for _, v := range old {
+		oldKeys[key(v)] = true
+		n, ok := newByKey[key(v)]
+		if !ok {
+			changes = append(changes, ValueChange{Kind: Removed, Method: v.method, Name: v.name, Old: FormatTraceValue(v.value)})
+			continue
+		}
+		changes = append(changes, diffValue(v.method, v.name, v.value, n.value)...)
+	}
+	for _, v := range new {
+		if !oldKeys[key(v)] {
+			changes = append(changes, ValueChange{Kind: Added, Method: v.method, Name: v.name, New: FormatTraceValue(v.value)})
+		}
+	}
+	return changes
+}
+
+// diffValue compares two values of a name, tables element by element if
+// they are as long as each other
+func diffValue(method, name string, old, new interface{}) []ValueChange {
+	oldTable, oldOK := old.([]interface{})
+	newTable, newOK := new.([]interface{})
+	if oldOK && newOK && len(oldTable) == len(newTable) {
+		var changes []ValueChange
+		for i := range oldTable {
+			changes = append(changes, diffValue(method, fmt.Sprintf("%s[%d]", name, i), oldTable[i], newTable[i])...)
+		}
+		return changes
+	}
+
+	if FormatTraceValue(old) == FormatTraceValue(new) {
+		return nil
+	}
+	return []ValueChange{{Kind: Changed, Method: method, Name: name, Old: FormatTraceValue(old), New: FormatTraceValue(new)}}
+}
+
+// parameters collects the variables that methods set to constant values.
+// Methods are named as in the new PAP, going by renamed. A variable set
+// several times in a method is numbered from the second time, e.g. BBGRV#2.
+func parameters(pap *PAPData, renamed map[string]string) []namedValue {
+	var values []namedValue
+
+	var walk func(method string, statements []Statement, seen map[string]int)
+	walk = func(method string, statements []Statement, seen map[string]int) {
+		for _, statement := range statements {
+			switch s := statement.(type) {
+			case *EvalStatement:
+				if s.Assignment == nil {
+					continue
+				}
+				target, ok := s.Assignment.Target.(*Ident)
+				if !ok {
+					continue
+				}
+				value, err := (&TaxCalculator{}).evalExpr(s.Assignment.Value)
+				if err != nil {
+					continue
+				}
+				seen[target.Name]++
+				name := target.Name
+				if n := seen[target.Name]; n > 1 {
+					name = fmt.Sprintf("%s#%d", name, n)
+				}
+				values = append(values, namedValue{method: method, name: name, value: value})
+			case *IfStatement:
+				walk(method, s.Then, seen)
+				walk(method, s.Else, seen)
+			}
+		}
+	}
+
+	for _, method := range papMethods(pap) {
+		name := method.Name
+		if newName, ok := renamed[name]; ok {
+			name = newName
+		}
+		walk(name, method.Statements, make(map[string]int))
+	}
+	return values
+}
+
+// papMethods returns MAIN followed by the other methods, the first of each name
+func papMethods(pap *PAPData) []PAPMethod {
+	var methods []PAPMethod
+	seen := make(map[string]bool)
+	if len(pap.Methods.Main) > 0 {
+		methods = append(methods, PAPMethod{Name: "MAIN", Statements: pap.Methods.Main[0].Statements})
+		seen["MAIN"] = true
+	}
+	for _, method := range pap.Methods.Method {
+		if !seen[method.Name] {
+			methods = append(methods, method)
+			seen[method.Name] = true
+		}
+	}
+	return methods
+}
+
+// pairMethods finds methods of the old PAP that the new one has under a name
+// for the new year: the name without its trailing digits, such as the year
+// in UPTAB24, is the same and neither name exists in the other PAP
+func pairMethods(old, new *PAPData) map[string]string {
+	oldNames, newNames := make(map[string]bool), make(map[string]bool)
+	for _, method := range papMethods(old) {
+		oldNames[method.Name] = true
+	}
+	for _, method := range papMethods(new) {
+		newNames[method.Name] = true
+	}
+
+	candidates := make(map[string][]string)
+	for _, method := range papMethods(new) {
+		if !oldNames[method.Name] {
+			stem := strings.TrimRight(method.Name, "0123456789")
+			candidates[stem] = append(candidates[stem], method.Name)
+		}
+	}
+
+	renamed := make(map[string]string)
+	for _, method := range papMethods(old) {
+		if newNames[method.Name] {
+			continue
+		}
+		stem := strings.TrimRight(method.Name, "0123456789")
+		if stem != method.Name && len(candidates[stem]) == 1 {
+			renamed[method.Name] = candidates[stem][0]
+			delete(candidates, stem)
+		}
+	}
+	return renamed
+}
+
+func diffMethods(old, new *PAPData, renamed map[string]string) []MethodChange {
+	var changes []MethodChange
+
+	oldByName := make(map[string]PAPMethod)
+	for _, method := range papMethods(old) {
+		name := method.Name
+		if newName, ok := renamed[name]; ok {
+			name = newName
+		}
+		oldByName[name] = method
+	}
+
+	newNames := make(map[string]bool)
+	for _, method := range papMethods(new) {
+		newNames[method.Name] = true
+		previous, ok := oldByName[method.Name]
+		if !ok {
+			changes = append(changes, MethodChange{Kind: Added, Name: method.Name})
+			continue
+		}
+		lines := diffLines(statementLines(previous.Statements, ""), statementLines(method.Statements, ""))
+		if len(lines) > 0 || previous.Name != method.Name {
+			changes = append(changes, MethodChange{Kind: Changed, Name: method.Name, OldName: previous.Name, Lines: lines})
+		}
+	}
+	for _, method := range papMethods(old) {
+		if _, ok := renamed[method.Name]; !ok && !newNames[method.Name] {
+			changes = append(changes, MethodChange{Kind: Removed, Name: method.Name})
+		}
+	}
+	return changes
+}
+
+// statementLines renders statements one per line, the blocks of IFs indented
+func statementLines(statements []Statement, indent string) []string {
+	var lines []string
+	for _, statement := range statements {
+		if _, ok := statement.(*BausteinFinishStatement); ok {
+			continue
+		}
+		lines = append(lines, indent+statementSource(statement))
+		if s, ok := statement.(*IfStatement); ok {
+			lines = append(lines, statementLines(s.Then, indent+"  ")...)
+			if len(s.Else) > 0 {
+				lines = append(lines, indent+"ELSE")
+				lines = append(lines, statementLines(s.Else, indent+"  ")...)
+			}
+		}
+	}
+	return lines
+}
+
+// diffLines returns the lines to remove from old and add to get new, going by
+// their longest common subsequence
+func diffLines(old, new []string) []LineChange {
+	// common[i][j] is the length of the longest common subsequence of
+	// old[i:] and new[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var changes []LineChange
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			i++
+			j++
+		case j == len(new) || (i < len(old) && common[i+1][j] >= common[i][j+1]):
+			changes = append(changes, LineChange{Kind: Removed, Text: old[i]})
+			i++
+		default:
+			changes = append(changes, LineChange{Kind: Added, Text: new[j]})
+			j++
+		}
+	}
+	return changes
+}
@@ -0,0 +1,169 @@
+package bmf
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+const diffOldPAP = `<PAP name="Lohnsteuer2030">
+	<VARIABLES>
+		<INPUTS>
+			<INPUT name="RE4" type="BigDecimal"/>
+			<INPUT name="VMT" type="BigDecimal"/>
+		</INPUTS>
+		<OUTPUTS><OUTPUT name="LSTLZZ" type="BigDecimal" default="new BigDecimal(0)"/></OUTPUTS>
+		<INTERNALS>
+			<INTERNAL name="GFB" type="BigDecimal"/>
+			<INTERNAL name="KZTAB" type="int" default="1"/>
+		</INTERNALS>
+	</VARIABLES>
+	<CONSTANTS>
+		<CONSTANT name="ZAHL100" type="BigDecimal" value="BigDecimal.valueOf(100)"/>
+		<CONSTANT name="TAB1" type="BigDecimal[]" value="{BigDecimal.valueOf(0.0), BigDecimal.valueOf(0.4), BigDecimal.valueOf(0.384)}"/>
+	</CONSTANTS>
+	<METHODS>
+		<MAIN>
+			<EXECUTE method="MPARA"/>
+			<EXECUTE method="UPTAB30"/>
+			<EXECUTE method="MVMT"/>
+		</MAIN>
+		<METHOD name="MPARA">
+			<EVAL exec="GFB = new BigDecimal(12000)"/>
+		</METHOD>
+		<METHOD name="UPTAB30">
+			<IF expr="RE4.compareTo(GFB) &lt; 1">
+				<THEN><EVAL exec="LSTLZZ = BigDecimal.ZERO"/></THEN>
+				<ELSE><EVAL exec="LSTLZZ = RE4.subtract(GFB)"/></ELSE>
+			</IF>
+		</METHOD>
+		<METHOD name="MVMT">
+			<EVAL exec="KZTAB = 2"/>
+		</METHOD>
+	</METHODS>
+</PAP>`
+
+const diffNewPAP = `<PAP name="Lohnsteuer2031">
+	<VARIABLES>
+		<INPUTS>
+			<INPUT name="RE4" type="BigDecimal"/>
+			<INPUT name="ALTER1" type="int"/>
+		</INPUTS>
+		<OUTPUTS><OUTPUT name="LSTLZZ" type="BigDecimal" default="new BigDecimal(0)"/></OUTPUTS>
+		<INTERNALS>
+			<INTERNAL name="GFB" type="BigDecimal"/>
+			<INTERNAL name="KZTAB" type="int" default="2"/>
+		</INTERNALS>
+	</VARIABLES>
+	<CONSTANTS>
+		<CONSTANT name="ZAHL100" type="BigDecimal" value="BigDecimal.valueOf(100)"/>
+		<CONSTANT name="TAB1" type="BigDecimal[]" value="{BigDecimal.valueOf(0.0), BigDecimal.valueOf(0.4), BigDecimal.valueOf(0.368)}"/>
+	</CONSTANTS>
+	<METHODS>
+		<MAIN>
+			<EXECUTE method="MPARA"/>
+			<EXECUTE method="UPTAB31"/>
+		</MAIN>
+		<METHOD name="MPARA">
+			<EVAL exec="GFB = new BigDecimal(12500)"/>
+		</METHOD>
+		<METHOD name="UPTAB31">
+			<IF expr="RE4.compareTo(GFB) &lt; 1">
+				<THEN><EVAL exec="LSTLZZ = BigDecimal.ZERO"/></THEN>
+				<ELSE><EVAL exec="LSTLZZ = RE4.subtract(GFB).multiply(TAB1[2])"/></ELSE>
+			</IF>
+		</METHOD>
+	</METHODS>
+</PAP>`
+
+func parseDiffPAP(t *testing.T, src string) *PAPData {
+	t.Helper()
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(src), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return &papData
+}
+
+func TestDiffPAP(t *testing.T) {
+	diff := DiffPAP(parseDiffPAP(t, diffOldPAP), parseDiffPAP(t, diffNewPAP))
+
+	variables := []VariableChange{
+		{Kind: Removed, Section: "INPUT", Name: "VMT", Old: "BigDecimal"},
+		{Kind: Added, Section: "INPUT", Name: "ALTER1", New: "int"},
+		{Kind: Changed, Section: "INTERNAL", Name: "KZTAB", Old: "int = 1", New: "int = 2"},
+	}
+	if !reflect.DeepEqual(diff.Variables, variables) {
+		t.Errorf("Expected variables %+v, got %+v", variables, diff.Variables)
+	}
+
+	constants := []ValueChange{{Kind: Changed, Name: "TAB1[2]", Old: "0.384", New: "0.368"}}
+	if !reflect.DeepEqual(diff.Constants, constants) {
+		t.Errorf("Expected constants %+v, got %+v", constants, diff.Constants)
+	}
+
+	// KZTAB = 2 in the removed MVMT is reported with its method only
+	parameters := []ValueChange{{Kind: Changed, Method: "MPARA", Name: "GFB", Old: "12000", New: "12500"}}
+	if !reflect.DeepEqual(diff.Parameters, parameters) {
+		t.Errorf("Expected parameters %+v, got %+v", parameters, diff.Parameters)
+	}
+
+	methods := []MethodChange{
+		{Kind: Changed, Name: "MAIN", OldName: "MAIN", Lines: []LineChange{
+			{Kind: Removed, Text: "EXECUTE UPTAB30"},
+			{Kind: Removed, Text: "EXECUTE MVMT"},
+			{Kind: Added, Text: "EXECUTE UPTAB31"},
+		}},
+		{Kind: Changed, Name: "MPARA", OldName: "MPARA", Lines: []LineChange{
+			{Kind: Removed, Text: "GFB = new BigDecimal(12000)"},
+			{Kind: Added, Text: "GFB = new BigDecimal(12500)"},
+		}},
+		{Kind: Changed, Name: "UPTAB31", OldName: "UPTAB30", Lines: []LineChange{
+			{Kind: Removed, Text: "  LSTLZZ = RE4.subtract(GFB)"},
+			{Kind: Added, Text: "  LSTLZZ = RE4.subtract(GFB).multiply(TAB1[2])"},
+		}},
+		{Kind: Removed, Name: "MVMT"},
+	}
+	if !reflect.DeepEqual(diff.Methods, methods) {
+		t.Errorf("Expected methods %+v, got %+v", methods, diff.Methods)
+	}
+
+	if diff.Len() != 9 {
+		t.Errorf("Expected 9 changes, got %d", diff.Len())
+	}
+}
+
+func TestDiffPAPSame(t *testing.T) {
+	papData := parseDiffPAP(t, diffOldPAP)
+	if diff := DiffPAP(papData, papData); diff.Len() != 0 {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+}
+
+func TestDiffPAPEmbedded(t *testing.T) {
+	old, err := LoadPAP(2024)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	new, err := LoadPAP(2025)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	diff := DiffPAP(old, new)
+	expected := map[string]ValueChange{
+		"GFB":      {Kind: Changed, Method: "MPARA", Name: "GFB", Old: "11784", New: "12096"},
+		"SOLZFREI": {Kind: Changed, Method: "MPARA", Name: "SOLZFREI", Old: "18130", New: "19950"},
+	}
+	for _, parameter := range diff.Parameters {
+		if want, ok := expected[parameter.Name]; ok {
+			if parameter != want {
+				t.Errorf("Expected %+v, got %+v", want, parameter)
+			}
+			delete(expected, parameter.Name)
+		}
+	}
+	for name := range expected {
+		t.Errorf("Expected a change of %s between 2024 and 2025", name)
+	}
+}
@@ -0,0 +1,543 @@
+package bmf
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Java BigDecimal rounding modes as used in the PAP files
+const (
+	RoundUp          = 0
+	RoundDown        = 1
+	RoundCeiling     = 2
+	RoundFloor       = 3
+	RoundHalfUp      = 4
+	RoundHalfDown    = 5
+	RoundHalfEven    = 6
+	RoundUnnecessary = 7
+)
+
+var bigDecimalFields = map[string]int{
+	"ROUND_UP":          RoundUp,
+	"ROUND_DOWN":        RoundDown,
+	"ROUND_CEILING":     RoundCeiling,
+	"ROUND_FLOOR":       RoundFloor,
+	"ROUND_HALF_UP":     RoundHalfUp,
+	"ROUND_HALF_DOWN":   RoundHalfDown,
+	"ROUND_HALF_EVEN":   RoundHalfEven,
+	"ROUND_UNNECESSARY": RoundUnnecessary,
+}
+
+// classRef is the value of a bare class name such as BigDecimal, used as the
+// receiver of static fields and methods
+type classRef struct {
+	name string
+}
+
+// executeAssignment runs the exec attribute of an EVAL element
+func (tc *TaxCalculator) executeAssignment(src string) error {
+	assignment, err := ParseAssignment(src)
+	if err != nil {
+		return err
+	}
+
+	value, err := tc.evalExpr(assignment.Value)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate %q: %w", src, err)
+	}
+
+	return tc.assign(assignment.Target, value)
+}
+
+// evaluateCondition runs the expr attribute of an IF element
+func (tc *TaxCalculator) evaluateCondition(src string) (bool, error) {
+	expr, err := ParseExpression(src)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tc.evaluateConditionExpr(expr)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate %q: %w", src, err)
+	}
+	return result, nil
+}
+
+func (tc *TaxCalculator) evaluateConditionExpr(expr Expr) (bool, error) {
+	value, err := tc.evalExpr(expr)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition %s is not boolean: %T", expr.String(), value)
+	}
+	return result, nil
+}
+
+func (tc *TaxCalculator) assign(target Expr, value interface{}) error {
+	switch t := target.(type) {
+	case *Ident:
+		tc.setVariableValue(t.Name, value)
+		return nil
+	case *IndexExpr:
+		array, ok := t.Array.(*Ident)
+		if !ok {
+			return fmt.Errorf("unsupported assignment target %s", target.String())
+		}
+		current, err := tc.getVariableValue(array.Name)
+		if err != nil {
+			return err
+		}
+		index, err := tc.evalExpr(t.Index)
+		if err != nil {
+			return err
+		}
+		updated, err := setIndex(current, index, value)
+		if err != nil {
+			return err
+		}
+		tc.setVariableValue(array.Name, updated)
+		return nil
+	default:
+		return fmt.Errorf("unsupported assignment target %s", target.String())
+	}
+}
+
+func (tc *TaxCalculator) evalExpr(expr Expr) (interface{}, error) {
+	switch e := expr.(type) {
+	case *NumberLit:
+		return parseNumberLiteral(e.Text)
+
+	case *BoolLit:
+		return e.Value, nil
+
+	case *Ident:
+		if e.Name == "BigDecimal" {
+			return classRef{name: e.Name}, nil
+		}
+		return tc.getVariableValue(e.Name)
+
+	case *FieldAccess:
+		target, err := tc.evalExpr(e.Target)
+		if err != nil {
+			return nil, err
+		}
+		return staticField(target, e.Name)
+
+	case *MethodCall:
+		receiver, err := tc.evalExpr(e.Receiver)
+		if err != nil {
+			return nil, err
+		}
+		args, err := tc.evalArgs(e.Args)
+		if err != nil {
+			return nil, err
+		}
+		return callMethod(receiver, e.Method, args)
+
+	case *NewObject:
+		args, err := tc.evalArgs(e.Args)
+		if err != nil {
+			return nil, err
+		}
+		return newObject(e.Type, args)
+
+	case *IndexExpr:
+		array, err := tc.evalExpr(e.Array)
+		if err != nil {
+			return nil, err
+		}
+		index, err := tc.evalExpr(e.Index)
+		if err != nil {
+			return nil, err
+		}
+		return indexValue(array, index)
+
+	case *UnaryExpr:
+		operand, err := tc.evalExpr(e.Operand)
+		if err != nil {
+			return nil, err
+		}
+		return unaryOp(e.Op, operand)
+
+	case *BinaryExpr:
+		left, err := tc.evalExpr(e.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		// Short-circuit boolean operators like Java does
+		if e.Op == "&&" || e.Op == "||" {
+			leftBool, ok := left.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator %s requires boolean operands, got %T", e.Op, left)
+			}
+			if (e.Op == "&&" && !leftBool) || (e.Op == "||" && leftBool) {
+				return leftBool, nil
+			}
+		}
+
+		right, err := tc.evalExpr(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return binaryOp(e.Op, left, right)
+
+	case *ArrayLit:
+		return tc.evalArgs(e.Elements)
+
+	default:
+		return nil, fmt.Errorf("unsupported expression %T", expr)
+	}
+}
+
+func (tc *TaxCalculator) evalArgs(exprs []Expr) ([]interface{}, error) {
+	values := make([]interface{}, len(exprs))
+	for i, arg := range exprs {
+		value, err := tc.evalExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func parseNumberLiteral(text string) (interface{}, error) {
+	if !strings.ContainsAny(text, ".eE") {
+		var i int
+		if _, err := fmt.Sscanf(text, "%d", &i); err != nil {
+			return nil, fmt.Errorf("invalid integer literal %q", text)
+		}
+		return i, nil
+	}
+
+	// Java reads such literals as double, so 0.320 becomes 0.32
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid decimal literal %q", text)
+	}
+	return f, nil
+}
+
+func staticField(target interface{}, name string) (interface{}, error) {
+	class, ok := target.(classRef)
+	if !ok || class.name != "BigDecimal" {
+		return nil, fmt.Errorf("unknown field %s on %T", name, target)
+	}
+
+	switch name {
+	case "ZERO":
+		return DecimalZero, nil
+	case "ONE":
+		return DecimalOne, nil
+	case "TEN":
+		return DecimalTen, nil
+	}
+
+	if mode, ok := bigDecimalFields[name]; ok {
+		return mode, nil
+	}
+	return nil, fmt.Errorf("unknown field BigDecimal.%s", name)
+}
+
+func newObject(typeName string, args []interface{}) (interface{}, error) {
+	if typeName != "BigDecimal" {
+		return nil, fmt.Errorf("cannot instantiate %s", typeName)
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("new BigDecimal expects 1 argument, got %d", len(args))
+	}
+	// Unlike BigDecimal.valueOf, the constructor takes a double exactly
+	if f, ok := args[0].(float64); ok {
+		return DecimalFromFloatExact(f)
+	}
+	return toDecimal(args[0])
+}
+
+func callMethod(receiver interface{}, method string, args []interface{}) (interface{}, error) {
+	if class, ok := receiver.(classRef); ok {
+		if class.name == "BigDecimal" && method == "valueOf" && len(args) == 1 {
+			return toDecimal(args[0])
+		}
+		return nil, fmt.Errorf("unknown static method %s.%s/%d", class.name, method, len(args))
+	}
+
+	value, err := toDecimal(receiver)
+	if err != nil {
+		return nil, fmt.Errorf("cannot call %s on %T", method, receiver)
+	}
+
+	switch method {
+	case "add", "subtract", "multiply", "max", "min", "compareTo", "remainder":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expects 1 argument, got %d", method, len(args))
+		}
+		other, err := toDecimal(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+
+		switch method {
+		case "add":
+			return value.Add(other), nil
+		case "subtract":
+			return value.Sub(other), nil
+		case "multiply":
+			return value.Mul(other), nil
+		case "max":
+			return value.Max(other), nil
+		case "min":
+			return value.Min(other), nil
+		case "remainder":
+			return value.Remainder(other)
+		default:
+			return value.Cmp(other), nil
+		}
+
+	case "divide":
+		if len(args) < 1 || len(args) > 3 {
+			return nil, fmt.Errorf("divide expects 1 to 3 arguments, got %d", len(args))
+		}
+		divisor, err := toDecimal(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("divide: %w", err)
+		}
+
+		switch len(args) {
+		case 1:
+			return value.Div(divisor)
+		case 2:
+			mode, ok := args[1].(int)
+			if !ok {
+				return nil, fmt.Errorf("divide: rounding mode must be an int, got %T", args[1])
+			}
+			return value.DivRound(divisor, mode)
+		default:
+			scale, mode, err := scaleAndMode(args[1:])
+			if err != nil {
+				return nil, fmt.Errorf("divide: %w", err)
+			}
+			return value.DivScale(divisor, scale, mode)
+		}
+
+	case "setScale":
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("setScale expects 1 or 2 arguments, got %d", len(args))
+		}
+		if len(args) == 1 {
+			args = append(args, RoundUnnecessary)
+		}
+		scale, mode, err := scaleAndMode(args)
+		if err != nil {
+			return nil, fmt.Errorf("setScale: %w", err)
+		}
+		return value.SetScale(scale, mode)
+
+	case "negate":
+		return value.Neg(), nil
+	case "abs":
+		return value.Abs(), nil
+	case "signum":
+		return value.Sign(), nil
+	case "scale":
+		return value.Scale(), nil
+	case "intValue", "longValue":
+		return value.IntValue(), nil
+	}
+
+	return nil, fmt.Errorf("unknown method %s/%d", method, len(args))
+}
+
+func scaleAndMode(args []interface{}) (int, int, error) {
+	scale, ok := args[0].(int)
+	if !ok {
+		return 0, 0, fmt.Errorf("scale must be an int, got %T", args[0])
+	}
+	mode, ok := args[1].(int)
+	if !ok {
+		return 0, 0, fmt.Errorf("rounding mode must be an int, got %T", args[1])
+	}
+	return scale, mode, nil
+}
+
+func indexValue(array, index interface{}) (interface{}, error) {
+	elements, ok := array.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot index %T", array)
+	}
+	i, ok := index.(int)
+	if !ok {
+		return nil, fmt.Errorf("array index must be an int, got %T", index)
+	}
+	if i < 0 || i >= len(elements) {
+		return nil, fmt.Errorf("array index %d out of bounds [0, %d)", i, len(elements))
+	}
+	return elements[i], nil
+}
+
+// setIndex returns a copy of an array with one element replaced. Arrays are
+// never written in place, so constant tables shared by all calculations of a
+// Program keep their values.
+func setIndex(array, index, value interface{}) ([]interface{}, error) {
+	if _, err := indexValue(array, index); err != nil {
+		return nil, err
+	}
+	elements := slices.Clone(array.([]interface{}))
+	elements[index.(int)] = value
+	return elements, nil
+}
+
+func unaryOp(op string, operand interface{}) (interface{}, error) {
+	switch op {
+	case "!":
+		b, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! requires a boolean, got %T", operand)
+		}
+		return !b, nil
+	case "-":
+		switch v := operand.(type) {
+		case int:
+			return -v, nil
+		case float64:
+			return -v, nil
+		case Decimal:
+			return v.Neg(), nil
+		}
+		return nil, fmt.Errorf("operator - requires a number, got %T", operand)
+	}
+	return nil, fmt.Errorf("unknown unary operator %s", op)
+}
+
+func binaryOp(op string, left, right interface{}) (interface{}, error) {
+	switch op {
+	case "&&", "||":
+		rightBool, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires boolean operands, got %T", op, right)
+		}
+		// The left operand has already decided the short-circuit case
+		return rightBool, nil
+
+	case "==", "!=":
+		leftBool, okLeft := left.(bool)
+		rightBool, okRight := right.(bool)
+		if okLeft && okRight {
+			return (leftBool == rightBool) == (op == "=="), nil
+		}
+		fallthrough
+
+	case "<", "<=", ">", ">=":
+		leftNum, rightNum, err := toDecimals(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("operator %s: %w", op, err)
+		}
+		cmp := leftNum.Cmp(rightNum)
+		switch op {
+		case "==":
+			return cmp == 0, nil
+		case "!=":
+			return cmp != 0, nil
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+
+	case "+", "-", "*", "/", "%":
+		leftInt, okLeft := left.(int)
+		rightInt, okRight := right.(int)
+		if okLeft && okRight {
+			return intOp(op, leftInt, rightInt)
+		}
+
+		leftNum, rightNum, err := toDecimals(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("operator %s: %w", op, err)
+		}
+		switch op {
+		case "+":
+			return leftNum.Add(rightNum), nil
+		case "-":
+			return leftNum.Sub(rightNum), nil
+		case "*":
+			return leftNum.Mul(rightNum), nil
+		case "/":
+			return leftNum.Div(rightNum)
+		default:
+			return leftNum.Remainder(rightNum)
+		}
+	}
+
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+// intOp implements Java int arithmetic, which truncates towards zero
+func intOp(op string, left, right int) (interface{}, error) {
+	switch op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	case "%":
+		if right == 0 {
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		return left % right, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+func toDecimal(value interface{}) (Decimal, error) {
+	switch v := value.(type) {
+	case int:
+		return DecimalFromInt(v), nil
+	case Decimal:
+		return v, nil
+	case float64:
+		return DecimalFromFloat(v), nil
+	}
+	return Decimal{}, fmt.Errorf("not a number: %T", value)
+}
+
+// toFloat converts a number to a Java double
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case Decimal:
+		f, _ := v.Rat().Float64()
+		return f, nil
+	}
+	return 0, fmt.Errorf("not a number: %T", value)
+}
+
+func toDecimals(left, right interface{}) (Decimal, Decimal, error) {
+	leftNum, err := toDecimal(left)
+	if err != nil {
+		return Decimal{}, Decimal{}, fmt.Errorf("left operand: %w", err)
+	}
+	rightNum, err := toDecimal(right)
+	if err != nil {
+		return Decimal{}, Decimal{}, fmt.Errorf("right operand: %w", err)
+	}
+	return leftNum, rightNum, nil
+}
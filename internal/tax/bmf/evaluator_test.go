@@ -0,0 +1,162 @@
+package bmf
+
+import (
+	"testing"
+)
+
+func newTestCalculator() *TaxCalculator {
+	return &TaxCalculator{
+		XMLData: &PAPData{},
+		InputValues: map[string]interface{}{
+			"RE4":  DecimalFromInt(5000000),
+			"STKL": 1,
+			"KRV":  0,
+			"TAB1": []interface{}{MustParseDecimal("0.0"), MustParseDecimal("0.4"), MustParseDecimal("38.4")},
+		},
+		OutputValues: make(map[string]interface{}),
+		InternalVars: map[string]interface{}{
+			"J": 2,
+		},
+		Constants: map[string]interface{}{
+			"ZAHL100": DecimalFromInt(100),
+			"ZAHL12":  DecimalFromInt(12),
+		},
+	}
+}
+
+func TestTaxCalculatorEvaluateCondition(t *testing.T) {
+	calculator := newTestCalculator()
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"KRV < 1", true},
+		{"STKL == 1 && KRV == 0", true},
+		{"STKL > 1 || KRV != 0", false},
+		{"!(STKL >= 2)", true},
+		{"RE4.compareTo(ZAHL100) == 1", true},
+		{"RE4.compareTo(BigDecimal.ZERO) == 0", false},
+		{"TAB1[J].compareTo(BigDecimal.valueOf(38.4)) == 0", true},
+		{"- -5 == 5", true},
+		{"new BigDecimal(0.1).compareTo(BigDecimal.valueOf(0.1)) == 1", true},
+		{"new BigDecimal(0.5).compareTo(BigDecimal.valueOf(0.5)) == 0", true},
+		{"-(-J) == 2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result, err := calculator.evaluateCondition(tt.expr)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestTaxCalculatorExecuteAssignment(t *testing.T) {
+	tests := []struct {
+		exec     string
+		target   string
+		expected string
+	}{
+		{"ZRE4J = (RE4.divide(ZAHL100)).setScale(2, BigDecimal.ROUND_DOWN)", "ZRE4J", "50000.00"},
+		{"X = RE4.divide(ZAHL12, 2, BigDecimal.ROUND_DOWN)", "X", "416666.66"},
+		{"X = RE4.divide(ZAHL12, 2, BigDecimal.ROUND_UP)", "X", "416666.67"},
+		{"X = BigDecimal.valueOf(0.093).multiply(new BigDecimal(96600))", "X", "8983.800"},
+		{"X = BigDecimal.valueOf(2.5).setScale(0, BigDecimal.ROUND_HALF_UP)", "X", "3"},
+		{"X = BigDecimal.valueOf(2.5).setScale(0, BigDecimal.ROUND_HALF_EVEN)", "X", "2"},
+		{"X = BigDecimal.valueOf(-2.5).setScale(0, BigDecimal.ROUND_HALF_DOWN)", "X", "-2"},
+		{"X = BigDecimal.valueOf(-2.1).setScale(0, BigDecimal.ROUND_FLOOR)", "X", "-3"},
+		{"X = BigDecimal.valueOf(2.1).setScale(0, BigDecimal.ROUND_CEILING)", "X", "3"},
+		{"X = BigDecimal.ONE.negate().abs().max(BigDecimal.TEN)", "X", "10"},
+		{"X = TAB1[J - 1]", "X", "0.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exec, func(t *testing.T) {
+			calculator := newTestCalculator()
+
+			if err := calculator.executeAssignment(tt.exec); err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			result, ok := calculator.InternalVars[tt.target].(Decimal)
+			if !ok {
+				t.Fatalf("Expected Decimal, got %T", calculator.InternalVars[tt.target])
+			}
+			if result.String() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestTaxCalculatorExecuteIntAssignment(t *testing.T) {
+	calculator := newTestCalculator()
+
+	if err := calculator.executeAssignment("J = (2040 - 2005) / 2 + 7 % 3"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if calculator.InternalVars["J"] != 18 {
+		t.Errorf("Expected 18, got %v", calculator.InternalVars["J"])
+	}
+
+	if err := calculator.executeAssignment("K = RE4.divide(ZAHL100).intValue()"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if calculator.InternalVars["K"] != 50000 {
+		t.Errorf("Expected 50000, got %v", calculator.InternalVars["K"])
+	}
+}
+
+func TestTaxCalculatorExecuteElementAssignment(t *testing.T) {
+	calculator := newTestCalculator()
+	table := []interface{}{DecimalZero, DecimalZero, DecimalZero}
+	calculator.InternalVars["H"] = table
+
+	if err := calculator.executeAssignment("H[J - 1] = RE4.divide(ZAHL100)"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	updated := calculator.InternalVars["H"].([]interface{})
+	if got := updated[1].(Decimal).String(); got != "50000" {
+		t.Errorf("Expected H[1] = 50000, got %s", got)
+	}
+	if got := table[1].(Decimal).String(); got != "0" {
+		t.Errorf("Expected the previous array to keep 0, got %s", got)
+	}
+}
+
+func TestTaxCalculatorEvaluationErrors(t *testing.T) {
+	calculator := newTestCalculator()
+
+	execs := []string{
+		"X = UNKNOWN.add(RE4)",
+		"X = RE4.divide(BigDecimal.ZERO)",
+		"X = BigDecimal.valueOf(0.125).setScale(2)",
+		"X = RE4.frobnicate()",
+		"X = TAB1[5]",
+		"TAB1[3] = RE4",
+		"RE4[0] = RE4",
+		"TAB1[RE4] = RE4",
+		"X = BigDecimal.ONE.divide(BigDecimal.valueOf(3))",
+		"X = STKL / 0",
+		"X = KRV && true",
+	}
+
+	for _, exec := range execs {
+		if err := calculator.executeAssignment(exec); err == nil {
+			t.Errorf("Expected error for %q, got none", exec)
+		}
+	}
+
+	if _, err := calculator.evaluateCondition("RE4.add(ZAHL100)"); err == nil {
+		t.Error("Expected error for non-boolean condition")
+	}
+}
@@ -0,0 +1,501 @@
+package bmf
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Expr is a node of a parsed PAP expression. The PAP files use a small
+// subset of Java: arithmetic, comparisons, boolean logic, BigDecimal method
+// chains, static field access and array indexing.
+type Expr interface {
+	String() string
+}
+
+type NumberLit struct {
+	Text string
+}
+
+type BoolLit struct {
+	Value bool
+}
+
+type Ident struct {
+	Name string
+}
+
+type FieldAccess struct {
+	Target Expr
+	Name   string
+}
+
+type MethodCall struct {
+	Receiver Expr
+	Method   string
+	Args     []Expr
+}
+
+type NewObject struct {
+	Type string
+	Args []Expr
+}
+
+type IndexExpr struct {
+	Array Expr
+	Index Expr
+}
+
+type UnaryExpr struct {
+	Op      string
+	Operand Expr
+}
+
+type BinaryExpr struct {
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+type ArrayLit struct {
+	Elements []Expr
+}
+
+// Assignment is the statement form used by EVAL elements: TARGET = expr
+type Assignment struct {
+	Target Expr
+	Value  Expr
+}
+
+func (e *NumberLit) String() string { return e.Text }
+
+func (e *BoolLit) String() string {
+	if e.Value {
+		return "true"
+	}
+	return "false"
+}
+
+func (e *Ident) String() string { return e.Name }
+
+func (e *FieldAccess) String() string { return e.Target.String() + "." + e.Name }
+
+func (e *MethodCall) String() string {
+	return fmt.Sprintf("%s.%s(%s)", e.Receiver.String(), e.Method, joinExprs(e.Args))
+}
+
+func (e *NewObject) String() string {
+	return fmt.Sprintf("new %s(%s)", e.Type, joinExprs(e.Args))
+}
+
+func (e *IndexExpr) String() string {
+	return fmt.Sprintf("%s[%s]", e.Array.String(), e.Index.String())
+}
+
+func (e *UnaryExpr) String() string {
+	operand := e.Operand.String()
+	if strings.HasPrefix(operand, e.Op) {
+		// - -1 must not read as --1
+		return e.Op + " " + operand
+	}
+	return e.Op + operand
+}
+
+func (e *BinaryExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", e.Left.String(), e.Op, e.Right.String())
+}
+
+func (e *ArrayLit) String() string { return "{" + joinExprs(e.Elements) + "}" }
+
+func (a *Assignment) String() string {
+	return a.Target.String() + " = " + a.Value.String()
+}
+
+func joinExprs(exprs []Expr) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		parts[i] = e.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Operators sorted so that longer ones are matched first
+var exprOperators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "=", "!",
+	"(", ")", "[", "]", "{", "}", ",", ".",
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+
+		if unicode.IsSpace(c) {
+			i++
+			continue
+		}
+
+		if unicode.IsLetter(c) || c == '_' || c == '$' {
+			start := i
+			for i < len(src) && (isIdentChar(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+			continue
+		}
+
+		if unicode.IsDigit(c) || (c == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))) {
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && unicode.IsDigit(rune(src[i])) {
+					i++
+				}
+			}
+			text := src[start:i]
+			// Java literal suffixes carry no meaning for the PAP
+			if i < len(src) && strings.ContainsRune("lLdDfF", rune(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, pos: start})
+			continue
+		}
+
+		matched := false
+		for _, op := range exprOperators {
+			if strings.HasPrefix(src[i:], op) {
+				tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+				i += len(op)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, pos: len(src)})
+	return tokens, nil
+}
+
+func isIdentChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '$'
+}
+
+type exprParser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+// ParseExpression parses a PAP expression such as the expr attribute of an IF
+func ParseExpression(src string) (Expr, error) {
+	p, err := newExprParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// ParseAssignment parses the exec attribute of an EVAL element
+func ParseAssignment(src string) (*Assignment, error) {
+	p, err := newExprParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+
+	switch target.(type) {
+	case *Ident, *IndexExpr:
+	default:
+		return nil, fmt.Errorf("invalid assignment target %s in %q", target.String(), src)
+	}
+
+	if !p.accept("=") {
+		return nil, fmt.Errorf("expected '=' after %s in %q", target.String(), src)
+	}
+
+	value, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+	return &Assignment{Target: target, Value: value}, nil
+}
+
+func newExprParser(src string) (*exprParser, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to tokenize %q: %w", src, err)
+	}
+	return &exprParser{src: src, tokens: tokens}, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) accept(op string) bool {
+	t := p.peek()
+	if t.kind == tokOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		return p.errorf("expected %q", op)
+	}
+	return nil
+}
+
+func (p *exprParser) expectEOF() error {
+	if p.peek().kind != tokEOF {
+		return p.errorf("unexpected %q", p.peek().text)
+	}
+	return nil
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d in %q", fmt.Sprintf(format, args...), p.peek().pos, p.src)
+}
+
+func (p *exprParser) parseBinary(ops []string, operand func() (Expr, error)) (Expr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t.kind != tokOp || !containsString(ops, t.text) {
+			return left, nil
+		}
+		p.next()
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: t.text, Left: left, Right: right}
+	}
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	return p.parseBinary([]string{"||"}, p.parseAnd)
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	return p.parseBinary([]string{"&&"}, p.parseEquality)
+}
+
+func (p *exprParser) parseEquality() (Expr, error) {
+	return p.parseBinary([]string{"==", "!="}, p.parseRelational)
+}
+
+func (p *exprParser) parseRelational() (Expr, error) {
+	return p.parseBinary([]string{"<", "<=", ">", ">="}, p.parseAdditive)
+}
+
+func (p *exprParser) parseAdditive() (Expr, error) {
+	return p.parseBinary([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *exprParser) parseMultiplicative() (Expr, error) {
+	return p.parseBinary([]string{"*", "/", "%"}, p.parseUnary)
+}
+
+func (p *exprParser) parseUnary() (Expr, error) {
+	t := p.peek()
+	if t.kind == tokOp && (t.text == "-" || t.text == "!" || t.text == "+") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if t.text == "+" {
+			return operand, nil
+		}
+		// Fold negative literals so that -1 stays a literal; - -1 does not
+		if num, ok := operand.(*NumberLit); ok && t.text == "-" && !strings.HasPrefix(num.Text, "-") {
+			return &NumberLit{Text: "-" + num.Text}, nil
+		}
+		return &UnaryExpr{Op: t.text, Operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *exprParser) parsePostfix() (Expr, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.accept("."):
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, p.errorf("expected member name after '.'")
+			}
+			if p.accept("(") {
+				args, err := p.parseArgs(")")
+				if err != nil {
+					return nil, err
+				}
+				expr = &MethodCall{Receiver: expr, Method: name.text, Args: args}
+			} else {
+				expr = &FieldAccess{Target: expr, Name: name.text}
+			}
+
+		case p.accept("["):
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			expr = &IndexExpr{Array: expr, Index: index}
+
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (p *exprParser) parsePrimary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &NumberLit{Text: t.text}, nil
+
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &BoolLit{Value: true}, nil
+		case "false":
+			return &BoolLit{Value: false}, nil
+		case "new":
+			typeName := p.next()
+			if typeName.kind != tokIdent {
+				return nil, p.errorf("expected type name after 'new'")
+			}
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			args, err := p.parseArgs(")")
+			if err != nil {
+				return nil, err
+			}
+			return &NewObject{Type: typeName.text, Args: args}, nil
+		}
+		return &Ident{Name: t.text}, nil
+
+	case tokOp:
+		switch t.text {
+		case "(":
+			expr, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+		case "{":
+			elements, err := p.parseArgs("}")
+			if err != nil {
+				return nil, err
+			}
+			return &ArrayLit{Elements: elements}, nil
+		}
+	}
+
+	if t.kind == tokEOF {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	p.pos--
+	return nil, p.errorf("unexpected %q", t.text)
+}
+
+func (p *exprParser) parseArgs(closing string) ([]Expr, error) {
+	var args []Expr
+	if p.accept(closing) {
+		return args, nil
+	}
+
+	for {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if p.accept(closing) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package bmf
+
+import (
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "comparison",
+			input:    "KRV < 1",
+			expected: "(KRV < 1)",
+		},
+		{
+			name:     "equality with boolean logic",
+			input:    "STKL == 6 && ZKF.compareTo(BigDecimal.ZERO) == 1 || af != 0",
+			expected: "(((STKL == 6) && (ZKF.compareTo(BigDecimal.ZERO) == 1)) || (af != 0))",
+		},
+		{
+			name:     "method chain with spaces before parentheses",
+			input:    "(RE4.divide (ZAHL100)).setScale (2, BigDecimal.ROUND_DOWN)",
+			expected: "RE4.divide(ZAHL100).setScale(2, BigDecimal.ROUND_DOWN)",
+		},
+		{
+			name:     "static factory and constructor",
+			input:    "BigDecimal.valueOf(0.093).add(new BigDecimal(96600))",
+			expected: "BigDecimal.valueOf(0.093).add(new BigDecimal(96600))",
+		},
+		{
+			name:     "array indexing",
+			input:    "TAB1[J].multiply(ZAHL100)",
+			expected: "TAB1[J].multiply(ZAHL100)",
+		},
+		{
+			name:     "arithmetic precedence",
+			input:    "VJAHR - 2004 * 2 + 1",
+			expected: "((VJAHR - (2004 * 2)) + 1)",
+		},
+		{
+			name:     "negative literal and negation",
+			input:    "!(X >= -1)",
+			expected: "!(X >= -1)",
+		},
+		{
+			name:     "double negation",
+			input:    "- -5",
+			expected: "- -5",
+		},
+		{
+			name:     "array literal",
+			input:    "{BigDecimal.valueOf (0.0), BigDecimal.valueOf (0.4)}",
+			expected: "{BigDecimal.valueOf(0.0), BigDecimal.valueOf(0.4)}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseExpression(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if expr.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, expr.String())
+			}
+		})
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	inputs := []string{
+		"",
+		"A +",
+		"(A",
+		"A.divide(B",
+		"A # B",
+		"A B",
+		"new (1)",
+	}
+
+	for _, input := range inputs {
+		if _, err := ParseExpression(input); err == nil {
+			t.Errorf("Expected error for %q, got none", input)
+		}
+	}
+}
+
+func TestParseAssignment(t *testing.T) {
+	assignment, err := ParseAssignment("ZRE4J = (RE4.divide(ZAHL100)).setScale(2, BigDecimal.ROUND_DOWN)")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	target, ok := assignment.Target.(*Ident)
+	if !ok || target.Name != "ZRE4J" {
+		t.Errorf("Expected target ZRE4J, got %v", assignment.Target)
+	}
+
+	if _, ok := assignment.Value.(*MethodCall); !ok {
+		t.Errorf("Expected method call value, got %T", assignment.Value)
+	}
+
+	indexed, err := ParseAssignment("TAB[J] = BigDecimal.ONE")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, ok := indexed.Target.(*IndexExpr); !ok {
+		t.Errorf("Expected index target, got %T", indexed.Target)
+	}
+}
+
+func TestParseAssignmentErrors(t *testing.T) {
+	inputs := []string{
+		"X",
+		"X == 1",
+		"A.add(B) = C",
+		"1 = X",
+		"X = ",
+	}
+
+	for _, input := range inputs {
+		if _, err := ParseAssignment(input); err == nil {
+			t.Errorf("Expected error for %q, got none", input)
+		}
+	}
+}
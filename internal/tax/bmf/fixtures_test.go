@@ -0,0 +1,173 @@
+package bmf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tax-calculator/internal/tax/models"
+)
+
+// recordFromMock records the answers of a MockServer to requests in dir
+func recordFromMock(t *testing.T, dir string, faults MockFaults, requests ...models.TaxRequest) {
+	t.Helper()
+	server := httptest.NewServer(NewMockServer(WithFaults(faults)))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL+"/interface"), WithRecording(dir), WithRetryPolicy(NoRetry))
+	for _, req := range requests {
+		client.CalculateTax(context.Background(), req)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, KVZ: 2.5, Year: 2025}
+	recordFromMock(t, dir, MockFaults{}, req)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 || !strings.HasPrefix(filepath.Base(files[0]), "2025Version1-") {
+		t.Fatalf("Expected a fixture named after the version, got %v", files)
+	}
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if fixture := fixtures[0]; fixture.Method != http.MethodGet || fixture.Status != http.StatusOK ||
+		!strings.HasPrefix(fixture.URL, "/interface/2025Version1.xhtml?") || !strings.Contains(fixture.Body, `name="LSTLZZ"`) {
+		t.Errorf("Unexpected fixture %+v", fixture)
+	}
+
+	// The default interface has the same path, and nothing listens there
+	t.Setenv(APIBaseURLEnv, "")
+	client := NewClient(WithReplay(dir), WithRetryPolicy(NoRetry))
+	response, err := client.CalculateTax(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected the recorded answer, got: %v", err)
+	}
+	outputs, err := response.TaxOutputs()
+	if err != nil || outputs.LSTLZZ == 0 {
+		t.Errorf("Expected the recorded Lohnsteuer, got %+v, %v", outputs, err)
+	}
+}
+
+func TestReplayUnknownRequest(t *testing.T) {
+	t.Setenv(APIBaseURLEnv, "")
+	dir := t.TempDir()
+	client := NewClient(WithReplay(dir), WithRetryPolicy(fastRetry))
+
+	_, err := client.CalculateTax(context.Background(), clientRequest)
+	if !errors.Is(err, ErrNoFixture) {
+		t.Fatalf("Expected ErrNoFixture, got: %v", err)
+	}
+	for _, expected := range []string{"GET /interface/2025Version1.xhtml?", filepath.Join(dir, "2025Version1-")} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in the error, got: %v", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "attempts") {
+		t.Errorf("Expected no retries, got: %v", err)
+	}
+}
+
+func TestReplayRecordedError(t *testing.T) {
+	dir := t.TempDir()
+	recordFromMock(t, dir, MockFaults{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable}, clientRequest)
+
+	client := NewClient(WithReplay(dir), WithRetryPolicy(NoRetry))
+	_, err := client.CalculateTax(context.Background(), clientRequest)
+	if err == nil || !strings.Contains(err.Error(), "503 Service Unavailable") {
+		t.Errorf("Expected the recorded 503, got: %v", err)
+	}
+}
+
+func TestRecordAndReplayRejected(t *testing.T) {
+	record, replay := t.TempDir(), t.TempDir()
+	recordFromMock(t, replay, MockFaults{}, clientRequest)
+
+	for name, opts := range map[string][]ClientOption{
+		"record first": {WithRecording(record), WithReplay(replay)},
+		"replay first": {WithReplay(replay), WithRecording(record)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := NewClient(append(opts, WithRetryPolicy(fastRetry))...)
+			_, err := client.CalculateTax(context.Background(), clientRequest)
+			if !errors.Is(err, ErrRecordAndReplay) {
+				t.Fatalf("Expected ErrRecordAndReplay, got: %v", err)
+			}
+			if strings.Contains(err.Error(), "attempts") {
+				t.Errorf("Expected no retries, got: %v", err)
+			}
+			if files, _ := filepath.Glob(filepath.Join(record, "*.json")); len(files) != 0 {
+				t.Errorf("Expected nothing recorded, got %v", files)
+			}
+		})
+	}
+}
+
+func TestFixtureOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  string
+		replay  string
+		options int
+		err     error
+	}{
+		{"neither", "", "", 0, nil},
+		{"record", "a", "", 1, nil},
+		{"replay", "", "b", 1, nil},
+		{"both", "a", "b", 0, ErrRecordAndReplay},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(RecordDirEnv, tt.record)
+			t.Setenv(ReplayDirEnv, tt.replay)
+			opts, err := FixtureOptionsFromEnv()
+			if !errors.Is(err, tt.err) || len(opts) != tt.options {
+				t.Errorf("Expected %d options and %v, got %d and %v", tt.options, tt.err, len(opts), err)
+			}
+			if err != nil && !strings.Contains(err.Error(), RecordDirEnv) {
+				t.Errorf("Expected the error to name %s, got: %v", RecordDirEnv, err)
+			}
+		})
+	}
+}
+
+func TestFixtureCalculation(t *testing.T) {
+	fixture := Fixture{Method: "GET", URL: "/interface/2024Version2.xhtml?LZZ=1&RE4=5000000&STKL=3&code=extS2024"}
+	version, inputs, err := fixture.Calculation()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if version.Version != "2024Version2" || version.Year != 2024 {
+		t.Errorf("Expected 2024Version2, got %+v", version)
+	}
+	if re4, ok := inputs["RE4"].(Decimal); !ok || re4.Cmp(DecimalFromInt(5000000)) != 0 {
+		t.Errorf("Expected RE4 5000000, got %v", inputs["RE4"])
+	}
+	if inputs["STKL"] != 3 {
+		t.Errorf("Expected STKL 3, got %v", inputs["STKL"])
+	}
+	if _, ok := inputs["code"]; ok {
+		t.Error("Expected the code not to be an input")
+	}
+}
+
+func TestLoadFixturesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFixtures(dir); err == nil || !strings.Contains(err.Error(), "broken.json") {
+		t.Errorf("Expected an error naming the file, got: %v", err)
+	}
+
+	if _, _, err := (Fixture{URL: "/javax.faces.resource/daten/xmls/Lohnsteuer2025.xml.xhtml"}).Calculation(); err == nil {
+		t.Error("Expected an error for a request that is not a calculation")
+	}
+}
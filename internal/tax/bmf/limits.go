@@ -0,0 +1,128 @@
+package bmf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Limits bound a single calculation, so a malformed PAP, e.g. one whose
+// methods EXECUTE each other in a cycle, fails with a *LimitError instead of
+// overflowing the stack or running forever. A zero field means no limit.
+type Limits struct {
+	// MaxDepth is how deeply EXECUTE statements may nest below MAIN
+	MaxDepth int
+	// MaxSteps is how many statements a calculation may run
+	MaxSteps int
+	// Timeout is how long a calculation may take, on top of any deadline of
+	// the context it runs with
+	Timeout time.Duration
+}
+
+// DefaultLimits leave ample room for the BMF PAPs, which nest four methods
+// deep and run a few hundred statements per calculation
+var DefaultLimits = Limits{MaxDepth: 64, MaxSteps: 100000, Timeout: 5 * time.Second}
+
+// LimitKind tells which limit stopped a calculation
+type LimitKind string
+
+const (
+	DepthLimit    LimitKind = "call depth"
+	StepLimit     LimitKind = "step"
+	DeadlineLimit LimitKind = "deadline"
+)
+
+// LimitError reports where a calculation was stopped by its Limits or its
+// context. Step counts the statements run so far, including the one that
+// was stopped.
+type LimitError struct {
+	Limit     LimitKind
+	Max       int
+	Method    string
+	Step      int
+	Statement string
+
+	// The context's error for DeadlineLimit
+	Err error
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == DeadlineLimit {
+		return fmt.Sprintf("calculation stopped in method %s at step %d (%s): %v", e.Method, e.Step, e.Statement, e.Err)
+	}
+	return fmt.Sprintf("%s limit of %d exceeded in method %s at step %d (%s)", e.Limit, e.Max, e.Method, e.Step, e.Statement)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// context adds the timeout to the context of a calculation
+func (l Limits) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.Timeout > 0 {
+		return context.WithTimeout(ctx, l.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// guard enforces the limits of one calculation for either engine. Both count
+// the same statements, so they stop at the same step.
+type guard struct {
+	limits Limits
+	ctx    context.Context
+	done   <-chan struct{}
+	depth  int
+	steps  int
+}
+
+func newGuard(ctx context.Context, limits Limits) guard {
+	return guard{limits: limits, ctx: ctx, done: ctx.Done()}
+}
+
+// step counts a statement of method that is about to run
+func (g *guard) step(method string, statement Statement) error {
+	g.steps++
+	if g.limits.MaxSteps > 0 && g.steps > g.limits.MaxSteps {
+		return g.stop(StepLimit, g.limits.MaxSteps, method, statement)
+	}
+	select {
+	case <-g.done:
+		return g.stop(DeadlineLimit, 0, method, statement)
+	default:
+		return nil
+	}
+}
+
+// enter is called by an EXECUTE statement of method before the call, and
+// leave after it
+func (g *guard) enter(method string, statement Statement) error {
+	if g.limits.MaxDepth > 0 && g.depth >= g.limits.MaxDepth {
+		return g.stop(DepthLimit, g.limits.MaxDepth, method, statement)
+	}
+	g.depth++
+	return nil
+}
+
+func (g *guard) leave() {
+	g.depth--
+}
+
+func (g *guard) stop(limit LimitKind, value int, method string, statement Statement) *LimitError {
+	err := &LimitError{Limit: limit, Max: value, Method: method, Step: g.steps, Statement: statementSource(statement)}
+	if limit == DeadlineLimit {
+		err.Err = g.ctx.Err()
+	}
+	return err
+}
+
+// callError adds the called method to an error from its body. Limit errors
+// already name their method and step, and in a cycle would otherwise repeat
+// the whole call stack.
+func callError(method string, err error) error {
+	var limit *LimitError
+	if errors.As(err, &limit) {
+		return err
+	}
+	return fmt.Errorf("error executing method %s: %w", method, err)
+}
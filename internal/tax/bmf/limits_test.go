@@ -0,0 +1,126 @@
+package bmf
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cyclicPAP has MA and MB EXECUTE each other forever once A is 1
+const cyclicPAP = `<PAP name="Cyclic">
+	<VARIABLES>
+		<INPUTS><INPUT name="A" type="int"/></INPUTS>
+		<OUTPUTS><OUTPUT name="B" type="int"/></OUTPUTS>
+	</VARIABLES>
+	<CONSTANTS/>
+	<METHODS>
+		<MAIN>
+			<EVAL exec="B = 1"/>
+			<EXECUTE method="MA"/>
+		</MAIN>
+		<METHOD name="MA">
+			<EVAL exec="B = B + 1"/>
+			<IF expr="A == 1"><THEN><EXECUTE method="MB"/></THEN></IF>
+		</METHOD>
+		<METHOD name="MB">
+			<EXECUTE method="MA"/>
+		</METHOD>
+	</METHODS>
+</PAP>`
+
+// limitPrograms returns the cyclic PAP for both engines
+func limitPrograms(t *testing.T, opts ...ProgramOption) map[string]*Program {
+	t.Helper()
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(cyclicPAP), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	compiled, err := Compile(&papData, opts...)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return map[string]*Program{"compiled": compiled, "interpreted": NewProgram(&papData, opts...)}
+}
+
+func TestLimitsCallDepth(t *testing.T) {
+	for name, program := range limitPrograms(t, WithLimits(Limits{MaxDepth: 10})) {
+		outputs, err := program.Calculate(map[string]interface{}{"A": 0})
+		if err != nil || outputs["B"] != 2 {
+			t.Errorf("%s: expected B = 2 without the cycle, got %v (%v)", name, outputs, err)
+		}
+
+		_, err = program.Calculate(map[string]interface{}{"A": 1})
+		var limit *LimitError
+		if !errors.As(err, &limit) {
+			t.Fatalf("%s: expected a *LimitError, got: %v", name, err)
+		}
+		// MAIN calls MA, then MA and MB alternate: the eleventh call is MB's
+		// EXECUTE of MA after 2 + 5*4 statements
+		if limit.Limit != DepthLimit || limit.Method != "MB" || limit.Step != 22 || limit.Statement != "EXECUTE MA" {
+			t.Errorf("%s: expected the depth limit at MB step 22, got %+v", name, limit)
+		}
+		if want := "call depth limit of 10 exceeded in method MB at step 22 (EXECUTE MA)"; err.Error() != want {
+			t.Errorf("%s: expected %q without the call stack, got %q", name, want, err)
+		}
+	}
+}
+
+func TestLimitsSteps(t *testing.T) {
+	for name, program := range limitPrograms(t, WithLimits(Limits{MaxSteps: 5})) {
+		_, err := program.Calculate(map[string]interface{}{"A": 1})
+		var limit *LimitError
+		if !errors.As(err, &limit) {
+			t.Fatalf("%s: expected a *LimitError, got: %v", name, err)
+		}
+		if limit.Limit != StepLimit || limit.Method != "MB" || limit.Step != 6 || limit.Statement != "EXECUTE MA" {
+			t.Errorf("%s: expected the step limit at MB step 6, got %+v", name, limit)
+		}
+	}
+}
+
+func TestLimitsDeadline(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, program := range limitPrograms(t, WithLimits(Limits{})) {
+		_, err := program.CalculateContext(cancelled, map[string]interface{}{"A": 0})
+		var limit *LimitError
+		if !errors.As(err, &limit) || limit.Limit != DeadlineLimit || limit.Method != "MAIN" || limit.Step != 1 {
+			t.Errorf("%s: expected the calculation to stop at its first step, got: %v", name, err)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected the context's error, got: %v", name, err)
+		}
+	}
+
+	// The timeout of the limits is added to the context
+	for name, program := range limitPrograms(t, WithLimits(Limits{Timeout: time.Nanosecond})) {
+		_, err := program.Calculate(map[string]interface{}{"A": 1})
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.HasPrefix(err.Error(), "calculation stopped in method MAIN at step 1 (B = 1)") {
+			t.Errorf("%s: expected a deadline error, got: %v", name, err)
+		}
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	for name, program := range limitPrograms(t) {
+		if program.Limits() != DefaultLimits {
+			t.Errorf("%s: expected DefaultLimits, got %+v", name, program.Limits())
+		}
+		_, err := program.Calculate(map[string]interface{}{"A": 1})
+		var limit *LimitError
+		if !errors.As(err, &limit) || limit.Limit != DepthLimit || limit.Max != DefaultLimits.MaxDepth {
+			t.Errorf("%s: expected the default depth limit, got: %v", name, err)
+		}
+	}
+
+	// The calculator of a Program traces up to the stopped step
+	trace := &Trace{}
+	_, err := limitPrograms(t, WithLimits(Limits{MaxSteps: 5}))["interpreted"].Trace(map[string]interface{}{"A": 1}, trace)
+	if err == nil || trace.Len() == 0 {
+		t.Errorf("Expected a limit error and a trace, got %v with %d steps", err, trace.Len())
+	}
+}
@@ -0,0 +1,721 @@
+package bmf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LintSeverity tells whether a lint issue breaks a calculation
+type LintSeverity string
+
+const (
+	// LintError is a problem that fails a calculation or gives wrong numbers
+	LintError LintSeverity = "error"
+	// LintWarning is suspicious but harmless, such as a method nobody calls
+	LintWarning LintSeverity = "warning"
+)
+
+// LintIssue is a problem Lint found in a PAP. Method is empty for problems
+// in the declarations; Source is the PAP text the problem is in, such as the
+// exec attribute of an EVAL.
+type LintIssue struct {
+	Severity LintSeverity
+	Method   string
+	Source   string
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	var b strings.Builder
+	if i.Method != "" {
+		b.WriteString(i.Method + ": ")
+	}
+	fmt.Fprintf(&b, "%s: %s", i.Severity, i.Message)
+	if i.Source != "" {
+		fmt.Fprintf(&b, " in %q", i.Source)
+	}
+	return b.String()
+}
+
+// LintErrors returns the issues of severity LintError
+func LintErrors(issues []LintIssue) []LintIssue {
+	var errors []LintIssue
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			errors = append(errors, issue)
+		}
+	}
+	return errors
+}
+
+// CheckPAP lints a PAP and returns a *LintFailure listing its errors, or
+// nil if it has none. Warnings do not fail the check.
+func CheckPAP(papData *PAPData) error {
+	errors := LintErrors(Lint(papData))
+	if len(errors) == 0 {
+		return nil
+	}
+	name := papData.Name
+	if name == "" {
+		name = "PAP"
+	}
+	return &LintFailure{PAP: name, Issues: errors}
+}
+
+// LintFailure is the error of CheckPAP
+type LintFailure struct {
+	PAP    string
+	Issues []LintIssue
+}
+
+func (f *LintFailure) Error() string {
+	messages := make([]string, len(f.Issues))
+	for i, issue := range f.Issues {
+		messages[i] = issue.String()
+	}
+	return fmt.Sprintf("%s does not pass lint: %s", f.PAP, strings.Join(messages, "; "))
+}
+
+// lintType is the static type of a PAP value, after the conversions the
+// evaluator makes
+type lintType int
+
+const (
+	// lintUnknown follows an expression that already has an issue, so it is
+	// not reported again by every expression around it
+	lintUnknown lintType = iota
+	lintInt
+	lintDouble
+	lintBool
+	lintDecimal
+	lintDecimalArray
+	lintIntArray
+	lintClass
+)
+
+var lintTypeNames = map[lintType]string{
+	lintInt:          "int",
+	lintDouble:       "double",
+	lintBool:         "boolean",
+	lintDecimal:      "BigDecimal",
+	lintDecimalArray: "BigDecimal[]",
+	lintIntArray:     "int[]",
+	lintClass:        "class BigDecimal",
+}
+
+func (t lintType) String() string {
+	if name, ok := lintTypeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (t lintType) numeric() bool {
+	return t == lintInt || t == lintDouble || t == lintDecimal
+}
+
+// assignable reports whether a value of type from may be stored in a
+// variable of type to: ints widen to double and BigDecimal, doubles to
+// BigDecimal, and nothing narrows
+func (t lintType) assignable(to lintType) bool {
+	switch {
+	case t == to, t == lintUnknown, to == lintUnknown:
+		return true
+	case t == lintInt:
+		return to == lintDouble || to == lintDecimal
+	case t == lintDouble:
+		return to == lintDecimal
+	}
+	return false
+}
+
+func parseLintType(papType string) (lintType, bool) {
+	switch papType {
+	case "int", "long":
+		return lintInt, true
+	case "double":
+		return lintDouble, true
+	case "boolean":
+		return lintBool, true
+	case "BigDecimal":
+		return lintDecimal, true
+	case "BigDecimal[]":
+		return lintDecimalArray, true
+	case "int[]":
+		return lintIntArray, true
+	}
+	return lintUnknown, false
+}
+
+type lintVariable struct {
+	typ  lintType
+	kind string
+}
+
+type linter struct {
+	pap       *PAPData
+	variables map[string]lintVariable
+	methods   map[string]bool
+	method    string
+	source    string
+	issues    []LintIssue
+}
+
+// Lint checks a PAP without running it. It reports undefined variables,
+// EXECUTEs of unknown methods, values that do not fit the declared type of
+// a variable, unsupported operators, methods and fields, and methods that
+// are never called. The engines themselves only notice most of these when a
+// calculation takes the broken path, or not at all.
+func Lint(papData *PAPData) []LintIssue {
+	l := &linter{
+		pap:       papData,
+		variables: make(map[string]lintVariable),
+		methods:   make(map[string]bool),
+	}
+
+	l.declarations()
+
+	if len(papData.Methods.Main) == 0 {
+		l.errorf("no main method found in XML data")
+	} else if len(papData.Methods.Main) > 1 {
+		l.warnf("%d MAIN methods, only the first one runs", len(papData.Methods.Main))
+	}
+	for _, method := range papData.Methods.Method {
+		if l.methods[method.Name] {
+			l.errorf("method %s is declared twice, only the first one runs", method.Name)
+		}
+		l.methods[method.Name] = true
+	}
+
+	if len(papData.Methods.Main) > 0 {
+		l.method = "MAIN"
+		l.statements(papData.Methods.Main[0].Statements)
+	}
+	for _, method := range papData.Methods.Method {
+		l.method = method.Name
+		l.statements(method.Statements)
+	}
+	l.method, l.source = "", ""
+
+	l.reachability()
+
+	return l.issues
+}
+
+func (l *linter) report(severity LintSeverity, format string, args ...interface{}) {
+	l.issues = append(l.issues, LintIssue{
+		Severity: severity,
+		Method:   l.method,
+		Source:   l.source,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) errorf(format string, args ...interface{}) {
+	l.report(LintError, format, args...)
+}
+
+func (l *linter) warnf(format string, args ...interface{}) {
+	l.report(LintWarning, format, args...)
+}
+
+// declarations checks the declared types, defaults and constant values
+func (l *linter) declarations() {
+	declare := func(kind, name, papType, value string, isDefault bool) {
+		typ, ok := parseLintType(papType)
+		if !ok {
+			l.errorf("%s %s has unsupported type %q", kind, name, papType)
+		}
+		if _, declared := l.variables[name]; declared {
+			l.warnf("%s is declared twice, the first declaration wins", name)
+		} else {
+			l.variables[name] = lintVariable{typ: typ, kind: kind}
+		}
+		if ok && (value != "" || !isDefault) && !constantFits(papType, value) {
+			what := "value"
+			if isDefault {
+				what = "default"
+			}
+			l.errorf("%s %q of %s %s is not a valid %s", what, value, kind, name, papType)
+		}
+	}
+
+	for _, input := range l.pap.Variables.Inputs.Input {
+		declare("input", input.Name, input.Type, input.Default, true)
+	}
+	for _, output := range l.pap.Variables.Outputs.Output {
+		declare("output", output.Name, output.Type, output.Default, true)
+	}
+	for _, internal := range l.pap.Variables.Internals.Internal {
+		declare("internal", internal.Name, internal.Type, internal.Default, true)
+	}
+	for _, constant := range l.pap.Constants.Constant {
+		declare("constant", constant.Name, constant.Type, constant.Value, false)
+	}
+}
+
+// constantFits reports whether parseValue can read a value as the type
+// instead of falling back to a zero value
+func constantFits(papType, value string) bool {
+	switch papType {
+	case "int", "long":
+		if _, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return true
+		}
+		result, err := evaluateConstantExpression(value)
+		_, ok := result.(int)
+		return err == nil && ok
+	case "double", "BigDecimal":
+		if _, err := ParseDecimal(value); err == nil {
+			return true
+		}
+		result, err := evaluateConstantExpression(value)
+		if err != nil {
+			return false
+		}
+		_, err = toDecimal(result)
+		return err == nil
+	case "boolean":
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	case "BigDecimal[]", "int[]":
+		result, err := evaluateConstantExpression(value)
+		elements, ok := result.([]interface{})
+		if err != nil || !ok {
+			return false
+		}
+		for _, element := range elements {
+			if _, isInt := element.(int); papType == "int[]" && !isInt {
+				return false
+			}
+			if _, err := toDecimal(element); err != nil {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (l *linter) statements(statements []Statement) {
+	for _, statement := range statements {
+		l.statement(statement)
+	}
+}
+
+func (l *linter) statement(statement Statement) {
+	switch s := statement.(type) {
+	case *ExecuteStatement:
+		l.source = ""
+		if !l.methods[s.Method] {
+			l.errorf("EXECUTE of unknown method %s", s.Method)
+		}
+
+	case *EvalStatement:
+		if s.Assignment != nil {
+			l.source = s.Exec
+			target := l.assignTarget(s.Assignment.Target)
+			value := l.expr(s.Assignment.Value)
+			if !value.assignable(target) {
+				l.errorf("cannot assign %s to %s %s", value, target, s.Assignment.Target.String())
+			}
+			break
+		}
+
+		l.source = legacyEvalSource(s)
+		target := l.assignTarget(&Ident{Name: s.Target})
+		var value lintType
+		switch s.Op {
+		case EvalAssign:
+			value = l.operand(s.Right)
+		case EvalAdd, EvalSubtract, EvalMultiply, EvalDivide, EvalIntDivide, EvalModulo:
+			left, right := l.operand(s.Left), l.operand(s.Right)
+			switch {
+			case !l.numbers(string(s.Op), left, right):
+			case left == lintInt && right == lintInt:
+				value = lintInt
+			default:
+				value = lintDecimal
+			}
+		default:
+			l.errorf("unsupported EVAL operator %q", s.Op)
+		}
+		if !value.assignable(target) {
+			l.errorf("cannot assign %s to %s %s", value, target, s.Target)
+		}
+
+	case *IfStatement:
+		if s.Condition != nil {
+			l.source = s.Expr
+			if typ := l.expr(s.Condition); typ != lintUnknown && typ != lintBool {
+				l.errorf("condition is %s, not boolean", typ)
+			}
+		} else {
+			l.source = fmt.Sprintf("%s %s %s", s.Left, s.Op, s.Right)
+			l.comparison(s.Left, s.Right, s.Op)
+		}
+		l.statements(s.Then)
+		l.statements(s.Else)
+
+	case *CompareStatement:
+		l.source = fmt.Sprintf("%s = %s %s %s", s.Target, s.Left, s.Op, s.Right)
+		if target := l.assignTarget(&Ident{Name: s.Target}); !lintBool.assignable(target) {
+			l.errorf("cannot assign boolean to %s %s", target, s.Target)
+		}
+		l.comparison(s.Left, s.Right, s.Op)
+	}
+}
+
+func legacyEvalSource(s *EvalStatement) string {
+	if s.Op == EvalAssign {
+		return fmt.Sprintf("%s = %s", s.Target, s.Right)
+	}
+	return fmt.Sprintf("%s = %s %s %s", s.Target, s.Left, s.Op, s.Right)
+}
+
+// assignTarget checks the target of an assignment and returns its type
+func (l *linter) assignTarget(target Expr) lintType {
+	if element, ok := target.(*IndexExpr); ok {
+		array, ok := element.Array.(*Ident)
+		if !ok {
+			l.errorf("unsupported assignment target %s", target.String())
+			return lintUnknown
+		}
+		if v, ok := l.variables[array.Name]; ok && v.kind == "constant" {
+			l.errorf("assignment to constant %s", array.Name)
+		}
+		return l.expr(element)
+	}
+
+	ident, ok := target.(*Ident)
+	if !ok {
+		l.errorf("unsupported assignment target %s", target.String())
+		return lintUnknown
+	}
+	v, ok := l.variables[ident.Name]
+	if !ok {
+		l.errorf("undefined variable %s", ident.Name)
+		return lintUnknown
+	}
+	if v.kind == "constant" {
+		l.errorf("assignment to constant %s", ident.Name)
+	}
+	return v.typ
+}
+
+// operand checks a left or right attribute of a legacy element, which is a
+// variable name or a literal
+func (l *linter) operand(name string) lintType {
+	if v, ok := l.variables[name]; ok {
+		return v.typ
+	}
+	if value, ok := literalValue(name); ok {
+		switch value.(type) {
+		case int:
+			return lintInt
+		case bool:
+			return lintBool
+		}
+		return lintDecimal
+	}
+	l.errorf("undefined variable %s", name)
+	return lintUnknown
+}
+
+func (l *linter) comparison(left, right string, op ComparisonOperator) {
+	leftType, rightType := l.operand(left), l.operand(right)
+	switch op {
+	case CompEQ, CompNE:
+		if leftType == lintBool && rightType == lintBool {
+			return
+		}
+	case CompLT, CompLE, CompGT, CompGE:
+	default:
+		l.errorf("unsupported comparison operator %q", op)
+		return
+	}
+	l.numbers(string(op), leftType, rightType)
+}
+
+// numbers checks that both operands of an operator are numbers, reporting
+// whether they are known to be
+func (l *linter) numbers(op string, left, right lintType) bool {
+	if left == lintUnknown || right == lintUnknown {
+		return false
+	}
+	if !left.numeric() || !right.numeric() {
+		l.errorf("operator %s on %s and %s", op, left, right)
+		return false
+	}
+	return true
+}
+
+func (l *linter) expr(expr Expr) lintType {
+	switch e := expr.(type) {
+	case *NumberLit:
+		value, err := parseNumberLiteral(e.Text)
+		if err != nil {
+			l.errorf("%v", err)
+			return lintUnknown
+		}
+		if _, ok := value.(int); ok {
+			return lintInt
+		}
+		// Decimal literals are doubles in Java
+		return lintDouble
+
+	case *BoolLit:
+		return lintBool
+
+	case *Ident:
+		if e.Name == "BigDecimal" {
+			return lintClass
+		}
+		v, ok := l.variables[e.Name]
+		if !ok {
+			l.errorf("undefined variable %s", e.Name)
+			return lintUnknown
+		}
+		return v.typ
+
+	case *FieldAccess:
+		target := l.expr(e.Target)
+		if target == lintUnknown {
+			return lintUnknown
+		}
+		if target != lintClass {
+			l.errorf("unknown field %s on %s", e.Name, target)
+			return lintUnknown
+		}
+		switch e.Name {
+		case "ZERO", "ONE", "TEN":
+			return lintDecimal
+		}
+		if _, ok := bigDecimalFields[e.Name]; ok {
+			return lintInt
+		}
+		l.errorf("unknown field BigDecimal.%s", e.Name)
+		return lintUnknown
+
+	case *MethodCall:
+		return l.methodCall(e)
+
+	case *NewObject:
+		args := l.exprs(e.Args)
+		if e.Type != "BigDecimal" {
+			l.errorf("cannot instantiate %s", e.Type)
+			return lintUnknown
+		}
+		if len(args) != 1 {
+			l.errorf("new BigDecimal expects 1 argument, got %d", len(args))
+			return lintDecimal
+		}
+		l.decimalArgs("new BigDecimal", args)
+		return lintDecimal
+
+	case *IndexExpr:
+		array, index := l.expr(e.Array), l.expr(e.Index)
+		if index != lintUnknown && index != lintInt {
+			l.errorf("array index must be an int, got %s", index)
+		}
+		switch array {
+		case lintDecimalArray:
+			return lintDecimal
+		case lintIntArray:
+			return lintInt
+		case lintUnknown:
+			return lintUnknown
+		}
+		l.errorf("cannot index %s", array)
+		return lintUnknown
+
+	case *UnaryExpr:
+		operand := l.expr(e.Operand)
+		switch {
+		case operand == lintUnknown:
+			return lintUnknown
+		case e.Op == "!" && operand == lintBool:
+			return lintBool
+		case e.Op == "-" && operand.numeric():
+			return operand
+		case e.Op != "!" && e.Op != "-":
+			l.errorf("unsupported operator %s", e.Op)
+		default:
+			l.errorf("operator %s on %s", e.Op, operand)
+		}
+		return lintUnknown
+
+	case *BinaryExpr:
+		return l.binary(e)
+
+	case *ArrayLit:
+		l.errorf("array literal outside a constant")
+		l.exprs(e.Elements)
+		return lintUnknown
+	}
+
+	l.errorf("unsupported expression %s", expr.String())
+	return lintUnknown
+}
+
+func (l *linter) exprs(exprs []Expr) []lintType {
+	types := make([]lintType, len(exprs))
+	for i, expr := range exprs {
+		types[i] = l.expr(expr)
+	}
+	return types
+}
+
+func (l *linter) binary(e *BinaryExpr) lintType {
+	left, right := l.expr(e.Left), l.expr(e.Right)
+
+	switch e.Op {
+	case "&&", "||":
+		for _, typ := range []lintType{left, right} {
+			if typ != lintUnknown && typ != lintBool {
+				l.errorf("operator %s requires boolean operands, got %s", e.Op, typ)
+			}
+		}
+		return lintBool
+
+	case "==", "!=", "<", "<=", ">", ">=":
+		if (e.Op == "==" || e.Op == "!=") && left == lintBool && right == lintBool {
+			return lintBool
+		}
+		l.numbers(e.Op, left, right)
+		return lintBool
+
+	case "+", "-", "*", "/", "%":
+		switch {
+		case !l.numbers(e.Op, left, right):
+			return lintUnknown
+		case left == lintInt && right == lintInt:
+			return lintInt
+		}
+		return lintDecimal
+	}
+
+	l.errorf("unsupported operator %s", e.Op)
+	return lintUnknown
+}
+
+// decimalArgs checks arguments the evaluator converts to BigDecimal
+func (l *linter) decimalArgs(method string, args []lintType) {
+	for _, arg := range args {
+		if arg != lintUnknown && !arg.numeric() {
+			l.errorf("%s: cannot use %s as BigDecimal", method, arg)
+		}
+	}
+}
+
+// intArgs checks scale and rounding mode arguments
+func (l *linter) intArgs(method string, args []lintType) {
+	for _, arg := range args {
+		if arg != lintUnknown && arg != lintInt {
+			l.errorf("%s: argument must be an int, got %s", method, arg)
+		}
+	}
+}
+
+// methodCall follows callMethod of the evaluator
+func (l *linter) methodCall(e *MethodCall) lintType {
+	receiver := l.expr(e.Receiver)
+	args := l.exprs(e.Args)
+
+	if receiver == lintClass {
+		if e.Method == "valueOf" && len(args) == 1 {
+			l.decimalArgs("valueOf", args)
+			return lintDecimal
+		}
+		l.errorf("unknown static method BigDecimal.%s/%d", e.Method, len(args))
+		return lintUnknown
+	}
+	if receiver != lintUnknown && !receiver.numeric() {
+		l.errorf("cannot call %s on %s", e.Method, receiver)
+	}
+
+	arity := func(min, max int) bool {
+		if len(args) < min || len(args) > max {
+			l.errorf("%s expects %d to %d arguments, got %d", e.Method, min, max, len(args))
+			return false
+		}
+		return true
+	}
+
+	switch e.Method {
+	case "add", "subtract", "multiply", "max", "min", "remainder", "compareTo":
+		if arity(1, 1) {
+			l.decimalArgs(e.Method, args)
+		}
+		if e.Method == "compareTo" {
+			return lintInt
+		}
+		return lintDecimal
+
+	case "divide":
+		if arity(1, 3) {
+			l.decimalArgs(e.Method, args[:1])
+			l.intArgs(e.Method, args[1:])
+		}
+		return lintDecimal
+
+	case "setScale":
+		if arity(1, 2) {
+			l.intArgs(e.Method, args)
+		}
+		return lintDecimal
+
+	case "negate", "abs":
+		arity(0, 0)
+		return lintDecimal
+
+	case "signum", "scale", "intValue", "longValue":
+		arity(0, 0)
+		return lintInt
+	}
+
+	l.errorf("unknown method %s/%d", e.Method, len(args))
+	return lintUnknown
+}
+
+// reachability warns about methods that MAIN never calls
+func (l *linter) reachability() {
+	if len(l.pap.Methods.Main) == 0 {
+		return
+	}
+
+	bodies := make(map[string][]Statement)
+	for _, method := range l.pap.Methods.Method {
+		if _, ok := bodies[method.Name]; !ok {
+			bodies[method.Name] = method.Statements
+		}
+	}
+
+	reached := make(map[string]bool)
+	var visit func(statements []Statement)
+	visit = func(statements []Statement) {
+		for _, statement := range statements {
+			switch s := statement.(type) {
+			case *ExecuteStatement:
+				if body, ok := bodies[s.Method]; ok && !reached[s.Method] {
+					reached[s.Method] = true
+					visit(body)
+				}
+			case *IfStatement:
+				visit(s.Then)
+				visit(s.Else)
+			}
+		}
+	}
+	visit(l.pap.Methods.Main[0].Statements)
+
+	for _, method := range l.pap.Methods.Method {
+		if !reached[method.Name] {
+			l.method = method.Name
+			l.warnf("method %s is never called", method.Name)
+			reached[method.Name] = true
+		}
+	}
+	l.method = ""
+}
@@ -0,0 +1,165 @@
+package bmf
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// lintPAP declares one variable of each kind around the given methods
+func lintPAP(t *testing.T, constants, methods string) *PAPData {
+	t.Helper()
+	src := `<PAP name="Lint">
+		<VARIABLES>
+			<INPUTS>
+				<INPUT name="STKL" type="int"/>
+				<INPUT name="RE4" type="BigDecimal"/>
+				<INPUT name="ZKF" type="double"/>
+			</INPUTS>
+			<OUTPUTS><OUTPUT name="LSTLZZ" type="BigDecimal"/></OUTPUTS>
+			<INTERNALS>
+				<INTERNAL name="KZTAB" type="int"/>
+				<INTERNAL name="ZVE" type="BigDecimal"/>
+				<INTERNAL name="FLAG" type="boolean"/>
+				<INTERNAL name="ZTAB" type="BigDecimal[]" default="{BigDecimal.ZERO, BigDecimal.ZERO}"/>
+			</INTERNALS>
+		</VARIABLES>
+		<CONSTANTS>
+			<CONSTANT name="TAB1" type="BigDecimal[]" value="{BigDecimal.valueOf(0.0), BigDecimal.valueOf(0.4)}"/>
+			<CONSTANT name="ZAHL100" type="BigDecimal" value="BigDecimal.valueOf(100)"/>
+			` + constants + `
+		</CONSTANTS>
+		<METHODS>` + methods + `</METHODS>
+	</PAP>`
+
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(src), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return &papData
+}
+
+func TestLintCleanPAP(t *testing.T) {
+	papData := lintPAP(t, "", `
+		<MAIN><EXECUTE method="MZVE"/></MAIN>
+		<METHOD name="MZVE">
+			<EVAL exec="KZTAB = 1"/>
+			<IF expr="STKL == 3 &amp;&amp; ZKF &gt; 0">
+				<THEN><EVAL exec="KZTAB = 2"/></THEN>
+			</IF>
+			<EVAL exec="ZVE = RE4.multiply(TAB1[KZTAB - 1]).divide(ZAHL100, 2, BigDecimal.ROUND_DOWN)"/>
+			<EVAL exec="ZTAB[KZTAB - 1] = ZVE"/>
+			<EVAL exec="FLAG = ZTAB[0].compareTo(BigDecimal.ZERO) == 1"/>
+			<EVAL exec="LSTLZZ = ZVE.setScale(0, BigDecimal.ROUND_DOWN)"/>
+		</METHOD>`)
+
+	if issues := Lint(papData); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+	if err := CheckPAP(papData); err != nil {
+		t.Errorf("Expected the check to pass, got: %v", err)
+	}
+}
+
+func TestLintEmbeddedPAPs(t *testing.T) {
+	t.Setenv(PAPDirEnv, "")
+	registry := NewPAPRegistry()
+
+	for _, version := range registry.versions {
+		papData, err := registry.LoadVersion(version)
+		if err != nil {
+			t.Fatalf("%s: %v", version.Version, err)
+		}
+		for _, issue := range Lint(papData) {
+			t.Errorf("%s: %s", version.Version, issue)
+		}
+	}
+}
+
+func TestLintIssues(t *testing.T) {
+	tests := []struct {
+		name      string
+		constants string
+		methods   string
+		severity  LintSeverity
+		expected  string
+	}{
+		{"no main", "", `<METHOD name="MA"/>`, LintError, "no main method"},
+		{"unknown method", "", `<MAIN><EXECUTE method="MISSING"/></MAIN>`, LintError, "MAIN: error: EXECUTE of unknown method MISSING"},
+		{"unreachable method", "", `<MAIN/><METHOD name="MOLD"/>`, LintWarning, "MOLD: warning: method MOLD is never called"},
+		{"method declared twice", "", `<MAIN><EXECUTE method="MA"/></MAIN><METHOD name="MA"/><METHOD name="MA"/>`, LintError, "method MA is declared twice"},
+		{"undefined variable read", "", `<MAIN><EVAL exec="ZVE = ZVE.add(WVFRB)"/></MAIN>`, LintError, `MAIN: error: undefined variable WVFRB in "ZVE = ZVE.add(WVFRB)"`},
+		{"undefined variable written", "", `<MAIN><EVAL exec="ZVEX = 1"/></MAIN>`, LintError, "undefined variable ZVEX"},
+		{"undefined legacy operand", "", `<MAIN><IF left="X" op="GT" right="0"><THEN/></IF></MAIN>`, LintError, "undefined variable X"},
+		{"assign to constant", "", `<MAIN><EVAL exec="ZAHL100 = ZVE"/></MAIN>`, LintError, "assignment to constant ZAHL100"},
+		{"decimal to int", "", `<MAIN><EVAL exec="KZTAB = ZVE.add(RE4)"/></MAIN>`, LintError, "cannot assign BigDecimal to int KZTAB"},
+		{"double literal to int", "", `<MAIN><EVAL exec="KZTAB = 0.5"/></MAIN>`, LintError, "cannot assign double to int KZTAB"},
+		{"number to boolean", "", `<MAIN><EVAL exec="FLAG = STKL"/></MAIN>`, LintError, "cannot assign int to boolean FLAG"},
+		{"legacy decimal to int", "", `<MAIN><EVAL target="KZTAB" left="ZVE" op="+" right="1"/></MAIN>`, LintError, "cannot assign BigDecimal to int KZTAB"},
+		{"condition not boolean", "", `<MAIN><IF expr="STKL + 1"><THEN/></IF></MAIN>`, LintError, "condition is int, not boolean"},
+		{"boolean arithmetic", "", `<MAIN><EVAL exec="KZTAB = FLAG + 1"/></MAIN>`, LintError, "operator + on boolean and int"},
+		{"and on numbers", "", `<MAIN><IF expr="STKL &amp;&amp; FLAG"><THEN/></IF></MAIN>`, LintError, "operator && requires boolean operands, got int"},
+		{"index not int", "", `<MAIN><EVAL exec="ZVE = TAB1[ZVE]"/></MAIN>`, LintError, "array index must be an int, got BigDecimal"},
+		{"index non-array", "", `<MAIN><EVAL exec="ZVE = RE4[0]"/></MAIN>`, LintError, "cannot index BigDecimal"},
+		{"method on boolean", "", `<MAIN><EVAL exec="ZVE = FLAG.add(RE4)"/></MAIN>`, LintError, "cannot call add on boolean"},
+		{"unknown method call", "", `<MAIN><EVAL exec="ZVE = RE4.pow(2)"/></MAIN>`, LintError, "unknown method pow/1"},
+		{"wrong arity", "", `<MAIN><EVAL exec="ZVE = RE4.add(RE4, RE4)"/></MAIN>`, LintError, "add expects 1 to 1 arguments, got 2"},
+		{"decimal scale", "", `<MAIN><EVAL exec="ZVE = RE4.setScale(ZVE, BigDecimal.ROUND_DOWN)"/></MAIN>`, LintError, "setScale: argument must be an int, got BigDecimal"},
+		{"unknown field", "", `<MAIN><EVAL exec="ZVE = BigDecimal.TWO"/></MAIN>`, LintError, "unknown field BigDecimal.TWO"},
+		{"unknown static method", "", `<MAIN><EVAL exec="ZVE = BigDecimal.of(1)"/></MAIN>`, LintError, "unknown static method BigDecimal.of/1"},
+		{"unknown class", "", `<MAIN><EVAL exec="ZVE = new Integer(1)"/></MAIN>`, LintError, "cannot instantiate Integer"},
+		{"unsupported target", "", `<MAIN><EVAL exec="ZTAB[0][1] = ZVE"/></MAIN>`, LintError, "unsupported assignment target ZTAB[0][1]"},
+		{"assign to constant element", "", `<MAIN><EVAL exec="TAB1[0] = ZVE"/></MAIN>`, LintError, "assignment to constant TAB1"},
+		{"element not int", "", `<MAIN><EVAL exec="ZTAB[ZVE] = ZVE"/></MAIN>`, LintError, "array index must be an int, got BigDecimal"},
+		{"element of non-array", "", `<MAIN><EVAL exec="ZVE[0] = ZVE"/></MAIN>`, LintError, "cannot index BigDecimal"},
+		{"element to int", "", `<MAIN><EVAL exec="KZTAB = ZTAB[1]"/></MAIN>`, LintError, "cannot assign BigDecimal to int KZTAB"},
+		{"legacy eval operator", "", `<MAIN><EVAL target="KZTAB" left="KZTAB" op="^" right="2"/></MAIN>`, LintError, `unsupported EVAL operator "^"`},
+		{"legacy comparison operator", "", `<MAIN><IF left="KZTAB" op="GREATER" right="2"><THEN/></IF></MAIN>`, LintError, `unsupported comparison operator "GREATER"`},
+		{"compare into int", "", `<MAIN><COMPARE target="KZTAB" left="STKL" op="EQ" right="1"/></MAIN>`, LintError, "cannot assign boolean to int KZTAB"},
+		{"bad constant", `<CONSTANT name="GFB" type="BigDecimal" value="BigDecimal.valueOf(l2096)"/>`, `<MAIN/>`, LintError, `value "BigDecimal.valueOf(l2096)" of constant GFB is not a valid BigDecimal`},
+		{"bad int constant", `<CONSTANT name="TAGE" type="int" value="3.5"/>`, `<MAIN/>`, LintError, "of constant TAGE is not a valid int"},
+		{"unsupported type", `<CONSTANT name="NAME" type="String" value="x"/>`, `<MAIN/>`, LintError, `constant NAME has unsupported type "String"`},
+		{"declared twice", `<CONSTANT name="ZVE" type="BigDecimal" value="0"/>`, `<MAIN/>`, LintWarning, "ZVE is declared twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Lint(lintPAP(t, tt.constants, tt.methods))
+
+			found := false
+			for _, issue := range issues {
+				if issue.Severity == tt.severity && strings.Contains(issue.String(), tt.expected) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected %s %q, got %v", tt.severity, tt.expected, issues)
+			}
+			if len(issues) != 1 {
+				t.Errorf("Expected exactly one issue, got %v", issues)
+			}
+		})
+	}
+}
+
+func TestCheckPAP(t *testing.T) {
+	papData := lintPAP(t, "", `
+		<MAIN><EXECUTE method="MA"/><EXECUTE method="MB"/></MAIN>
+		<METHOD name="MA"><EVAL exec="ZVE = ZVE.add(WVFRB)"/></METHOD>
+		<METHOD name="MOLD"/>`)
+
+	err := CheckPAP(papData)
+	var failure *LintFailure
+	if !errors.As(err, &failure) {
+		t.Fatalf("Expected a LintFailure, got: %v", err)
+	}
+
+	// Only the errors fail the check
+	if len(failure.Issues) != 2 {
+		t.Errorf("Expected 2 errors, got %v", failure.Issues)
+	}
+	if !strings.Contains(err.Error(), "Lint does not pass lint") || !strings.Contains(err.Error(), "unknown method MB") {
+		t.Errorf("Expected the PAP and its errors in the message, got: %v", err)
+	}
+}
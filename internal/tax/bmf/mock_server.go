@@ -0,0 +1,260 @@
+package bmf
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tax-calculator/internal/tax/models"
+)
+
+// MockFaults are failures a MockServer injects. The rates are the share of
+// requests, between 0 and 1, that get the fault.
+type MockFaults struct {
+	// Latency delays every answer
+	Latency time.Duration
+	// ErrorRate answers with ErrorStatus, 503 Service Unavailable if unset
+	ErrorRate   float64
+	ErrorStatus int
+	// MalformedRate cuts the XML of an answer off halfway
+	MalformedRate float64
+}
+
+// MockServer answers like the BMF interface below /interface/, e.g.
+// /interface/2025Version1.xhtml?code=extS2025&LZZ=1&RE4=5000000, calculating
+// with the local PAPs. Inputs that are not numbers are echoed with an error
+// status like the BMF rejects them.
+type MockServer struct {
+	registry *PAPRegistry
+	faults   MockFaults
+	log      io.Writer
+
+	mu       sync.Mutex
+	programs map[string]*Program
+}
+
+// MockOption configures a MockServer
+type MockOption func(*MockServer)
+
+// WithFaults sets the failures the server injects
+func WithFaults(faults MockFaults) MockOption {
+	return func(s *MockServer) {
+		s.faults = faults
+	}
+}
+
+// WithRequestLog writes a line for every request to w
+func WithRequestLog(w io.Writer) MockOption {
+	return func(s *MockServer) {
+		s.log = w
+	}
+}
+
+// WithMockRegistry calculates with the PAPs of another registry than
+// DefaultPAPRegistry
+func WithMockRegistry(registry *PAPRegistry) MockOption {
+	return func(s *MockServer) {
+		s.registry = registry
+	}
+}
+
+// NewMockServer returns a stand-in for the BMF interface
+func NewMockServer(opts ...MockOption) *MockServer {
+	s := &MockServer{
+		registry: DefaultPAPRegistry,
+		programs: make(map[string]*Program),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+var mockPath = regexp.MustCompile(`^/interface/((\d{4})Version\d+)\.xhtml$`)
+
+func (s *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	status, fault := s.serve(w, r)
+	if s.log != nil {
+		if fault != "" {
+			fault = " (" + fault + ")"
+		}
+		fmt.Fprintf(s.log, "%s %s %d %s%s\n", r.Method, r.URL.RequestURI(), status, time.Since(start).Round(time.Millisecond), fault)
+	}
+}
+
+// serve answers a request and returns the status and the fault it injected
+func (s *MockServer) serve(w http.ResponseWriter, r *http.Request) (int, string) {
+	if s.faults.Latency > 0 {
+		select {
+		case <-time.After(s.faults.Latency):
+		case <-r.Context().Done():
+			return 0, "cancelled"
+		}
+	}
+	if hit(s.faults.ErrorRate) {
+		status := s.faults.ErrorStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, http.StatusText(status), status)
+		return status, "injected error"
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return http.StatusMethodNotAllowed, ""
+	}
+	match := mockPath.FindStringSubmatch(r.URL.Path)
+	if match == nil {
+		http.NotFound(w, r)
+		return http.StatusNotFound, ""
+	}
+	year, _ := strconv.Atoi(match[2])
+	version, err := s.registry.Resolve(year, match[1])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return http.StatusNotFound, ""
+	}
+	query := r.URL.Query()
+	if code := query.Get("code"); code != version.APICode {
+		http.Error(w, fmt.Sprintf("invalid code %q", code), http.StatusForbidden)
+		return http.StatusForbidden, ""
+	}
+
+	response, err := s.calculate(r.Context(), version, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return http.StatusInternalServerError, ""
+	}
+	data, err := xml.MarshalIndent(response, "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return http.StatusInternalServerError, ""
+	}
+	data = append([]byte(xml.Header), data...)
+
+	fault := ""
+	if hit(s.faults.MalformedRate) {
+		data = data[:len(data)/2]
+		fault = "malformed XML"
+	}
+	w.Header().Set("Content-Type", "text/xml; charset=UTF-8")
+	w.Write(data)
+	return http.StatusOK, fault
+}
+
+// hit decides whether a request gets a fault injected at the given rate
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// calculate runs the PAP on the inputs of a query and answers like the BMF:
+// every input echoed with its status, and the outputs unless one is invalid
+func (s *MockServer) calculate(ctx context.Context, version PAPVersion, query url.Values) (*TaxCalculationResponse, error) {
+	program, err := s.program(version)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TaxCalculationResponse{Year: strconv.Itoa(version.Year)}
+	inputs, echoed, valid := queryInputs(program.Data(), query)
+	response.Inputs.Input = echoed
+	if !valid {
+		response.Information = "Invalid input"
+		return response, nil
+	}
+
+	outputs, err := program.CalculateContext(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	response.Information = "Calculated by steuergo mock-bmf"
+	for _, output := range program.Data().Variables.Outputs.Output {
+		value, ok := outputs[output.Name]
+		if !ok {
+			continue
+		}
+		response.Outputs.Output = append(response.Outputs.Output, Output{
+			Name:  output.Name,
+			Value: FormatOutputValue(value),
+			Type:  outputType(output.Name),
+		})
+	}
+	return response, nil
+}
+
+// queryInputs reads the inputs of a PAP from the query of a request to the
+// BMF interface, and echoes each with its status as the BMF does
+func queryInputs(papData *PAPData, query url.Values) (inputs map[string]interface{}, echoed []Input, valid bool) {
+	inputs = make(map[string]interface{})
+	valid = true
+	for _, input := range papData.Variables.Inputs.Input {
+		values, ok := query[input.Name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		value := strings.TrimSpace(values[0])
+		status := "ok"
+		if parsed, err := parseInput(input.Type, value); err != nil {
+			status = fmt.Sprintf("not a valid %s", input.Type)
+			valid = false
+		} else {
+			inputs[input.Name] = parsed
+		}
+		echoed = append(echoed, Input{Name: input.Name, Value: value, Status: status})
+	}
+	return inputs, echoed, valid
+}
+
+// parseInput reads the value of an input of the given PAP type strictly,
+// where the PAP itself falls back to zero
+func parseInput(inputType, value string) (interface{}, error) {
+	if inputType == "int" {
+		return strconv.Atoi(value)
+	}
+	if _, err := ParseDecimal(value); err != nil {
+		return nil, err
+	}
+	return parseValue(inputType, value), nil
+}
+
+// outputType is the group the BMF lists an output in
+func outputType(name string) string {
+	if field, ok := models.LookupOutputField(name); ok && (field.Group == models.TreatyUsed || field.Group == models.TreatyIncome) {
+		return "DBA"
+	}
+	return "STANDARD"
+}
+
+// program returns the compiled PAP of a version, compiling it on first use
+func (s *MockServer) program(version PAPVersion) (*Program, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if program, ok := s.programs[version.Version]; ok {
+		return program, nil
+	}
+	papData, err := s.registry.LoadVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckPAP(papData); err != nil {
+		return nil, err
+	}
+	program, err := Compile(papData)
+	if err != nil {
+		return nil, err
+	}
+	s.programs[version.Version] = program
+	return program, nil
+}
@@ -0,0 +1,158 @@
+package bmf
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tax-calculator/internal/tax/models"
+)
+
+func newMockClient(t *testing.T, opts ...MockOption) (*Client, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(NewMockServer(opts...))
+	t.Cleanup(server.Close)
+	return NewClient(WithBaseURL(server.URL+"/interface"), WithHTTPClient(server.Client()), WithRetryPolicy(NoRetry)), server
+}
+
+func TestMockServerCalculates(t *testing.T) {
+	var log bytes.Buffer
+	client, _ := newMockClient(t, WithRequestLog(&log))
+
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass3, KVZ: 2.5, Year: 2025}
+	response, err := client.CalculateTax(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	version, _ := ResolvePAPVersion(req)
+	papData, err := DefaultPAPRegistry.LoadVersion(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := make(map[string]interface{})
+	for _, input := range RequestInputs(req) {
+		inputs[input.Name] = input.Value
+	}
+	expected, err := NewProgram(papData).Calculate(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Year != "2025" {
+		t.Errorf("Expected year 2025, got %q", response.Year)
+	}
+	if len(response.Outputs.Output) != len(papData.Variables.Outputs.Output) {
+		t.Errorf("Expected every output of the PAP, got %d", len(response.Outputs.Output))
+	}
+	for _, output := range response.Outputs.Output {
+		if want := FormatOutputValue(expected[output.Name]); output.Value != want {
+			t.Errorf("Expected %s = %s, got %s", output.Name, want, output.Value)
+		}
+	}
+	for _, input := range response.Inputs.Input {
+		if input.Status != "ok" {
+			t.Errorf("Expected status ok for %s, got %q", input.Name, input.Status)
+		}
+	}
+	if !strings.HasPrefix(log.String(), "GET /interface/2025Version1.xhtml?") || !strings.Contains(log.String(), " 200 ") {
+		t.Errorf("Expected the request in the log, got %q", log.String())
+	}
+}
+
+func TestMockServerRejectsInvalidInput(t *testing.T) {
+	_, server := newMockClient(t)
+
+	resp, err := server.Client().Get(server.URL + "/interface/2025Version1.xhtml?code=extS2025&LZZ=1&RE4=5000000&STKL=1&KVZ=1,3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+
+	var response TaxCalculationResponse
+	if err := xml.Unmarshal(data, &response); err != nil {
+		t.Fatalf("Expected XML, got: %v", err)
+	}
+	errs := response.ValidationErrors()
+	if err, ok := errs.Field("KVZ"); !ok || err.Value != "1,3" {
+		t.Errorf("Expected KVZ to be rejected, got %v", errs)
+	}
+	if _, ok := errs.Field("RE4"); ok {
+		t.Errorf("Expected RE4 to pass, got %v", errs)
+	}
+	if len(response.Outputs.Output) != 0 {
+		t.Errorf("Expected no outputs for a rejected request, got %d", len(response.Outputs.Output))
+	}
+}
+
+func TestMockServerErrors(t *testing.T) {
+	_, server := newMockClient(t)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/interface/2025Version1.xhtml?code=extS2024", http.StatusForbidden},
+		{"/interface/2025Version1.xhtml", http.StatusForbidden},
+		{"/interface/2025Version9.xhtml?code=extS2025", http.StatusNotFound},
+		{"/interface/1999Version1.xhtml?code=extS1999", http.StatusNotFound},
+		{"/other", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp, err := server.Client().Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, resp.StatusCode)
+		}
+	}
+}
+
+func TestMockServerFaults(t *testing.T) {
+	t.Run("HTTP error", func(t *testing.T) {
+		client, _ := newMockClient(t, WithFaults(MockFaults{ErrorRate: 1, ErrorStatus: http.StatusBadGateway}))
+		_, err := client.CalculateTax(context.Background(), clientRequest)
+		if err == nil || !strings.Contains(err.Error(), "502") {
+			t.Errorf("Expected a 502 error, got: %v", err)
+		}
+	})
+
+	t.Run("malformed XML", func(t *testing.T) {
+		client, _ := newMockClient(t, WithFaults(MockFaults{MalformedRate: 1}))
+		_, err := client.CalculateTax(context.Background(), clientRequest)
+		if err == nil || !strings.Contains(err.Error(), "failed to decode XML response") {
+			t.Errorf("Expected a decoding error, got: %v", err)
+		}
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		server := httptest.NewServer(NewMockServer(WithFaults(MockFaults{Latency: time.Second})))
+		defer server.Close()
+		client := NewClient(WithBaseURL(server.URL+"/interface"), WithTimeout(20*time.Millisecond), WithRetryPolicy(NoRetry))
+		if _, err := client.CalculateTax(context.Background(), clientRequest); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected a deadline error, got: %v", err)
+		}
+	})
+}
+
+func TestDefaultAPIBaseURL(t *testing.T) {
+	t.Setenv(APIBaseURLEnv, "")
+	if url := DefaultAPIBaseURL(); url != APIBaseURL {
+		t.Errorf("Expected %s, got %s", APIBaseURL, url)
+	}
+
+	t.Setenv(APIBaseURLEnv, "http://localhost:8080/interface/")
+	if url := NewClient().Endpoint(PAPVersion{Version: "2025Version1"}); url != "http://localhost:8080/interface/2025Version1.xhtml" {
+		t.Errorf("Expected the endpoint below %s, got %s", APIBaseURLEnv, url)
+	}
+}
@@ -0,0 +1,260 @@
+package bmf
+
+import (
+	"embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"tax-calculator/internal/tax/models"
+)
+
+// The PAP files published by the BMF, shipped inside the binary so the local
+// calculator works without network access
+//
+//go:embed paps/*.xml
+var embeddedPAPs embed.FS
+
+const (
+	// DefaultYear is the tax year used when a request does not name one
+	DefaultYear = 2025
+
+	// PAPDirEnv names a directory with PAP files, named like the embedded
+	// ones (e.g. Lohnsteuer2025.xml), that take precedence over them
+	PAPDirEnv = "STEUERGO_PAP_DIR"
+)
+
+var ErrUnsupportedYear = errors.New("unsupported tax year")
+
+// PAPVersion describes one published Programmablaufplan. Version is the
+// name the BMF uses for it, which is also the path of its API endpoint.
+type PAPVersion struct {
+	Year    int
+	Version string
+	APICode string
+	File    string
+}
+
+// papVersions lists the PAPs per year in publication order. A later version
+// of a year, such as the December 2024 PAP, replaces the earlier one.
+var papVersions = []PAPVersion{
+	{Year: 2024, Version: "2024Version1", APICode: "extS2024", File: "paps/Lohnsteuer2024.xml"},
+	{Year: 2024, Version: "2024Version2", APICode: "extS2024", File: "paps/Lohnsteuer2024Version2.xml"},
+	{Year: 2025, Version: "2025Version1", APICode: "extS2025", File: "paps/Lohnsteuer2025.xml"},
+}
+
+// PAPRegistry returns the PAP for a tax year. A local file registered with
+// SetOverride wins, then a file in the PAPDirEnv directory, then the
+// embedded copy.
+type PAPRegistry struct {
+	mu        sync.RWMutex
+	versions  []PAPVersion
+	overrides map[string]string
+	loaded    map[string]*PAPData
+
+	// generations counts the SetOverride calls per version, so a PAP parsed
+	// before one is not stored after it
+	generations map[string]int
+	// parsed, if set, is called between parsing and storing a PAP, for tests
+	parsed func(version PAPVersion)
+}
+
+var DefaultPAPRegistry = NewPAPRegistry()
+
+func NewPAPRegistry() *PAPRegistry {
+	return &PAPRegistry{
+		versions:  papVersions,
+		overrides: make(map[string]string),
+		loaded:    make(map[string]*PAPData),
+
+		generations: make(map[string]int),
+	}
+}
+
+// Years lists the supported tax years in ascending order
+func (r *PAPRegistry) Years() []int {
+	var years []int
+	for _, version := range r.versions {
+		if len(years) == 0 || years[len(years)-1] != version.Year {
+			years = append(years, version.Year)
+		}
+	}
+	sort.Ints(years)
+	return years
+}
+
+// Versions lists all PAP versions published for a tax year, oldest first
+func (r *PAPRegistry) Versions(year int) []PAPVersion {
+	var versions []PAPVersion
+	for _, version := range r.versions {
+		if version.Year == year {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// Version returns the current PAP version for a tax year
+func (r *PAPRegistry) Version(year int) (PAPVersion, error) {
+	versions := r.Versions(year)
+	if len(versions) == 0 {
+		return PAPVersion{}, fmt.Errorf("%w %d (supported: %s)", ErrUnsupportedYear, year, joinYears(r.Years()))
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Resolve picks the PAP version for a year, or a specific version of it such
+// as "2024Version1". Year 0 stands for DefaultYear.
+func (r *PAPRegistry) Resolve(year int, name string) (PAPVersion, error) {
+	if year == 0 {
+		year = DefaultYear
+	}
+	if name == "" {
+		return r.Version(year)
+	}
+
+	versions := r.Versions(year)
+	if len(versions) == 0 {
+		return PAPVersion{}, fmt.Errorf("%w %d (supported: %s)", ErrUnsupportedYear, year, joinYears(r.Years()))
+	}
+
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		if version.Version == name {
+			return version, nil
+		}
+		names[i] = version.Version
+	}
+	return PAPVersion{}, fmt.Errorf("unknown PAP version %q for %d (available: %s)", name, year, strings.Join(names, ", "))
+}
+
+// SetOverride makes Load read a PAP version, e.g. "2025Version1", from a
+// local file. An empty path removes the override.
+func (r *PAPRegistry) SetOverride(version string, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if path == "" {
+		delete(r.overrides, version)
+	} else {
+		r.overrides[version] = path
+	}
+	delete(r.loaded, version)
+	r.generations[version]++
+}
+
+// Load returns the parsed current PAP for a tax year
+func (r *PAPRegistry) Load(year int) (*PAPData, error) {
+	version, err := r.Version(year)
+	if err != nil {
+		return nil, err
+	}
+	return r.LoadVersion(version)
+}
+
+// LoadVersion returns the parsed PAP of one version. The parse runs without
+// the lock; if SetOverride changed the version meanwhile, it is dropped and
+// the PAP loaded again.
+func (r *PAPRegistry) LoadVersion(version PAPVersion) (*PAPData, error) {
+	r.mu.RLock()
+	papData, ok := r.loaded[version.Version]
+	override := r.overrides[version.Version]
+	generation := r.generations[version.Version]
+	r.mu.RUnlock()
+	if ok {
+		return papData, nil
+	}
+
+	var err error
+
+	if override == "" {
+		if dir := os.Getenv(PAPDirEnv); dir != "" {
+			path := filepath.Join(dir, filepath.Base(version.File))
+			if _, err := os.Stat(path); err == nil {
+				override = path
+			}
+		}
+	}
+
+	if override != "" {
+		papData, err = LoadPAPFile(override)
+	} else {
+		papData, err = loadEmbeddedPAP(version.File)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.parsed != nil {
+		r.parsed(version)
+	}
+
+	r.mu.Lock()
+	if r.generations[version.Version] != generation {
+		r.mu.Unlock()
+		return r.LoadVersion(version)
+	}
+	r.loaded[version.Version] = papData
+	r.mu.Unlock()
+
+	return papData, nil
+}
+
+// LoadPAP returns the PAP for a tax year from the default registry
+func LoadPAP(year int) (*PAPData, error) {
+	return DefaultPAPRegistry.Load(year)
+}
+
+// ResolvePAPVersion picks the PAP version for a request from the default registry
+func ResolvePAPVersion(req models.TaxRequest) (PAPVersion, error) {
+	return DefaultPAPRegistry.Resolve(req.Year, req.PAPVersion)
+}
+
+// LoadPAPFile reads a PAP XML file from disk
+func LoadPAPFile(path string) (*PAPData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PAP file: %w", err)
+	}
+
+	papData, err := ParsePAP(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return papData, nil
+}
+
+// ParsePAP decodes a PAP XML document
+func ParsePAP(data []byte) (*PAPData, error) {
+	var papData PAPData
+	if err := xml.Unmarshal(data, &papData); err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+	return &papData, nil
+}
+
+func loadEmbeddedPAP(file string) (*PAPData, error) {
+	data, err := embeddedPAPs.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded PAP: %w", err)
+	}
+
+	papData, err := ParsePAP(data)
+	if err != nil {
+		return nil, fmt.Errorf("embedded %s: %w", file, err)
+	}
+	return papData, nil
+}
+
+func joinYears(years []int) string {
+	parts := make([]string, len(years))
+	for i, year := range years {
+		parts[i] = strconv.Itoa(year)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,243 @@
+package bmf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPAPRegistryYears(t *testing.T) {
+	registry := NewPAPRegistry()
+
+	years := registry.Years()
+	if len(years) < 2 {
+		t.Fatalf("Expected at least 2 supported years, got %v", years)
+	}
+
+	for i := 1; i < len(years); i++ {
+		if years[i-1] >= years[i] {
+			t.Errorf("Expected ascending years, got %v", years)
+		}
+	}
+
+	if _, err := registry.Version(DefaultYear); err != nil {
+		t.Errorf("Expected the default year to be supported, got: %v", err)
+	}
+}
+
+func TestPAPRegistryUnsupportedYear(t *testing.T) {
+	registry := NewPAPRegistry()
+
+	_, err := registry.Load(1999)
+	if !errors.Is(err, ErrUnsupportedYear) {
+		t.Fatalf("Expected ErrUnsupportedYear, got: %v", err)
+	}
+}
+
+func TestPAPRegistryVersions(t *testing.T) {
+	registry := NewPAPRegistry()
+
+	versions := registry.Versions(2024)
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 PAP versions for 2024, got %v", versions)
+	}
+
+	current, err := registry.Version(2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Version != "2024Version2" {
+		t.Errorf("Expected the later 2024 PAP to be current, got %s", current.Version)
+	}
+
+	tests := []struct {
+		year     int
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{0, "", "2025Version1", false},
+		{2024, "", "2024Version2", false},
+		{2024, "2024Version1", "2024Version1", false},
+		{2025, "2024Version1", "", true},
+		{2030, "", "", true},
+	}
+
+	for _, tc := range tests {
+		version, err := registry.Resolve(tc.year, tc.name)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Resolve(%d, %q): expected error, got %s", tc.year, tc.name, version.Version)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Resolve(%d, %q): expected no error, got: %v", tc.year, tc.name, err)
+		} else if version.Version != tc.expected {
+			t.Errorf("Resolve(%d, %q): expected %s, got %s", tc.year, tc.name, tc.expected, version.Version)
+		}
+	}
+}
+
+func TestClientEndpoint(t *testing.T) {
+	t.Setenv(APIBaseURLEnv, "")
+	version, err := NewPAPRegistry().Version(2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url := NewClient().Endpoint(version); url != APIBaseURL+"/2025Version1.xhtml" {
+		t.Errorf("Expected the 2025Version1 endpoint, got %s", url)
+	}
+	if url := NewClient(WithBaseURL("http://localhost:8080/interface")).Endpoint(version); url != "http://localhost:8080/interface/2025Version1.xhtml" {
+		t.Errorf("Expected the endpoint of the configured interface, got %s", url)
+	}
+	if version.APICode != "extS2025" {
+		t.Errorf("Expected code extS2025, got %s", version.APICode)
+	}
+}
+
+func TestPAPRegistryLoadEmbedded(t *testing.T) {
+	t.Setenv(PAPDirEnv, "")
+	registry := NewPAPRegistry()
+
+	for _, version := range registry.versions {
+		papData, err := registry.LoadVersion(version)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got: %v", version.Version, err)
+		}
+
+		if len(papData.Methods.Main) != 1 || len(papData.Methods.Method) == 0 {
+			t.Errorf("%s: expected MAIN and methods to be decoded", version.Version)
+		}
+
+		again, err := registry.LoadVersion(version)
+		if err != nil || again != papData {
+			t.Errorf("%s: expected the parsed PAP to be reused", version.Version)
+		}
+	}
+}
+
+func TestPAPRegistryCalculate(t *testing.T) {
+	t.Setenv(PAPDirEnv, "")
+	registry := NewPAPRegistry()
+
+	for _, year := range registry.Years() {
+		papData, err := registry.Load(year)
+		if err != nil {
+			t.Fatalf("%d: expected no error, got: %v", year, err)
+		}
+
+		calculator := NewTaxCalculator(papData)
+		calculator.SetInputValue("LZZ", 1)
+		calculator.SetInputValue("STKL", 1)
+		calculator.SetInputValue("KVZ", 2.5)
+
+		calculator.SetInputValue("RE4", 1000000)
+		if err := calculator.Calculate(); err != nil {
+			t.Fatalf("%d: expected no error, got: %v", year, err)
+		}
+		if lst := calculator.GetOutputValue("LSTLZZ").(Decimal); lst.Sign() != 0 {
+			t.Errorf("%d: expected no income tax below the basic allowance, got %s", year, lst)
+		}
+
+		calculator.SetInputValue("RE4", 5000000)
+		if err := calculator.Calculate(); err != nil {
+			t.Fatalf("%d: expected no error, got: %v", year, err)
+		}
+		lst := calculator.GetOutputValue("LSTLZZ").(Decimal)
+		if lst.Cmp(DecimalFromInt(500000)) < 0 || lst.Cmp(DecimalFromInt(900000)) > 0 {
+			t.Errorf("%d: expected between 5000 and 9000 euros income tax on 50000 euros, got %s cents", year, lst)
+		}
+	}
+}
+
+func TestPAPRegistryOverride(t *testing.T) {
+	t.Setenv(PAPDirEnv, "")
+	registry := NewPAPRegistry()
+	version, err := registry.Version(DefaultYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "custom.xml")
+	if err := os.WriteFile(path, []byte(nestedPAP), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry.SetOverride(version.Version, path)
+	papData, err := registry.Load(DefaultYear)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if papData.Name != "Nested" {
+		t.Errorf("Expected the override file to be loaded, got %q", papData.Name)
+	}
+
+	registry.SetOverride(version.Version, "")
+	papData, err = registry.Load(DefaultYear)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if papData.Name == "Nested" {
+		t.Error("Expected the embedded PAP after removing the override")
+	}
+
+	registry.SetOverride(version.Version, filepath.Join(t.TempDir(), "missing.xml"))
+	if _, err := registry.Load(DefaultYear); err == nil {
+		t.Error("Expected error for a missing override file")
+	}
+}
+
+func TestPAPRegistryOverrideDuringLoad(t *testing.T) {
+	t.Setenv(PAPDirEnv, "")
+	registry := NewPAPRegistry()
+	version, err := registry.Version(DefaultYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "custom.xml")
+	if err := os.WriteFile(path, []byte(nestedPAP), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The override arrives while the embedded PAP is being parsed
+	registry.parsed = func(PAPVersion) {
+		registry.parsed = nil
+		registry.SetOverride(version.Version, path)
+	}
+	papData, err := registry.LoadVersion(version)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if papData.Name != "Nested" {
+		t.Errorf("Expected the override, got %q", papData.Name)
+	}
+	if papData, _ := registry.LoadVersion(version); papData.Name != "Nested" {
+		t.Errorf("Expected the override to stay loaded, got %q", papData.Name)
+	}
+}
+
+func TestPAPRegistryOverrideDirectory(t *testing.T) {
+	registry := NewPAPRegistry()
+	version, err := registry.Version(DefaultYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, filepath.Base(version.File)), []byte(nestedPAP), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PAPDirEnv, dir)
+
+	papData, err := registry.Load(DefaultYear)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if papData.Name != "Nested" {
+		t.Errorf("Expected the PAP from %s, got %q", PAPDirEnv, papData.Name)
+	}
+}
@@ -0,0 +1,151 @@
+package bmf
+
+import (
+	"context"
+	"maps"
+)
+
+// Program is a parsed PAP ready to run. It is never modified after
+// NewProgram, so one Program can be shared by any number of goroutines. The
+// values of a calculation live in the TaxCalculator returned by NewCalculator,
+// which belongs to a single goroutine.
+type Program struct {
+	data      *PAPData
+	constants map[string]interface{}
+	methods   map[string]*PAPMethod
+	kinds     map[string]variableKind
+	limits    Limits
+
+	// Set by Compile
+	code *code
+}
+
+// ProgramOption configures a Program
+type ProgramOption func(*Program)
+
+// WithLimits bounds every calculation of the program instead of DefaultLimits
+func WithLimits(limits Limits) ProgramOption {
+	return func(p *Program) {
+		p.limits = limits
+	}
+}
+
+// variableKind tells which map of a TaxCalculator holds a variable
+type variableKind int
+
+const (
+	internalVariable variableKind = iota
+	inputVariable
+	outputVariable
+)
+
+func NewProgram(papData *PAPData, opts ...ProgramOption) *Program {
+	program := &Program{
+		data:      papData,
+		constants: make(map[string]interface{}),
+		methods:   make(map[string]*PAPMethod),
+		kinds:     make(map[string]variableKind),
+		limits:    DefaultLimits,
+	}
+	for _, opt := range opts {
+		opt(program)
+	}
+
+	for _, constant := range papData.Constants.Constant {
+		program.constants[constant.Name] = parseValue(constant.Type, constant.Value)
+	}
+
+	for i := range papData.Methods.Method {
+		method := &papData.Methods.Method[i]
+		if _, ok := program.methods[method.Name]; !ok {
+			program.methods[method.Name] = method
+		}
+	}
+
+	// Outputs first, so a variable declared as both stays an input like in
+	// setVariableValue
+	for _, output := range papData.Variables.Outputs.Output {
+		program.kinds[output.Name] = outputVariable
+	}
+	for _, input := range papData.Variables.Inputs.Input {
+		program.kinds[input.Name] = inputVariable
+	}
+
+	return program
+}
+
+// Data returns the PAP the program was built from
+func (p *Program) Data() *PAPData {
+	return p.data
+}
+
+// NewCalculator returns a fresh evaluation state with the declared defaults
+func (p *Program) NewCalculator() *TaxCalculator {
+	calculator := &TaxCalculator{
+		XMLData:      p.data,
+		InputValues:  make(map[string]interface{}),
+		OutputValues: make(map[string]interface{}),
+		InternalVars: make(map[string]interface{}),
+		Constants:    maps.Clone(p.constants),
+		Limits:       p.limits,
+		program:      p,
+	}
+
+	for _, input := range p.data.Variables.Inputs.Input {
+		if input.Default != "" {
+			calculator.InputValues[input.Name] = parseValue(input.Type, input.Default)
+		}
+	}
+
+	calculator.resetVariables()
+	return calculator
+}
+
+// Compiled reports whether Calculate runs compiled code
+func (p *Program) Compiled() bool {
+	return p.code != nil
+}
+
+// Limits returns the limits every calculation of the program runs with
+func (p *Program) Limits() Limits {
+	return p.limits
+}
+
+// Calculate runs the program on a set of inputs and returns the outputs.
+// Programs from Compile run their compiled code, others the interpreter.
+func (p *Program) Calculate(inputs map[string]interface{}) (map[string]interface{}, error) {
+	return p.CalculateContext(context.Background(), inputs)
+}
+
+// CalculateContext is Calculate stopping with a *LimitError when the context
+// is done
+func (p *Program) CalculateContext(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if p.code != nil {
+		return p.code.run(ctx, p.limits, inputs)
+	}
+
+	calculator := p.NewCalculator()
+	for name, value := range inputs {
+		calculator.SetInputValue(name, value)
+	}
+
+	if err := calculator.CalculateContext(ctx); err != nil {
+		return nil, err
+	}
+	return calculator.OutputValues, nil
+}
+
+// Trace runs the program like Calculate and tells the tracer about every
+// step. Compiled code cannot be traced, so this always interprets the PAP.
+func (p *Program) Trace(inputs map[string]interface{}, tracer Tracer) (map[string]interface{}, error) {
+	calculator := p.NewCalculator()
+	calculator.Tracer = tracer
+	for name, value := range inputs {
+		calculator.SetInputValue(name, value)
+	}
+
+	if err := calculator.Calculate(); err != nil {
+		return nil, err
+	}
+	return calculator.OutputValues, nil
+}
@@ -0,0 +1,73 @@
+package bmf
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestProgramNewCalculator(t *testing.T) {
+	papData, err := LoadPAP(DefaultYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := NewProgram(papData)
+
+	first := program.NewCalculator()
+	second := program.NewCalculator()
+
+	first.SetInputValue("STKL", 1)
+	first.SetInputValue("RE4", 5000000)
+	if err := first.Calculate(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if _, ok := second.InputValues["RE4"]; ok {
+		t.Error("Expected calculators of one program not to share inputs")
+	}
+	if lst := second.GetOutputValue("LSTLZZ").(Decimal); lst.Sign() != 0 {
+		t.Errorf("Expected a fresh calculator to have no income tax yet, got %s", lst)
+	}
+
+	first.Constants["ZAHL100"] = DecimalZero
+	if program.NewCalculator().Constants["ZAHL100"] == DecimalZero {
+		t.Error("Expected changes to a calculator's constants to stay with it")
+	}
+}
+
+func TestProgramCalculateConcurrently(t *testing.T) {
+	papData, err := LoadPAP(DefaultYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := NewProgram(papData)
+
+	incomes := []int{1000000, 2500000, 5000000, 8000000, 15000000, 30000000}
+	expected := make([]Decimal, len(incomes))
+	for i, income := range incomes {
+		outputs, err := program.Calculate(map[string]interface{}{"STKL": 1, "LZZ": 1, "RE4": income})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected[i] = outputs["LSTLZZ"].(Decimal)
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for n := 0; n < 3*len(incomes); n++ {
+				i := (n + worker) % len(incomes)
+				outputs, err := program.Calculate(map[string]interface{}{"STKL": 1, "LZZ": 1, "RE4": incomes[i]})
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+					return
+				}
+				if lst := outputs["LSTLZZ"].(Decimal); lst.Cmp(expected[i]) != 0 {
+					t.Errorf("Expected %s income tax on %d, got %s", expected[i], incomes[i], lst)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
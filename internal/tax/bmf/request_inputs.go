@@ -0,0 +1,80 @@
+package bmf
+
+import (
+	"strconv"
+
+	"tax-calculator/internal/tax/models"
+)
+
+// RequestInput is one PAP input taken from a TaxRequest, already in the unit
+// the PAP and the BMF interface expect: whole numbers for codes and cents,
+// a fixed number of decimal places for rates and factors
+type RequestInput struct {
+	Name     string
+	Value    interface{}
+	Decimals int
+}
+
+// String formats the value the way the BMF interface reads it
+func (in RequestInput) String() string {
+	switch v := in.Value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', in.Decimals, 64)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return ""
+	}
+}
+
+// RequestInputs maps every field of a request to its PAP input
+func RequestInputs(req models.TaxRequest) []RequestInput {
+	zmvb := req.ZMVB
+	if zmvb == 0 {
+		zmvb = 12
+	}
+	f := req.F
+	if f == 0 {
+		f = 1.0
+	}
+
+	return []RequestInput{
+		{Name: "LZZ", Value: int(req.Period)},
+		{Name: "RE4", Value: req.Income},
+		{Name: "STKL", Value: int(req.TaxClass)},
+
+		{Name: "af", Value: req.AF},
+		{Name: "AJAHR", Value: req.AJAHR},
+		{Name: "ALTER1", Value: req.ALTER1},
+		{Name: "ENTSCH", Value: req.ENTSCH},
+		{Name: "f", Value: f, Decimals: 3},
+		{Name: "JFREIB", Value: req.JFREIB},
+		{Name: "JHINZU", Value: req.JHINZU},
+		{Name: "JRE4", Value: req.JRE4},
+		{Name: "JRE4ENT", Value: req.JRE4ENT},
+		{Name: "JVBEZ", Value: req.JVBEZ},
+		{Name: "KRV", Value: req.KRV},
+		{Name: "KVZ", Value: req.KVZ, Decimals: 2},
+		{Name: "LZZFREIB", Value: req.LZZFREIB},
+		{Name: "LZZHINZU", Value: req.LZZHINZU},
+		{Name: "MBV", Value: req.MBV},
+		{Name: "PKPV", Value: req.PKPV * 100},
+		{Name: "PKV", Value: req.PKV},
+		{Name: "PVA", Value: req.PVA},
+		{Name: "PVS", Value: req.PVS},
+		{Name: "PVZ", Value: req.PVZ},
+		{Name: "R", Value: req.R},
+		{Name: "SONSTB", Value: req.SONSTB},
+		{Name: "SONSTENT", Value: req.SONSTENT},
+		{Name: "STERBE", Value: req.STERBE},
+		{Name: "VBEZ", Value: req.VBEZ * 100},
+		{Name: "VBEZM", Value: req.VBEZM},
+		{Name: "VBEZS", Value: req.VBEZS},
+		{Name: "VBS", Value: req.VBS},
+		{Name: "VJAHR", Value: req.VJAHR},
+		{Name: "VKAPA", Value: req.VKAPA},
+		{Name: "VMT", Value: req.VMT},
+		{Name: "ZKF", Value: req.ZKF, Decimals: 1},
+		{Name: "ZMVB", Value: zmvb},
+	}
+}
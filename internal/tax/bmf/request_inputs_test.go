@@ -0,0 +1,62 @@
+package bmf
+
+import (
+	"testing"
+
+	"tax-calculator/internal/tax/models"
+)
+
+func TestRequestInputs(t *testing.T) {
+	req := models.TaxRequest{
+		Period:   models.Month,
+		Income:   420000,
+		TaxClass: models.TaxClass4,
+		KVZ:      1.7,
+		ZKF:      1.5,
+		VBEZ:     1200,
+		PKPV:     350,
+		F:        0.9125,
+		SONSTB:   250000,
+	}
+
+	inputs := make(map[string]string)
+	for _, input := range RequestInputs(req) {
+		if _, ok := inputs[input.Name]; ok {
+			t.Errorf("Duplicate input %s", input.Name)
+		}
+		inputs[input.Name] = input.String()
+	}
+
+	expected := map[string]string{
+		"LZZ":    "2",
+		"RE4":    "420000",
+		"STKL":   "4",
+		"KVZ":    "1.70",
+		"ZKF":    "1.5",
+		"VBEZ":   "120000",
+		"PKPV":   "35000",
+		"f":      "0.912",
+		"SONSTB": "250000",
+		"ZMVB":   "12",
+		"af":     "0",
+	}
+	for name, want := range expected {
+		if got := inputs[name]; got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+
+	// Every input of every embedded PAP comes from the request
+	registry := NewPAPRegistry()
+	for _, version := range registry.versions {
+		papData, err := registry.LoadVersion(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, input := range papData.Variables.Inputs.Input {
+			if _, ok := inputs[input.Name]; !ok {
+				t.Errorf("%s: input %s is not taken from the request", version.Version, input.Name)
+			}
+		}
+	}
+}
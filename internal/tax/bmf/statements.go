@@ -0,0 +1,220 @@
+package bmf
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Statement is one executable step of a PAP method. Statements form a tree:
+// an IF holds the statements of its THEN and ELSE blocks, which may contain
+// further IFs at any depth.
+type Statement interface {
+	Kind() OperationType
+}
+
+// ExecuteStatement calls another method of the PAP
+type ExecuteStatement struct {
+	Method string
+}
+
+// EvalStatement assigns the result of an expression. Real PAP files use the
+// exec attribute; the target/left/op/right form is kept for simple definitions.
+type EvalStatement struct {
+	Exec       string
+	Assignment *Assignment
+
+	Target string
+	Left   string
+	Right  string
+	Op     EvalOperator
+}
+
+// IfStatement branches on a condition into its THEN or ELSE block
+type IfStatement struct {
+	Expr      string
+	Condition Expr
+
+	Left  string
+	Right string
+	Op    ComparisonOperator
+
+	Then []Statement
+	Else []Statement
+}
+
+// CompareStatement stores the result of a comparison in a variable
+type CompareStatement struct {
+	Target string
+	Left   string
+	Right  string
+	Op     ComparisonOperator
+}
+
+// BausteinFinishStatement marks the end of a building block and does nothing
+type BausteinFinishStatement struct{}
+
+func (s *ExecuteStatement) Kind() OperationType        { return OpExecute }
+func (s *EvalStatement) Kind() OperationType           { return OpEval }
+func (s *IfStatement) Kind() OperationType             { return OpIf }
+func (s *CompareStatement) Kind() OperationType        { return OpCompare }
+func (s *BausteinFinishStatement) Kind() OperationType { return OpBausteinFinish }
+
+// statementSource renders a statement for error messages
+func statementSource(statement Statement) string {
+	switch s := statement.(type) {
+	case *ExecuteStatement:
+		return "EXECUTE " + s.Method
+	case *EvalStatement:
+		if s.Assignment != nil {
+			return s.Exec
+		}
+		return legacyEvalSource(s)
+	case *IfStatement:
+		if s.Condition != nil {
+			return "IF " + s.Expr
+		}
+		return fmt.Sprintf("IF %s %s %s", s.Left, s.Op, s.Right)
+	case *CompareStatement:
+		return fmt.Sprintf("%s = %s %s %s", s.Target, s.Left, s.Op, s.Right)
+	}
+	return string(statement.Kind())
+}
+
+// UnmarshalXML decodes the body of a MAIN or METHOD element into a statement tree
+func (m *PAPMethod) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	m.Name = attrValue(start, "name")
+
+	statements, err := decodeStatements(d, start)
+	if err != nil {
+		if m.Name != "" {
+			return fmt.Errorf("method %s: %w", m.Name, err)
+		}
+		return fmt.Errorf("%s: %w", start.Name.Local, err)
+	}
+	m.Statements = statements
+	return nil
+}
+
+func decodeStatements(d *xml.Decoder, parent xml.StartElement) ([]Statement, error) {
+	var statements []Statement
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s block: %w", parent.Name.Local, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			statement, err := decodeStatement(d, t)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, statement)
+
+		case xml.EndElement:
+			return statements, nil
+		}
+	}
+}
+
+func decodeStatement(d *xml.Decoder, start xml.StartElement) (Statement, error) {
+	switch OperationType(start.Name.Local) {
+	case OpExecute:
+		statement := &ExecuteStatement{Method: attrValue(start, "method")}
+		if statement.Method == "" {
+			return nil, fmt.Errorf("EXECUTE without method attribute")
+		}
+		return statement, d.Skip()
+
+	case OpEval:
+		statement := &EvalStatement{
+			Exec:   attrValue(start, "exec"),
+			Target: attrValue(start, "target"),
+			Left:   attrValue(start, "left"),
+			Right:  attrValue(start, "right"),
+			Op:     EvalOperator(attrValue(start, "op")),
+		}
+		if statement.Exec != "" {
+			assignment, err := ParseAssignment(statement.Exec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EVAL: %w", err)
+			}
+			statement.Assignment = assignment
+		} else if statement.Target == "" {
+			return nil, fmt.Errorf("EVAL without exec or target attribute")
+		}
+		return statement, d.Skip()
+
+	case OpIf:
+		statement := &IfStatement{
+			Expr:  attrValue(start, "expr"),
+			Left:  attrValue(start, "left"),
+			Right: attrValue(start, "right"),
+			Op:    ComparisonOperator(attrValue(start, "op")),
+		}
+		if statement.Expr != "" {
+			condition, err := ParseExpression(statement.Expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IF: %w", err)
+			}
+			statement.Condition = condition
+		}
+		if err := decodeIfBranches(d, start, statement); err != nil {
+			return nil, err
+		}
+		return statement, nil
+
+	case OpCompare:
+		statement := &CompareStatement{
+			Target: attrValue(start, "target"),
+			Left:   attrValue(start, "left"),
+			Right:  attrValue(start, "right"),
+			Op:     ComparisonOperator(attrValue(start, "op")),
+		}
+		return statement, d.Skip()
+
+	case OpBausteinFinish:
+		return &BausteinFinishStatement{}, d.Skip()
+	}
+
+	return nil, fmt.Errorf("unsupported statement <%s>", start.Name.Local)
+}
+
+func decodeIfBranches(d *xml.Decoder, start xml.StartElement, statement *IfStatement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read IF block: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			branch, err := decodeStatements(d, t)
+			if err != nil {
+				return err
+			}
+
+			switch OperationType(t.Name.Local) {
+			case OpThen:
+				statement.Then = append(statement.Then, branch...)
+			case OpElse:
+				statement.Else = append(statement.Else, branch...)
+			default:
+				return fmt.Errorf("unexpected <%s> inside IF, expected THEN or ELSE", t.Name.Local)
+			}
+
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func attrValue(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package bmf
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+const nestedPAP = `<?xml version="1.0" encoding="UTF-8"?>
+<PAP name="Nested" version="1.0">
+	<VARIABLES>
+		<INPUTS>
+			<INPUT name="STKL" type="int"/>
+			<INPUT name="KRV" type="int"/>
+		</INPUTS>
+		<OUTPUTS type="STANDARD">
+			<OUTPUT name="RESULT" type="int" default="0"/>
+			<OUTPUT name="CALLS" type="int" default="0"/>
+		</OUTPUTS>
+		<INTERNALS>
+			<INTERNAL name="KZTAB" type="int" default="1"/>
+		</INTERNALS>
+	</VARIABLES>
+	<CONSTANTS/>
+	<METHODS>
+		<MAIN>
+			<EXECUTE method="MCLASS"/>
+			<IF expr="RESULT &gt; 20">
+				<THEN>
+					<EVAL exec="RESULT = RESULT + 1000"/>
+				</THEN>
+			</IF>
+		</MAIN>
+		<METHOD name="MCLASS">
+			<IF expr="STKL &lt; 3">
+				<THEN>
+					<IF expr="KRV == 0">
+						<THEN>
+							<EVAL exec="RESULT = 11"/>
+						</THEN>
+						<ELSE>
+							<IF expr="KRV == 1">
+								<THEN>
+									<EVAL exec="RESULT = 12"/>
+								</THEN>
+								<ELSE>
+									<EVAL exec="RESULT = 13"/>
+								</ELSE>
+							</IF>
+						</ELSE>
+					</IF>
+				</THEN>
+				<ELSE>
+					<IF expr="STKL == 3">
+						<THEN>
+							<EVAL exec="KZTAB = 2"/>
+						</THEN>
+					</IF>
+					<EVAL exec="RESULT = 20 + KZTAB"/>
+				</ELSE>
+			</IF>
+			<EXECUTE method="MCOUNT"/>
+		</METHOD>
+		<METHOD name="MCOUNT">
+			<EVAL exec="CALLS = CALLS + 1"/>
+		</METHOD>
+	</METHODS>
+</PAP>`
+
+func TestPAPMethodUnmarshalXML(t *testing.T) {
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(nestedPAP), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(papData.Methods.Main) != 1 {
+		t.Fatalf("Expected 1 main method, got %d", len(papData.Methods.Main))
+	}
+
+	if len(papData.Methods.Main[0].Statements) != 2 {
+		t.Errorf("Expected 2 statements in MAIN, got %d", len(papData.Methods.Main[0].Statements))
+	}
+
+	if len(papData.Methods.Method) != 2 {
+		t.Fatalf("Expected 2 methods, got %d", len(papData.Methods.Method))
+	}
+
+	method := papData.Methods.Method[0]
+	if method.Name != "MCLASS" {
+		t.Errorf("Expected method MCLASS, got %q", method.Name)
+	}
+
+	outer, ok := method.Statements[0].(*IfStatement)
+	if !ok {
+		t.Fatalf("Expected *IfStatement, got %T", method.Statements[0])
+	}
+
+	if len(outer.Then) != 1 || len(outer.Else) != 2 {
+		t.Errorf("Expected 1 THEN and 2 ELSE statements, got %d and %d", len(outer.Then), len(outer.Else))
+	}
+
+	inner, ok := outer.Then[0].(*IfStatement)
+	if !ok {
+		t.Fatalf("Expected nested *IfStatement, got %T", outer.Then[0])
+	}
+
+	if _, ok := inner.Else[0].(*IfStatement); !ok {
+		t.Errorf("Expected third level *IfStatement, got %T", inner.Else[0])
+	}
+
+	if _, ok := method.Statements[1].(*ExecuteStatement); !ok {
+		t.Errorf("Expected *ExecuteStatement, got %T", method.Statements[1])
+	}
+}
+
+func TestPAPMethodUnmarshalXMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown statement", `<FOO/>`},
+		{"invalid expression", `<EVAL exec="X = (1"/>`},
+		{"invalid condition", `<IF expr="X &lt;"><THEN/></IF>`},
+		{"unexpected IF child", `<IF expr="X == 1"><EVAL exec="X = 1"/></IF>`},
+		{"execute without method", `<EXECUTE/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method PAPMethod
+			err := xml.Unmarshal([]byte(`<METHOD name="TEST">`+tt.body+`</METHOD>`), &method)
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+
+			if !strings.Contains(err.Error(), "method TEST") {
+				t.Errorf("Expected error to name the method, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaxCalculatorNestedBranches(t *testing.T) {
+	var papData PAPData
+	if err := xml.Unmarshal([]byte(nestedPAP), &papData); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		stkl     int
+		krv      int
+		expected int
+	}{
+		{stkl: 1, krv: 0, expected: 11},
+		{stkl: 2, krv: 1, expected: 12},
+		{stkl: 1, krv: 2, expected: 13},
+		{stkl: 3, krv: 0, expected: 1022},
+		{stkl: 4, krv: 0, expected: 1021},
+	}
+
+	calculator := NewTaxCalculator(&papData)
+	for _, tt := range tests {
+		calculator.SetInputValue("STKL", tt.stkl)
+		calculator.SetInputValue("KRV", tt.krv)
+
+		if err := calculator.Calculate(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if result := calculator.GetOutputValue("RESULT"); result != tt.expected {
+			t.Errorf("STKL=%d KRV=%d: expected %d, got %v", tt.stkl, tt.krv, tt.expected, result)
+		}
+
+		if calls := calculator.GetOutputValue("CALLS"); calls != 1 {
+			t.Errorf("Expected MCOUNT to run once per calculation, got %v", calls)
+		}
+	}
+}
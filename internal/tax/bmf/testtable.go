@@ -0,0 +1,210 @@
+package bmf
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// The Prüftabellen of the PAP versions, named after the version, e.g.
+// testtables/2025Version1.csv. None ship yet, see testtables/README.md.
+//
+//go:embed testtables
+var embeddedTestTables embed.FS
+
+// ErrNoTestTable is returned for a PAP version without a test table
+var ErrNoTestTable = errors.New("no test table")
+
+// TestTable lists inputs of a PAP with the outputs it must produce for them,
+// as the Prüftabellen the BMF publishes with each PAP do. In its CSV form
+// the first line that is not a # comment names the columns; a column of an
+// input of the PAP holds an input, one of an output an expected value in
+// the unit of the PAP, e.g. cents for LSTLZZ.
+type TestTable struct {
+	Version string
+	Cases   []TestCase
+}
+
+// TestCase is one line of a test table
+type TestCase struct {
+	Line     int
+	Inputs   map[string]interface{}
+	Expected map[string]Decimal
+
+	// The input columns in the order of the table, for reports
+	names []string
+}
+
+// String lists the inputs of the case, e.g. "STKL=1 RE4=3000000"
+func (c TestCase) String() string {
+	fields := make([]string, len(c.names))
+	for i, name := range c.names {
+		fields[i] = name + "=" + FormatTraceValue(c.Inputs[name])
+	}
+	return strings.Join(fields, " ")
+}
+
+// TestMismatch is an output that differs from the value in the table
+type TestMismatch struct {
+	Field    string
+	Expected Decimal
+	Actual   interface{}
+}
+
+func (m TestMismatch) String() string {
+	if m.Actual == nil {
+		return fmt.Sprintf("%s expected %s, got nothing", m.Field, m.Expected)
+	}
+	return fmt.Sprintf("%s expected %s, got %s", m.Field, m.Expected, FormatTraceValue(m.Actual))
+}
+
+// TestResult is the outcome of a test case. Err is set if the calculation
+// failed, Mismatches otherwise lists the outputs that differ.
+type TestResult struct {
+	Case       TestCase
+	Mismatches []TestMismatch
+	Err        error
+}
+
+// Passed reports whether the case produced every expected value
+func (r TestResult) Passed() bool {
+	return r.Err == nil && len(r.Mismatches) == 0
+}
+
+// LoadTestTable returns the embedded test table of a PAP version. The PAP
+// tells which columns are inputs and which are outputs.
+func LoadTestTable(version PAPVersion, papData *PAPData) (*TestTable, error) {
+	tables, err := fs.Sub(embeddedTestTables, "testtables")
+	if err != nil {
+		return nil, err
+	}
+	return loadTestTable(tables, version, papData)
+}
+
+// LoadTestTableDir is LoadTestTable for the tables in a directory, such as
+// Prüftabellen kept outside the binary
+func LoadTestTableDir(dir string, version PAPVersion, papData *PAPData) (*TestTable, error) {
+	return loadTestTable(os.DirFS(dir), version, papData)
+}
+
+func loadTestTable(tables fs.FS, version PAPVersion, papData *PAPData) (*TestTable, error) {
+	file, err := tables.Open(version.Version + ".csv")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w for PAP %s", ErrNoTestTable, version.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	table, err := ParseTestTable(file, papData)
+	if err != nil {
+		return nil, fmt.Errorf("test table %s: %w", version.Version, err)
+	}
+	table.Version = version.Version
+	return table, nil
+}
+
+// ParseTestTable reads a test table in CSV form for a PAP
+func ParseTestTable(r io.Reader, papData *PAPData) (*TestTable, error) {
+	inputs := make(map[string]string)
+	for _, input := range papData.Variables.Inputs.Input {
+		inputs[input.Name] = input.Type
+	}
+	outputs := make(map[string]bool)
+	for _, output := range papData.Variables.Outputs.Output {
+		outputs[output.Name] = true
+	}
+
+	table := &TestTable{}
+	var columns []string
+	var names []string
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		if columns == nil {
+			for _, column := range fields {
+				if _, ok := inputs[column]; ok {
+					names = append(names, column)
+				} else if !outputs[column] {
+					return nil, fmt.Errorf("line %d: %s is neither an input nor an output of the PAP", line, column)
+				}
+			}
+			columns = fields
+			continue
+		}
+
+		if len(fields) != len(columns) {
+			return nil, fmt.Errorf("line %d: expected %d values, got %d", line, len(columns), len(fields))
+		}
+		testCase := TestCase{
+			Line:     line,
+			Inputs:   make(map[string]interface{}),
+			Expected: make(map[string]Decimal),
+			names:    names,
+		}
+		for i, column := range columns {
+			if inputType, ok := inputs[column]; ok {
+				if _, err := ParseDecimal(fields[i]); err != nil {
+					return nil, fmt.Errorf("line %d: invalid value %q for %s", line, fields[i], column)
+				}
+				testCase.Inputs[column] = parseValue(inputType, fields[i])
+				continue
+			}
+			expected, err := ParseDecimal(fields[i])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q for %s", line, fields[i], column)
+			}
+			testCase.Expected[column] = expected
+		}
+		table.Cases = append(table.Cases, testCase)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("no columns")
+	}
+	return table, nil
+}
+
+// Run calculates every case of the table, e.g. with Program.Calculate or the
+// generated code of the PAP, and compares the outputs with the table
+func (t *TestTable) Run(calculate func(inputs map[string]interface{}) (map[string]interface{}, error)) []TestResult {
+	results := make([]TestResult, len(t.Cases))
+	for i, testCase := range t.Cases {
+		results[i] = TestResult{Case: testCase}
+
+		outputs, err := calculate(testCase.Inputs)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(testCase.Expected)) {
+			expected := testCase.Expected[name]
+			actual, err := toDecimal(outputs[name])
+			if err != nil || actual.Cmp(expected) != 0 {
+				results[i].Mismatches = append(results[i].Mismatches, TestMismatch{Field: name, Expected: expected, Actual: outputs[name]})
+			}
+		}
+	}
+	return results
+}
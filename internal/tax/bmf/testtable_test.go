@@ -0,0 +1,113 @@
+package bmf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// An embedded table must fit the PAP of its version; the local engine runs
+// its cases in the calculation package
+func TestEmbeddedTestTables(t *testing.T) {
+	for _, version := range papVersions {
+		papData, err := LoadPAPFile("./" + version.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table, err := LoadTestTable(version, papData)
+		if errors.Is(err, ErrNoTestTable) {
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", version.Version, err)
+		} else if len(table.Cases) == 0 {
+			t.Errorf("%s: expected test cases", version.Version)
+		}
+	}
+}
+
+func TestLoadTestTableDir(t *testing.T) {
+	version, err := DefaultPAPRegistry.Resolve(2025, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	papData, err := LoadPAPFile("./" + version.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if _, err := LoadTestTableDir(dir, version, papData); !errors.Is(err, ErrNoTestTable) {
+		t.Errorf("Expected ErrNoTestTable for an empty directory, got: %v", err)
+	}
+
+	src := "LZZ,STKL,RE4,LSTLZZ,SOLZLZZ\n1,1,0,0,0\n"
+	if err := os.WriteFile(filepath.Join(dir, "2025Version1.csv"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := LoadTestTableDir(dir, version, papData)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if table.Version != "2025Version1" || len(table.Cases) != 1 || table.Cases[0].String() != "LZZ=1 STKL=1 RE4=0" {
+		t.Errorf("Unexpected table %+v", table)
+	}
+}
+
+func TestParseTestTable(t *testing.T) {
+	var papData PAPData
+	papData.Variables.Inputs.Input = []InputVariable{{Name: "STKL", Type: "int"}, {Name: "RE4", Type: "BigDecimal"}}
+	papData.Variables.Outputs.Output = []OutputVariable{{Name: "LSTLZZ", Type: "BigDecimal"}}
+
+	src := "# comment\nSTKL, RE4, LSTLZZ\n\n1,100,10\n3,200.5,21\n6,1,0\n"
+	table, err := ParseTestTable(strings.NewReader(src), &papData)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(table.Cases) != 3 || table.Cases[1].Line != 5 || table.Cases[1].String() != "STKL=3 RE4=200.5" {
+		t.Fatalf("Expected three cases, the second from line 5, got %+v", table.Cases)
+	}
+
+	// RE4 / 10, wrong for the second case and failing for the third
+	results := table.Run(func(inputs map[string]interface{}) (map[string]interface{}, error) {
+		if inputs["STKL"] == 6 {
+			return nil, errors.New("failed")
+		}
+		return map[string]interface{}{"LSTLZZ": DecimalFromInt(inputs["RE4"].(Decimal).IntValue() / 10)}, nil
+	})
+	if !results[0].Passed() {
+		t.Errorf("Expected the first case to pass, got %+v", results[0])
+	}
+	if results[1].Passed() || len(results[1].Mismatches) != 1 || results[1].Mismatches[0].String() != "LSTLZZ expected 21, got 20" {
+		t.Errorf("Expected a mismatch of LSTLZZ, got %+v", results[1])
+	}
+	if results[2].Passed() || results[2].Err == nil {
+		t.Errorf("Expected the error of the calculation, got %+v", results[2])
+	}
+
+	errorTests := []struct {
+		src      string
+		expected string
+	}{
+		{"STKL,RE4,LSTLZZ,X\n", "line 1: X is neither an input nor an output of the PAP"},
+		{"STKL,LSTLZZ\n1\n", "line 2: expected 2 values, got 1"},
+		{"STKL,LSTLZZ\n1,abc\n", `line 2: invalid value "abc" for LSTLZZ`},
+		{"STKL,LSTLZZ\nI,10\n", `line 2: invalid value "I" for STKL`},
+		{"# only comments\n", "no columns"},
+	}
+	for _, tt := range errorTests {
+		_, err := ParseTestTable(strings.NewReader(tt.src), &papData)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.src, tt.expected, err)
+		}
+	}
+}
+
+func TestLoadTestTableMissing(t *testing.T) {
+	_, err := LoadTestTable(PAPVersion{Version: "1999Version1"}, &PAPData{})
+	if !errors.Is(err, ErrNoTestTable) {
+		t.Errorf("Expected ErrNoTestTable, got: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package bmf
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// TraceEventKind tells what happened in a step of a traced calculation
+type TraceEventKind string
+
+const (
+	// TraceStart carries the values of all variables before MAIN runs
+	TraceStart  TraceEventKind = "start"
+	TraceCall   TraceEventKind = "call"
+	TraceReturn TraceEventKind = "return"
+	TraceEval   TraceEventKind = "eval"
+	TraceBranch TraceEventKind = "branch"
+	TraceWrite  TraceEventKind = "write"
+)
+
+// TraceEvent is one step of a traced calculation. Method is the method
+// running at the time and Depth the number of methods on the stack, with
+// MAIN at 0. Source is the PAP text of the step: the exec attribute of an
+// EVAL, the condition of an IF or the called method.
+type TraceEvent struct {
+	Step   int
+	Kind   TraceEventKind
+	Method string
+	Depth  int
+	Source string
+
+	// The result of an EVAL expression, or of an IF condition as a bool
+	Value interface{}
+
+	// A written variable with its values before and after the write. Before
+	// is nil if the variable had no value yet.
+	Variable string
+	Before   interface{}
+	After    interface{}
+
+	// All variables, set on the TraceStart event only
+	Variables map[string]interface{}
+}
+
+func (e TraceEvent) String() string {
+	switch e.Kind {
+	case TraceStart:
+		return "start " + e.Method
+	case TraceCall:
+		return "→ " + e.Source
+	case TraceReturn:
+		return "← " + e.Source
+	case TraceEval:
+		return fmt.Sprintf("%s ⇒ %s", e.Source, FormatTraceValue(e.Value))
+	case TraceBranch:
+		branch := "ELSE"
+		if taken, _ := e.Value.(bool); taken {
+			branch = "THEN"
+		}
+		return fmt.Sprintf("IF %s → %s", e.Source, branch)
+	case TraceWrite:
+		return fmt.Sprintf("%s: %s → %s", e.Variable, FormatTraceValue(e.Before), FormatTraceValue(e.After))
+	}
+	return string(e.Kind)
+}
+
+// FormatTraceValue renders a variable value of the PAP, nil meaning unset
+func FormatTraceValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "unset"
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, element := range v {
+			parts[i] = FormatTraceValue(element)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	return fmt.Sprint(value)
+}
+
+// Tracer receives the steps of a calculation as they happen
+type Tracer interface {
+	Trace(event TraceEvent)
+}
+
+// Trace is a Tracer that records every step
+type Trace struct {
+	Events []TraceEvent
+}
+
+func (t *Trace) Trace(event TraceEvent) {
+	event.Step = len(t.Events)
+	t.Events = append(t.Events, event)
+}
+
+// Len returns the number of recorded steps
+func (t *Trace) Len() int {
+	return len(t.Events)
+}
+
+// Variables returns the values of all variables after a step
+func (t *Trace) Variables(step int) map[string]interface{} {
+	variables := make(map[string]interface{})
+	for i := 0; i <= step && i < len(t.Events); i++ {
+		switch event := t.Events[i]; event.Kind {
+		case TraceStart:
+			maps.Copy(variables, event.Variables)
+		case TraceWrite:
+			variables[event.Variable] = event.After
+		}
+	}
+	return variables
+}
+
+// Hits reports whether a step writes one of the named variables or calls
+// one of the named methods
+func (e TraceEvent) Hits(names []string) bool {
+	for _, name := range names {
+		if (e.Kind == TraceWrite && e.Variable == name) || (e.Kind == TraceCall && e.Source == name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextBreak returns the first step after from that hits a breakpoint, or -1
+func (t *Trace) NextBreak(from int, breakpoints []string) int {
+	for i := from + 1; i < len(t.Events); i++ {
+		if t.Events[i].Hits(breakpoints) {
+			return i
+		}
+	}
+	return -1
+}
+
+// PrevBreak returns the last step before from that hits a breakpoint, or -1
+func (t *Trace) PrevBreak(from int, breakpoints []string) int {
+	for i := min(from, len(t.Events)) - 1; i >= 0; i-- {
+		if t.Events[i].Hits(breakpoints) {
+			return i
+		}
+	}
+	return -1
+}
+
+// trace hands an event to the tracer, filling in where it happened
+func (tc *TaxCalculator) trace(event TraceEvent) {
+	event.Method = tc.traceMethod
+	event.Depth = tc.traceDepth
+	tc.Tracer.Trace(event)
+}
+
+// snapshot copies the values of all variables for the TraceStart event,
+// resolving names declared twice like getVariableValue
+func (tc *TaxCalculator) snapshot() map[string]interface{} {
+	variables := make(map[string]interface{})
+	for _, values := range []map[string]interface{}{tc.OutputValues, tc.Constants, tc.InternalVars, tc.InputValues} {
+		maps.Copy(variables, values)
+	}
+	return variables
+}
@@ -0,0 +1,147 @@
+package bmf
+
+import (
+	"testing"
+)
+
+const tracedPAP = `<PAP name="Traced">
+	<VARIABLES>
+		<INPUTS><INPUT name="A" type="int"/></INPUTS>
+		<OUTPUTS><OUTPUT name="B" type="int" default="0"/></OUTPUTS>
+		<INTERNALS><INTERNAL name="C" type="int"/></INTERNALS>
+	</VARIABLES>
+	<CONSTANTS><CONSTANT name="TWO" type="int" value="2"/></CONSTANTS>
+	<METHODS>
+		<MAIN><EXECUTE method="MCALC"/></MAIN>
+		<METHOD name="MCALC">
+			<IF expr="A &gt; 1">
+				<THEN><EVAL exec="C = A * TWO"/></THEN>
+				<ELSE><EVAL exec="C = 1"/></ELSE>
+			</IF>
+			<EVAL exec="B = C + 1"/>
+		</METHOD>
+	</METHODS>
+</PAP>`
+
+func tracedProgram(t *testing.T) *Program {
+	t.Helper()
+	papData, err := ParsePAP([]byte(tracedPAP))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return NewProgram(papData)
+}
+
+func TestTraceEvents(t *testing.T) {
+	trace := &Trace{}
+	outputs, err := tracedProgram(t).Trace(map[string]interface{}{"A": 3}, trace)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if outputs["B"] != 7 {
+		t.Errorf("Expected B = 7, got %v", outputs["B"])
+	}
+
+	expected := []struct {
+		kind   TraceEventKind
+		method string
+		depth  int
+		text   string
+	}{
+		{TraceStart, "MAIN", 0, "start MAIN"},
+		{TraceCall, "MAIN", 0, "→ MCALC"},
+		{TraceBranch, "MCALC", 1, "IF A > 1 → THEN"},
+		{TraceEval, "MCALC", 1, "C = A * TWO ⇒ 6"},
+		{TraceWrite, "MCALC", 1, "C: 0 → 6"},
+		{TraceEval, "MCALC", 1, "B = C + 1 ⇒ 7"},
+		{TraceWrite, "MCALC", 1, "B: 0 → 7"},
+		{TraceReturn, "MAIN", 0, "← MCALC"},
+	}
+	if trace.Len() != len(expected) {
+		t.Fatalf("Expected %d steps, got %d: %v", len(expected), trace.Len(), trace.Events)
+	}
+	for i, want := range expected {
+		event := trace.Events[i]
+		if event.Step != i || event.Kind != want.kind || event.Method != want.method || event.Depth != want.depth || event.String() != want.text {
+			t.Errorf("Step %d: expected %s in %s at depth %d (%q), got %s in %s at depth %d (%q)",
+				i, want.kind, want.method, want.depth, want.text, event.Kind, event.Method, event.Depth, event.String())
+		}
+	}
+}
+
+func TestTraceVariables(t *testing.T) {
+	trace := &Trace{}
+	if _, err := tracedProgram(t).Trace(map[string]interface{}{"A": 0}, trace); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	start := trace.Variables(0)
+	if start["A"] != 0 || start["B"] != 0 || start["C"] != 0 || start["TWO"] != 2 {
+		t.Errorf("Expected all variables with their initial values at the start, got %v", start)
+	}
+
+	end := trace.Variables(trace.Len() - 1)
+	if end["C"] != 1 || end["B"] != 2 {
+		t.Errorf("Expected C = 1 and B = 2 at the end, got %v", end)
+	}
+}
+
+func TestTraceBreakpoints(t *testing.T) {
+	trace := &Trace{}
+	if _, err := tracedProgram(t).Trace(map[string]interface{}{"A": 3}, trace); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	breakpoints := []string{"MCALC", "B"}
+	if step := trace.NextBreak(0, breakpoints); step != 1 {
+		t.Errorf("Expected to stop at the call of MCALC, got step %d", step)
+	}
+	if step := trace.NextBreak(1, breakpoints); step != 6 {
+		t.Errorf("Expected to stop at the write of B, got step %d", step)
+	}
+	if step := trace.NextBreak(6, breakpoints); step != -1 {
+		t.Errorf("Expected no further breakpoint, got step %d", step)
+	}
+	if step := trace.PrevBreak(6, breakpoints); step != 1 {
+		t.Errorf("Expected to go back to the call of MCALC, got step %d", step)
+	}
+	if step := trace.PrevBreak(1, breakpoints); step != -1 {
+		t.Errorf("Expected no earlier breakpoint, got step %d", step)
+	}
+}
+
+func TestTraceMatchesCalculation(t *testing.T) {
+	papData, err := LoadPAP(DefaultYear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := Compile(papData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := map[string]interface{}{"LZZ": 1, "STKL": 3, "KVZ": 2.5, "RE4": 6000000}
+	want, err := program.Calculate(inputs)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	trace := &Trace{}
+	got, err := program.Trace(inputs, trace)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	variables := trace.Variables(trace.Len() - 1)
+	for name, value := range want {
+		if FormatTraceValue(got[name]) != FormatTraceValue(value) {
+			t.Errorf("%s: expected %v from the traced run, got %v", name, value, got[name])
+		}
+		if FormatTraceValue(variables[name]) != FormatTraceValue(value) {
+			t.Errorf("%s: expected the trace to end with %v, got %v", name, value, variables[name])
+		}
+	}
+	if last := trace.Events[trace.Len()-1]; last.Kind != TraceReturn || last.Depth != 0 {
+		t.Errorf("Expected the trace to end with a return to MAIN, got %+v", last)
+	}
+}
@@ -1,20 +1,13 @@
 package bmf
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
-	"io"
-	"math"
-	"math/big"
-	"net/http"
 	"strconv"
 	"strings"
 )
 
-const (
-	XMLSourceURL = "https://www.bmf-steuerrechner.de/javax.faces.resource/daten/xmls/Lohnsteuer2025.xml.xhtml"
-)
-
 type PAPData struct {
 	XMLName    xml.Name        `xml:"PAP"`
 	Name       string          `xml:"name,attr"`
@@ -77,201 +70,116 @@ type PAPMethods struct {
 }
 
 type PAPMethod struct {
-	Name  string        `xml:"name,attr"`
-	Steps []interface{} `xml:",any"`
+	Name       string
+	Statements []Statement
 }
 
+// TaxCalculator holds the values of one calculation. It must not be used by
+// several goroutines at once; build one per calculation from a shared Program.
 type TaxCalculator struct {
 	XMLData      *PAPData
 	InputValues  map[string]interface{}
 	OutputValues map[string]interface{}
 	InternalVars map[string]interface{}
 	Constants    map[string]interface{}
+
+	// Tracer, if set, is told about every step of Calculate
+	Tracer Tracer
+
+	// Limits bound Calculate; NewCalculator sets those of the Program
+	Limits Limits
+
+	program     *Program
+	guard       guard
+	traceMethod string
+	traceDepth  int
 }
 
+// FetchTaxCalculationXML downloads the PAP of the default year from the BMF
+// using DefaultClient
 func FetchTaxCalculationXML() (*PAPData, error) {
-	resp, err := http.Get(XMLSourceURL)
+	version, err := DefaultPAPRegistry.Version(DefaultYear)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch XML: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return DefaultClient.FetchPAP(context.Background(), version)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("XML fetch failed with status: %s", resp.Status)
-	}
+func NewTaxCalculator(papData *PAPData) *TaxCalculator {
+	return NewProgram(papData).NewCalculator()
+}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read XML data: %w", err)
-	}
+func (tc *TaxCalculator) SetInputValue(name string, value interface{}) {
+	tc.InputValues[name] = value
+}
 
-	var papData PAPData
-	if err := xml.Unmarshal(data, &papData); err != nil {
-		return nil, fmt.Errorf("failed to parse XML: %w", err)
-	}
+func (tc *TaxCalculator) GetOutputValue(name string) interface{} {
+	return tc.OutputValues[name]
+}
 
-	return &papData, nil
+func (tc *TaxCalculator) Calculate() error {
+	return tc.CalculateContext(context.Background())
 }
 
-func NewTaxCalculator(papData *PAPData) *TaxCalculator {
-	calculator := &TaxCalculator{
-		XMLData:      papData,
-		InputValues:  make(map[string]interface{}),
-		OutputValues: make(map[string]interface{}),
-		InternalVars: make(map[string]interface{}),
-		Constants:    make(map[string]interface{}),
-	}
-
-	// Initialize default values from XML
-	for _, input := range papData.Variables.Inputs.Input {
-		if input.Default != "" {
-			calculator.InputValues[input.Name] = parseValue(input.Type, input.Default)
-		}
-	}
+// CalculateContext is Calculate stopping with a *LimitError when the context
+// is done
+func (tc *TaxCalculator) CalculateContext(ctx context.Context) error {
+	ctx, cancel := tc.Limits.context(ctx)
+	defer cancel()
+	tc.guard = newGuard(ctx, tc.Limits)
 
-	for _, output := range papData.Variables.Outputs.Output {
-		if output.Default != "" {
-			calculator.OutputValues[output.Name] = parseValue(output.Type, output.Default)
-		}
-	}
+	tc.resetVariables()
 
-	for _, internal := range papData.Variables.Internals.Internal {
-		if internal.Default != "" {
-			calculator.InternalVars[internal.Name] = parseValue(internal.Type, internal.Default)
+	// Bring inputs to their declared types, e.g. an int RE4 to BigDecimal,
+	// and treat inputs that were never set as zero
+	for _, input := range tc.XMLData.Variables.Inputs.Input {
+		value, ok := tc.InputValues[input.Name]
+		if !ok {
+			if value := initialValue(input.Type, input.Default); value != nil {
+				tc.InputValues[input.Name] = value
+			}
+			continue
+		}
+		converted, err := convertValue(input.Type, value)
+		if err != nil {
+			return fmt.Errorf("input %s: %w", input.Name, err)
 		}
+		tc.InputValues[input.Name] = converted
 	}
 
-	// Initialize constants
-	for _, constant := range papData.Constants.Constant {
-		calculator.Constants[constant.Name] = parseValue(constant.Type, constant.Value)
+	// Execute the main calculation method
+	if len(tc.XMLData.Methods.Main) == 0 {
+		return fmt.Errorf("no main method found in XML data")
 	}
 
-	return calculator
-}
+	if tc.Tracer != nil {
+		tc.traceMethod, tc.traceDepth = "MAIN", 0
+		tc.trace(TraceEvent{Kind: TraceStart, Variables: tc.snapshot()})
+	}
 
-func (tc *TaxCalculator) SetInputValue(name string, value interface{}) {
-	tc.InputValues[name] = value
-}
+	if err := tc.executeStatements(tc.XMLData.Methods.Main[0].Statements, "MAIN"); err != nil {
+		return err
+	}
 
-func (tc *TaxCalculator) GetOutputValue(name string) interface{} {
-	return tc.OutputValues[name]
+	return nil
 }
 
-func (tc *TaxCalculator) Calculate() error {
-	// Reset output values and internal variables
+// resetVariables sets outputs and internal variables back to their defaults
+func (tc *TaxCalculator) resetVariables() {
 	tc.OutputValues = make(map[string]interface{})
 	tc.InternalVars = make(map[string]interface{})
 
-	// Initialize defaults again
 	for _, output := range tc.XMLData.Variables.Outputs.Output {
-		if output.Default != "" {
-			tc.OutputValues[output.Name] = parseValue(output.Type, output.Default)
+		if value := initialValue(output.Type, output.Default); value != nil {
+			tc.OutputValues[output.Name] = value
 		}
 	}
 
 	for _, internal := range tc.XMLData.Variables.Internals.Internal {
-		if internal.Default != "" {
-			tc.InternalVars[internal.Name] = parseValue(internal.Type, internal.Default)
-		}
-	}
-
-	// Execute the main calculation method
-	if len(tc.XMLData.Methods.Main) > 0 {
-		// Process each step in the main method
-		for _, step := range tc.XMLData.Methods.Main[0].Steps {
-			if execute, ok := step.(xml.StartElement); ok {
-				op := OperationType(execute.Name.Local)
-				
-				switch op {
-				case OpExecute:
-					// Call another method
-					methodName := ""
-					for _, attr := range execute.Attr {
-						if attr.Name.Local == "method" {
-							methodName = attr.Value
-							break
-						}
-					}
-					if methodName != "" {
-						if err := tc.executeMethod(methodName); err != nil {
-							return fmt.Errorf("error executing method %s: %w", methodName, err)
-						}
-					}
-					
-				case OpEval:
-					// Evaluate an expression
-					var target string
-					var left, right string
-					var op EvalOperator
-					
-					for _, attr := range execute.Attr {
-						switch attr.Name.Local {
-						case "target":
-							target = attr.Value
-						case "op":
-							op = EvalOperator(attr.Value)
-						case "left":
-							left = attr.Value
-						case "right":
-							right = attr.Value
-						}
-					}
-					
-					if target != "" {
-						// Evaluate the expression
-						result, err := tc.evaluateExpression(left, right, op)
-						if err != nil {
-							return fmt.Errorf("evaluation error in main method: %w", err)
-						}
-						
-						// Store the result in the appropriate location
-						tc.setVariableValue(target, result)
-					}
-					
-				case OpIf:
-					// Process IF condition in the main method
-					var left, right string
-					var op ComparisonOperator
-					
-					for _, attr := range execute.Attr {
-						switch attr.Name.Local {
-						case "left":
-							left = attr.Value
-						case "right":
-							right = attr.Value
-						case "op":
-							op = ComparisonOperator(attr.Value)
-						}
-					}
-					
-					// Evaluate the condition
-					result, err := tc.evaluateComparison(left, right, op)
-					if err != nil {
-						return fmt.Errorf("if condition error in main method: %w", err)
-					}
-					
-					// Process THEN/ELSE blocks - this is simplified and would need to be
-					// implemented more completely by tracking the XML structure
-					// For now, we'll just log the condition result
-					_ = result
-				}
-			}
-		}
-	} else {
-		return fmt.Errorf("no main method found in XML data")
-	}
-
-	// Round monetary outputs to integers (cents)
-	for name, value := range tc.OutputValues {
-		if rat, ok := value.(*big.Rat); ok {
-			// For monetary values, round to cents
-			cents := new(big.Rat).Mul(rat, big.NewRat(100, 1))
-			f, _ := cents.Float64()
-			tc.OutputValues[name] = int(math.Round(f))
+		if value := initialValue(internal.Type, internal.Default); value != nil {
+			tc.InternalVars[internal.Name] = value
 		}
 	}
-
-	return nil
 }
 
 type OperationType string
@@ -312,161 +220,122 @@ const (
 func (tc *TaxCalculator) executeMethod(methodName string) error {
 	// Find the method with the given name
 	var methodToExecute *PAPMethod
-	for _, method := range tc.XMLData.Methods.Method {
-		if method.Name == methodName {
-			methodToExecute = &method
-			break
+	if tc.program != nil {
+		methodToExecute = tc.program.methods[methodName]
+	} else {
+		for i := range tc.XMLData.Methods.Method {
+			if tc.XMLData.Methods.Method[i].Name == methodName {
+				methodToExecute = &tc.XMLData.Methods.Method[i]
+				break
+			}
 		}
 	}
-	
+
 	if methodToExecute == nil {
 		return fmt.Errorf("method %s not found", methodName)
 	}
-	
-	// Execute each step in the method
-	var ifConditionResult bool
-	var inIfBlock bool
-	var inThenBlock bool
-	var inElseBlock bool
-	
-	for _, step := range methodToExecute.Steps {
-		if execute, ok := step.(xml.StartElement); ok {
-			op := OperationType(execute.Name.Local)
-			
-			// Handle IF/THEN/ELSE blocks
-			if inIfBlock {
-				if op == OpThen {
-					inThenBlock = true
-					inElseBlock = false
-					continue
-				} else if op == OpElse {
-					inThenBlock = false
-					inElseBlock = true
-					continue
-				} else if op != OpExecute && op != OpEval && op != OpCompare && op != OpIf {
-					// End of IF block
-					inIfBlock = false
-					inThenBlock = false
-					inElseBlock = false
-				}
+
+	if tc.Tracer == nil {
+		return tc.executeStatements(methodToExecute.Statements, methodName)
+	}
+
+	tc.trace(TraceEvent{Kind: TraceCall, Source: methodName})
+	caller := tc.traceMethod
+	tc.traceMethod = methodName
+	tc.traceDepth++
+
+	err := tc.executeStatements(methodToExecute.Statements, methodName)
+
+	tc.traceMethod = caller
+	tc.traceDepth--
+	tc.trace(TraceEvent{Kind: TraceReturn, Source: methodName})
+	return err
+}
+
+// executeStatements runs a block of statements, descending into the THEN or
+// ELSE block of every IF it meets
+func (tc *TaxCalculator) executeStatements(statements []Statement, methodName string) error {
+	for _, statement := range statements {
+		if _, ok := statement.(*BausteinFinishStatement); !ok {
+			if err := tc.guard.step(methodName, statement); err != nil {
+				return err
 			}
-			
-			// Skip operations in the wrong branch of an IF
-			if inIfBlock && ((inThenBlock && !ifConditionResult) || (inElseBlock && ifConditionResult)) {
-				continue
+		}
+
+		switch s := statement.(type) {
+		case *ExecuteStatement:
+			if err := tc.guard.enter(methodName, s); err != nil {
+				return err
 			}
-			
-			// Process operations
-			switch op {
-			case OpExecute:
-				// Call another method
-				methodToCall := ""
-				for _, attr := range execute.Attr {
-					if attr.Name.Local == "method" {
-						methodToCall = attr.Value
-						break
-					}
-				}
-				if methodToCall != "" {
-					if err := tc.executeMethod(methodToCall); err != nil {
-						return err
-					}
-				}
-				
-			case OpEval:
-				// Evaluate an expression
-				var target string
-				var left, right string
-				var op EvalOperator
-				
-				for _, attr := range execute.Attr {
-					switch attr.Name.Local {
-					case "target":
-						target = attr.Value
-					case "op":
-						op = EvalOperator(attr.Value)
-					case "left":
-						left = attr.Value
-					case "right":
-						right = attr.Value
-					}
-				}
-				
-				if target != "" {
-					// Evaluate the expression
-					result, err := tc.evaluateExpression(left, right, op)
-					if err != nil {
-						return fmt.Errorf("evaluation error in method %s: %w", methodName, err)
-					}
-					
-					// Store the result in the appropriate location (input, output, or internal)
-					tc.setVariableValue(target, result)
-				}
-				
-			case OpIf:
-				// Start of an IF block
-				inIfBlock = true
-				inThenBlock = false
-				inElseBlock = false
-				
-				var left, right string
-				var op ComparisonOperator
-				
-				for _, attr := range execute.Attr {
-					switch attr.Name.Local {
-					case "left":
-						left = attr.Value
-					case "right":
-						right = attr.Value
-					case "op":
-						op = ComparisonOperator(attr.Value)
-					}
-				}
-				
-				// Evaluate the condition
-				result, err := tc.evaluateComparison(left, right, op)
+			err := tc.executeMethod(s.Method)
+			tc.guard.leave()
+			if err != nil {
+				return callError(s.Method, err)
+			}
+
+		case *EvalStatement:
+			if s.Assignment != nil {
+				value, err := tc.evalExpr(s.Assignment.Value)
 				if err != nil {
-					return fmt.Errorf("if condition error in method %s: %w", methodName, err)
+					return fmt.Errorf("evaluation error in method %s: failed to evaluate %q: %w", methodName, s.Exec, err)
 				}
-				ifConditionResult = result
-				
-			case OpCompare:
-				// Compare two values and store the result
-				var target string
-				var left, right string
-				var op ComparisonOperator
-				
-				for _, attr := range execute.Attr {
-					switch attr.Name.Local {
-					case "target":
-						target = attr.Value
-					case "left":
-						left = attr.Value
-					case "right":
-						right = attr.Value
-					case "op":
-						op = ComparisonOperator(attr.Value)
-					}
+				if tc.Tracer != nil {
+					tc.trace(TraceEvent{Kind: TraceEval, Source: s.Exec, Value: value})
 				}
-				
-				if target != "" {
-					// Evaluate the comparison
-					result, err := tc.evaluateComparison(left, right, op)
-					if err != nil {
-						return fmt.Errorf("comparison error in method %s: %w", methodName, err)
-					}
-					
-					// Store the result
-					tc.setVariableValue(target, result)
+				if err := tc.assign(s.Assignment.Target, value); err != nil {
+					return fmt.Errorf("evaluation error in method %s: %w", methodName, err)
 				}
-				
-			case OpBausteinFinish:
-				// End of a building block, nothing to do here
 				continue
 			}
+
+			result, err := tc.evaluateExpression(s.Left, s.Right, s.Op)
+			if err != nil {
+				return fmt.Errorf("evaluation error in method %s: %w", methodName, err)
+			}
+			if tc.Tracer != nil {
+				tc.trace(TraceEvent{Kind: TraceEval, Source: fmt.Sprintf("%s = %s %s %s", s.Target, s.Left, s.Op, s.Right), Value: result})
+			}
+			tc.setVariableValue(s.Target, result)
+
+		case *IfStatement:
+			var result bool
+			var err error
+			if s.Condition != nil {
+				result, err = tc.evaluateConditionExpr(s.Condition)
+			} else {
+				result, err = tc.evaluateComparison(s.Left, s.Right, s.Op)
+			}
+			if err != nil {
+				return fmt.Errorf("if condition error in method %s: %w", methodName, err)
+			}
+			if tc.Tracer != nil {
+				source := s.Expr
+				if s.Condition == nil {
+					source = fmt.Sprintf("%s %s %s", s.Left, s.Op, s.Right)
+				}
+				tc.trace(TraceEvent{Kind: TraceBranch, Source: source, Value: result})
+			}
+
+			branch := s.Else
+			if result {
+				branch = s.Then
+			}
+			if err := tc.executeStatements(branch, methodName); err != nil {
+				return err
+			}
+
+		case *CompareStatement:
+			result, err := tc.evaluateComparison(s.Left, s.Right, s.Op)
+			if err != nil {
+				return fmt.Errorf("comparison error in method %s: %w", methodName, err)
+			}
+			tc.setVariableValue(s.Target, result)
+
+		case *BausteinFinishStatement:
+			// End of a building block, nothing to do here
 		}
 	}
-	
+
 	return nil
 }
 
@@ -491,27 +360,54 @@ func (tc *TaxCalculator) getVariableValue(name string) (interface{}, error) {
 		return val, nil
 	}
 
+	if val, ok := literalValue(name); ok {
+		return val, nil
+	}
+
+	return nil, fmt.Errorf("variable %s not found", name)
+}
+
+// literalValue reads an operand of a legacy element that is a literal
+// instead of a variable name
+func literalValue(name string) (interface{}, bool) {
 	// If it's a number literal, try to parse it
 	if _, err := strconv.Atoi(name); err == nil {
-		return parseValue("int", name), nil
+		return parseValue("int", name), true
 	}
 
 	if strings.Contains(name, ".") {
 		if _, err := strconv.ParseFloat(name, 64); err == nil {
-			return parseValue("double", name), nil
+			return parseValue("double", name), true
 		}
 	}
 
 	// If it's "true" or "false"
 	if name == "true" || name == "false" {
-		return parseValue("boolean", name), nil
+		return parseValue("boolean", name), true
 	}
 
-	return nil, fmt.Errorf("variable %s not found", name)
+	return nil, false
 }
 
 func (tc *TaxCalculator) setVariableValue(name string, value interface{}) {
+	if tc.Tracer != nil {
+		before, _ := tc.getVariableValue(name)
+		defer tc.trace(TraceEvent{Kind: TraceWrite, Variable: name, Before: before, After: value})
+	}
+
 	// Set in the appropriate variable map based on the variable name
+	if tc.program != nil {
+		switch tc.program.kinds[name] {
+		case inputVariable:
+			tc.InputValues[name] = value
+		case outputVariable:
+			tc.OutputValues[name] = value
+		default:
+			tc.InternalVars[name] = value
+		}
+		return
+	}
+
 	for _, input := range tc.XMLData.Variables.Inputs.Input {
 		if input.Name == name {
 			tc.InputValues[name] = value
@@ -541,8 +437,13 @@ func (tc *TaxCalculator) evaluateComparison(left, right string, op ComparisonOpe
 		return false, fmt.Errorf("right operand error: %w", err)
 	}
 
+	return compareValues(leftVal, rightVal, op)
+}
+
+// compareValues compares two operands of a COMPARE or legacy IF element
+func compareValues(leftVal, rightVal interface{}, op ComparisonOperator) (bool, error) {
 	// Convert to comparable types
-	leftNum, rightNum, err := tc.convertToComparableNumbers(leftVal, rightVal)
+	leftNum, rightNum, err := compatibleNumbers(leftVal, rightVal)
 	if err != nil {
 		// Try boolean comparison
 		leftBool, okLeft := leftVal.(bool)
@@ -602,123 +503,195 @@ func (tc *TaxCalculator) evaluateExpression(left, right string, op EvalOperator)
 		return nil, fmt.Errorf("right operand error: %w", err)
 	}
 
+	return applyOperation(leftVal, rightVal, op)
+}
+
+// applyOperation runs the operator of a legacy EVAL element
+func applyOperation(leftVal, rightVal interface{}, op EvalOperator) (interface{}, error) {
+	// Both ints follow Java int arithmetic, which truncates towards zero
+	leftInt, okLeft := leftVal.(int)
+	rightInt, okRight := rightVal.(int)
+	if okLeft && okRight {
+		switch op {
+		case EvalAdd:
+			return intOp("+", leftInt, rightInt)
+		case EvalSubtract:
+			return intOp("-", leftInt, rightInt)
+		case EvalMultiply:
+			return intOp("*", leftInt, rightInt)
+		case EvalDivide, EvalIntDivide:
+			return intOp("/", leftInt, rightInt)
+		case EvalModulo:
+			return intOp("%", leftInt, rightInt)
+		default:
+			return nil, fmt.Errorf("unknown operation: %s", op)
+		}
+	}
+
 	// Convert to compatible numeric types
-	leftNum, rightNum, err := tc.convertToCompatibleNumbers(leftVal, rightVal)
+	leftNum, rightNum, err := compatibleNumbers(leftVal, rightVal)
 	if err != nil {
 		return nil, err
 	}
 
 	// Perform the operation
-	result := new(big.Rat)
 	switch op {
 	case EvalAdd:
-		result.Add(leftNum, rightNum)
+		return leftNum.Add(rightNum), nil
 	case EvalSubtract:
-		result.Sub(leftNum, rightNum)
+		return leftNum.Sub(rightNum), nil
 	case EvalMultiply:
-		result.Mul(leftNum, rightNum)
+		return leftNum.Mul(rightNum), nil
 	case EvalDivide:
-		if rightNum.Cmp(big.NewRat(0, 1)) == 0 {
-			return nil, fmt.Errorf("division by zero")
-		}
-		result.Quo(leftNum, rightNum)
+		return leftNum.Div(rightNum)
 	case EvalIntDivide:
-		// Integer division
-		if rightNum.Cmp(big.NewRat(0, 1)) == 0 {
+		if rightNum.Sign() == 0 {
 			return nil, fmt.Errorf("division by zero")
 		}
-		// Get the integer quotient
-		quo := new(big.Rat).Quo(leftNum, rightNum)
-		f, _ := quo.Float64()
-		intResult := int(f)
-		return intResult, nil
+		quotient, err := leftNum.DivScale(rightNum, 0, RoundDown)
+		if err != nil {
+			return nil, err
+		}
+		return quotient.IntValue(), nil
 	case EvalModulo:
-		// Modulo operation
-		if rightNum.Cmp(big.NewRat(0, 1)) == 0 {
+		if rightNum.Sign() == 0 {
 			return nil, fmt.Errorf("modulo by zero")
 		}
-		// Get the remainder of integer division
-		leftF, _ := leftNum.Float64()
-		rightF, _ := rightNum.Float64()
-		intResult := int(leftF) % int(rightF)
-		return intResult, nil
+		return leftNum.Remainder(rightNum)
 	default:
 		return nil, fmt.Errorf("unknown operation: %s", op)
 	}
+}
 
-	// For integer operations, return an int if both inputs were ints
-	if _, okLeft := leftVal.(int); okLeft {
-		if _, okRight := rightVal.(int); okRight {
-			f, _ := result.Float64()
-			return int(f), nil
-		}
-	}
+func (tc *TaxCalculator) convertToCompatibleNumbers(left, right interface{}) (Decimal, Decimal, error) {
+	return compatibleNumbers(left, right)
+}
 
-	return result, nil
+func (tc *TaxCalculator) convertToComparableNumbers(left, right interface{}) (Decimal, Decimal, error) {
+	return compatibleNumbers(left, right)
 }
 
-func (tc *TaxCalculator) convertToCompatibleNumbers(left, right interface{}) (*big.Rat, *big.Rat, error) {
-	leftRat := new(big.Rat)
-	rightRat := new(big.Rat)
+func compatibleNumbers(left, right interface{}) (Decimal, Decimal, error) {
+	leftNum, err := toNumber(left)
+	if err != nil {
+		return Decimal{}, Decimal{}, fmt.Errorf("left operand is not a number: %T", left)
+	}
 
-	// Convert left value
-	switch v := left.(type) {
-	case int:
-		leftRat.SetInt64(int64(v))
-	case *big.Rat:
-		leftRat.Set(v)
-	case bool:
-		if v {
-			leftRat.SetInt64(1)
-		} else {
-			leftRat.SetInt64(0)
-		}
-	default:
-		return nil, nil, fmt.Errorf("left operand is not a number: %T", left)
-	}
-
-	// Convert right value
-	switch v := right.(type) {
-	case int:
-		rightRat.SetInt64(int64(v))
-	case *big.Rat:
-		rightRat.Set(v)
-	case bool:
-		if v {
-			rightRat.SetInt64(1)
-		} else {
-			rightRat.SetInt64(0)
-		}
-	default:
-		return nil, nil, fmt.Errorf("right operand is not a number: %T", right)
+	rightNum, err := toNumber(right)
+	if err != nil {
+		return Decimal{}, Decimal{}, fmt.Errorf("right operand is not a number: %T", right)
 	}
 
-	return leftRat, rightRat, nil
+	return leftNum, rightNum, nil
 }
 
-func (tc *TaxCalculator) convertToComparableNumbers(left, right interface{}) (*big.Rat, *big.Rat, error) {
-	return tc.convertToCompatibleNumbers(left, right)
+// toNumber is toDecimal with booleans counted as 0 and 1
+func toNumber(value interface{}) (Decimal, error) {
+	if b, ok := value.(bool); ok {
+		if b {
+			return DecimalOne, nil
+		}
+		return DecimalZero, nil
+	}
+	return toDecimal(value)
 }
 
 func parseValue(valueType string, value string) interface{} {
 	switch valueType {
 	case "int":
-		if strings.Contains(value, "default") {
-			return 0 // Default value handling
+		if intVal, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return intVal
+		}
+		if result, err := evaluateConstantExpression(value); err == nil {
+			if intVal, ok := result.(int); ok {
+				return intVal
+			}
+		}
+		return 0
+	case "double":
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+			return f
+		}
+		if result, err := evaluateConstantExpression(value); err == nil {
+			if f, err := toFloat(result); err == nil {
+				return f
+			}
+		}
+		return 0.0
+	case "BigDecimal":
+		if d, err := ParseDecimal(value); err == nil {
+			return d
 		}
-		intVal, _ := strconv.Atoi(value)
-		return intVal
-	case "double", "BigDecimal":
-		if strings.Contains(value, "BigDecimal") || strings.Contains(value, "ZERO") {
-			return big.NewRat(0, 1) // Default to 0 for expressions
+		// Values such as BigDecimal.ZERO or new BigDecimal(12096)
+		if result, err := evaluateConstantExpression(value); err == nil {
+			if d, err := toDecimal(result); err == nil {
+				return d
+			}
 		}
-		r := new(big.Rat)
-		r.SetString(value)
-		return r
+		return DecimalZero
 	case "boolean":
 		boolVal, _ := strconv.ParseBool(value)
 		return boolVal
+	case "BigDecimal[]", "int[]":
+		// Tables such as {BigDecimal.valueOf (0.0), BigDecimal.valueOf (0.4)}
+		if result, err := evaluateConstantExpression(value); err == nil {
+			if array, ok := result.([]interface{}); ok {
+				return array
+			}
+		}
+		return value
 	default:
 		return value
 	}
-}
\ No newline at end of file
+}
+
+// initialValue is the declared default or, like an uninitialised Java field,
+// the zero value of the type
+func initialValue(valueType string, def string) interface{} {
+	if def != "" {
+		return parseValue(valueType, def)
+	}
+
+	switch valueType {
+	case "int":
+		return 0
+	case "double":
+		return 0.0
+	case "BigDecimal":
+		return DecimalZero
+	case "boolean":
+		return false
+	}
+	return nil
+}
+
+// evaluateConstantExpression evaluates a default or constant value that is
+// written as an expression without variables
+func evaluateConstantExpression(src string) (interface{}, error) {
+	expr, err := ParseExpression(src)
+	if err != nil {
+		return nil, err
+	}
+	return (&TaxCalculator{}).evalExpr(expr)
+}
+
+// convertValue brings a value set from Go code to the declared PAP type
+func convertValue(valueType string, value interface{}) (interface{}, error) {
+	switch valueType {
+	case "double":
+		return toFloat(value)
+	case "BigDecimal":
+		return toDecimal(value)
+	case "int":
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case float64:
+			return int(v), nil
+		case Decimal:
+			return v.IntValue(), nil
+		}
+		return nil, fmt.Errorf("cannot convert %T to int", value)
+	}
+	return value, nil
+}
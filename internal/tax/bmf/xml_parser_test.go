@@ -1,7 +1,6 @@
 package bmf
 
 import (
-	"math/big"
 	"testing"
 )
 
@@ -22,7 +21,7 @@ func TestParseValue(t *testing.T) {
 			name:      "parse BigDecimal",
 			valueType: "BigDecimal",
 			value:     "123.45",
-			expected:  big.NewRat(12345, 100),
+			expected:  MustParseDecimal("123.45"),
 		},
 		{
 			name:      "parse boolean true",
@@ -58,7 +57,7 @@ func TestParseValue(t *testing.T) {
 			name:      "parse invalid BigDecimal",
 			valueType: "BigDecimal",
 			value:     "invalid",
-			expected:  big.NewRat(0, 1),
+			expected:  DecimalZero,
 		},
 		{
 			name:      "parse invalid boolean",
@@ -85,19 +84,39 @@ func TestParseValue(t *testing.T) {
 				if result != expected {
 					t.Errorf("Expected %q, got %v", expected, result)
 				}
-			case *big.Rat:
-				if rat, ok := result.(*big.Rat); ok {
-					if rat.Cmp(expected) != 0 {
-						t.Errorf("Expected %v, got %v", expected, rat)
+			case Decimal:
+				if d, ok := result.(Decimal); ok {
+					if d.Cmp(expected) != 0 {
+						t.Errorf("Expected %v, got %v", expected, d)
 					}
 				} else {
-					t.Errorf("Expected *big.Rat, got %T", result)
+					t.Errorf("Expected Decimal, got %T", result)
 				}
 			}
 		})
 	}
 }
 
+func TestParseValueArray(t *testing.T) {
+	result := parseValue("BigDecimal[]", "{BigDecimal.valueOf (0.0), BigDecimal.valueOf (0.4), new BigDecimal(3000)}")
+
+	array, ok := result.([]interface{})
+	if !ok {
+		t.Fatalf("Expected []interface{}, got %T", result)
+	}
+
+	expected := []string{"0.0", "0.4", "3000"}
+	if len(array) != len(expected) {
+		t.Fatalf("Expected %d elements, got %d", len(expected), len(array))
+	}
+
+	for i, value := range array {
+		if d, ok := value.(Decimal); !ok || d.String() != expected[i] {
+			t.Errorf("Element %d: expected %s, got %v", i, expected[i], value)
+		}
+	}
+}
+
 func TestNewTaxCalculator(t *testing.T) {
 	papData := &PAPData{
 		Variables: PAPVariables{
@@ -224,7 +243,7 @@ func TestTaxCalculatorGetVariableValue(t *testing.T) {
 		{
 			name:     "get float literal",
 			variable: "123.45",
-			expected: big.NewRat(12345, 100),
+			expected: 123.45,
 			hasError: false,
 		},
 		{
@@ -253,13 +272,18 @@ func TestTaxCalculatorGetVariableValue(t *testing.T) {
 					if result != expected {
 						t.Errorf("Expected %d, got %v", expected, result)
 					}
-				case *big.Rat:
-					if rat, ok := result.(*big.Rat); ok {
-						if rat.Cmp(expected) != 0 {
-							t.Errorf("Expected %v, got %v", expected, rat)
+				case float64:
+					// Java reads such literals as double
+					if result != expected {
+						t.Errorf("Expected %v, got %v (%T)", expected, result, result)
+					}
+				case Decimal:
+					if d, ok := result.(Decimal); ok {
+						if d.Cmp(expected) != 0 {
+							t.Errorf("Expected %v, got %v", expected, d)
 						}
 					} else {
-						t.Errorf("Expected *big.Rat, got %T", result)
+						t.Errorf("Expected Decimal, got %T", result)
 					}
 				}
 			}
@@ -327,21 +351,21 @@ func TestTaxCalculatorConvertToCompatibleNumbers(t *testing.T) {
 			expectErr: false,
 		},
 		{
-			name:      "int and big.Rat",
+			name:      "int and Decimal",
 			left:      100,
-			right:     big.NewRat(200, 1),
+			right:     DecimalFromInt(200),
 			expectErr: false,
 		},
 		{
-			name:      "big.Rat and int",
-			left:      big.NewRat(100, 1),
+			name:      "Decimal and int",
+			left:      DecimalFromInt(100),
 			right:     200,
 			expectErr: false,
 		},
 		{
-			name:      "big.Rat and big.Rat",
-			left:      big.NewRat(100, 1),
-			right:     big.NewRat(200, 1),
+			name:      "Decimal and Decimal",
+			left:      DecimalFromInt(100),
+			right:     DecimalFromInt(200),
 			expectErr: false,
 		},
 		{
@@ -360,7 +384,7 @@ func TestTaxCalculatorConvertToCompatibleNumbers(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			leftRat, rightRat, err := calculator.convertToCompatibleNumbers(tt.left, tt.right)
+			leftNum, rightNum, err := calculator.convertToCompatibleNumbers(tt.left, tt.right)
 
 			if tt.expectErr {
 				if err == nil {
@@ -371,8 +395,8 @@ func TestTaxCalculatorConvertToCompatibleNumbers(t *testing.T) {
 					t.Errorf("Expected no error but got: %v", err)
 				}
 
-				if leftRat == nil || rightRat == nil {
-					t.Error("Expected non-nil rational numbers")
+				if leftNum.Cmp(rightNum) >= 0 {
+					t.Errorf("Expected %v to be less than %v", leftNum, rightNum)
 				}
 			}
 		})
@@ -598,13 +622,18 @@ func TestTaxCalculatorEvaluateExpression(t *testing.T) {
 					if result != expected {
 						t.Errorf("Expected %d, got %v", expected, result)
 					}
-				case *big.Rat:
-					if rat, ok := result.(*big.Rat); ok {
-						if rat.Cmp(expected) != 0 {
-							t.Errorf("Expected %v, got %v", expected, rat)
+				case float64:
+					// Java reads such literals as double
+					if result != expected {
+						t.Errorf("Expected %v, got %v (%T)", expected, result, result)
+					}
+				case Decimal:
+					if d, ok := result.(Decimal); ok {
+						if d.Cmp(expected) != 0 {
+							t.Errorf("Expected %v, got %v", expected, d)
 						}
 					} else {
-						t.Errorf("Expected *big.Rat, got %T", result)
+						t.Errorf("Expected Decimal, got %T", result)
 					}
 				}
 			}
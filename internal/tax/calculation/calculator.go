@@ -0,0 +1,139 @@
+package calculation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+)
+
+// Calculator is an engine that turns a tax request into a BMF response
+type Calculator interface {
+	Calculate(req models.TaxRequest) (*bmf.TaxCalculationResponse, error)
+}
+
+// APICalculator asks the BMF web service
+type APICalculator struct {
+	client *bmf.Client
+}
+
+func NewAPICalculator() *APICalculator {
+	return NewAPICalculatorWithClient(bmf.DefaultClient)
+}
+
+// NewAPICalculatorWithClient asks the BMF through the given client, e.g. one
+// pointed at a test server
+func NewAPICalculatorWithClient(client *bmf.Client) *APICalculator {
+	return &APICalculator{client: client}
+}
+
+func (c *APICalculator) Calculate(req models.TaxRequest) (*bmf.TaxCalculationResponse, error) {
+	if c.client == nil {
+		return bmf.CalculateTax(req)
+	}
+	return c.client.CalculateTax(context.Background(), req)
+}
+
+// Calculate runs the embedded PAP, initializing the calculator on first use
+func (l *LocalTaxCalculator) Calculate(req models.TaxRequest) (*bmf.TaxCalculationResponse, error) {
+	if !l.IsInitialized() {
+		if err := l.Initialize(); err != nil {
+			return nil, fmt.Errorf("failed to initialize local calculator: %w", err)
+		}
+	}
+	return l.CalculateTax(req)
+}
+
+// CachedCalculator remembers the responses of another calculator. Failed
+// calculations are not cached.
+type CachedCalculator struct {
+	next      Calculator
+	mu        sync.RWMutex
+	responses map[models.TaxRequest]*bmf.TaxCalculationResponse
+}
+
+func NewCachedCalculator(next Calculator) *CachedCalculator {
+	return &CachedCalculator{
+		next:      next,
+		responses: make(map[models.TaxRequest]*bmf.TaxCalculationResponse),
+	}
+}
+
+func (c *CachedCalculator) Calculate(req models.TaxRequest) (*bmf.TaxCalculationResponse, error) {
+	c.mu.RLock()
+	response, ok := c.responses[req]
+	c.mu.RUnlock()
+	if ok {
+		return response, nil
+	}
+
+	response, err := c.next.Calculate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.responses[req] = response
+	c.mu.Unlock()
+
+	return response, nil
+}
+
+// Len returns the number of cached responses
+func (c *CachedCalculator) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.responses)
+}
+
+// FakeCalculator is a stand-in engine for tests. It charges flat rates on
+// the income and any Sonstige Bezüge, or returns Err if set, and records the
+// requests it got.
+type FakeCalculator struct {
+	IncomeTaxRate  float64
+	SolidarityRate float64
+	Err            error
+
+	mu       sync.Mutex
+	requests []models.TaxRequest
+}
+
+func (f *FakeCalculator) Calculate(req models.TaxRequest) (*bmf.TaxCalculationResponse, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	incomeTax := int(float64(req.Income) * f.IncomeTaxRate)
+	solidarityTax := int(float64(req.Income) * f.SolidarityRate)
+
+	response := &bmf.TaxCalculationResponse{
+		Year:        fmt.Sprintf("%d", req.Year),
+		Information: "Fake calculation",
+		Outputs: bmf.Outputs{
+			Output: []bmf.Output{
+				{Name: "LSTLZZ", Value: fmt.Sprintf("%d", incomeTax), Type: "STANDARD"},
+				{Name: "SOLZLZZ", Value: fmt.Sprintf("%d", solidarityTax), Type: "STANDARD"},
+			},
+		},
+	}
+	if req.SONSTB > 0 {
+		response.Outputs.Output = append(response.Outputs.Output,
+			bmf.Output{Name: "STS", Value: fmt.Sprintf("%d", int(float64(req.SONSTB)*f.IncomeTaxRate)), Type: "STANDARD"},
+			bmf.Output{Name: "SOLZS", Value: fmt.Sprintf("%d", int(float64(req.SONSTB)*f.SolidarityRate)), Type: "STANDARD"},
+		)
+	}
+	return response, nil
+}
+
+// Requests returns the requests calculated so far
+func (f *FakeCalculator) Requests() []models.TaxRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.TaxRequest(nil), f.requests...)
+}
@@ -0,0 +1,185 @@
+package calculation
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+)
+
+func TestFakeCalculator(t *testing.T) {
+	fake := &FakeCalculator{IncomeTaxRate: 0.2, SolidarityRate: 0.01}
+
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+	response, err := fake.Calculate(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	result := NewTaxService().GetTaxSummary(response, 50000)
+	if result.IncomeTax != 10000 || result.SolidarityTax != 500 {
+		t.Errorf("Expected 10000 income tax and 500 solidarity tax, got %f and %f", result.IncomeTax, result.SolidarityTax)
+	}
+
+	fake.Err = errors.New("fake failure")
+	if _, err := fake.Calculate(req); err != fake.Err {
+		t.Errorf("Expected the configured error, got: %v", err)
+	}
+
+	if requests := fake.Requests(); len(requests) != 2 || requests[0] != req {
+		t.Errorf("Expected both requests to be recorded, got %v", requests)
+	}
+}
+
+func TestCachedCalculator(t *testing.T) {
+	fake := &FakeCalculator{IncomeTaxRate: 0.2}
+	cached := NewCachedCalculator(fake)
+
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+	first, err := cached.Calculate(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	second, err := cached.Calculate(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if first != second {
+		t.Error("Expected the cached response to be returned")
+	}
+	if calls := len(fake.Requests()); calls != 1 {
+		t.Errorf("Expected 1 call to the wrapped calculator, got %d", calls)
+	}
+
+	req.Year = 2024
+	if _, err := cached.Calculate(req); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if cached.Len() != 2 {
+		t.Errorf("Expected a separate entry per request, got %d", cached.Len())
+	}
+
+	fake.Err = errors.New("fake failure")
+	req.Income = 100
+	if _, err := cached.Calculate(req); err == nil {
+		t.Error("Expected the wrapped error")
+	}
+	if cached.Len() != 2 {
+		t.Errorf("Expected failures not to be cached, got %d entries", cached.Len())
+	}
+}
+
+func TestAPICalculatorWithClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<lohnsteuer jahr="2025"><ausgaben><ausgabe name="LSTLZZ" value="100" type="STANDARD"/></ausgaben></lohnsteuer>`))
+	}))
+	defer server.Close()
+
+	calculator := NewAPICalculatorWithClient(bmf.NewClient(bmf.WithBaseURL(server.URL)))
+	response, err := calculator.Calculate(models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if response.Year != "2025" {
+		t.Errorf("Expected year 2025, got %q", response.Year)
+	}
+}
+
+func TestLocalTaxCalculatorCalculate(t *testing.T) {
+	var calculator Calculator = GetLocalTaxCalculator()
+
+	response, err := calculator.Calculate(models.TaxRequest{
+		Period:   models.Year,
+		Income:   5000000,
+		TaxClass: models.TaxClass1,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if response.Year != "2025" {
+		t.Errorf("Expected year 2025, got %q", response.Year)
+	}
+}
+
+func TestTaxServiceWithCalculators(t *testing.T) {
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+
+	t.Run("remote engine", func(t *testing.T) {
+		remote := &FakeCalculator{IncomeTaxRate: 0.2}
+		local := &FakeCalculator{IncomeTaxRate: 0.1}
+		service := NewTaxService(WithRemoteCalculator(remote), WithLocalCalculator(local))
+
+		result, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result.IncomeTax != 10000 {
+			t.Errorf("Expected the remote engine's 10000, got %f", result.IncomeTax)
+		}
+		if len(local.Requests()) != 0 {
+			t.Error("Expected the local engine not to be used")
+		}
+	})
+
+	t.Run("fallback to local engine", func(t *testing.T) {
+		remote := &FakeCalculator{Err: errors.New("offline")}
+		local := &FakeCalculator{IncomeTaxRate: 0.1}
+		service := NewTaxService(WithRemoteCalculator(remote), WithLocalCalculator(local))
+
+		result, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result.IncomeTax != 5000 {
+			t.Errorf("Expected the local engine's 5000, got %f", result.IncomeTax)
+		}
+	})
+
+	t.Run("both engines fail", func(t *testing.T) {
+		localErr := errors.New("broken PAP")
+		service := NewTaxService(
+			WithRemoteCalculator(&FakeCalculator{Err: errors.New("offline")}),
+			WithLocalCalculator(&FakeCalculator{Err: localErr}),
+		)
+
+		if _, err := service.CalculateTax(req); !errors.Is(err, localErr) {
+			t.Errorf("Expected the local error to be wrapped, got: %v", err)
+		}
+	})
+
+	t.Run("local engine enabled", func(t *testing.T) {
+		remote := &FakeCalculator{IncomeTaxRate: 0.2}
+		local := &FakeCalculator{IncomeTaxRate: 0.1}
+		service := NewTaxService(WithRemoteCalculator(remote), WithLocalCalculator(local))
+		service.EnableLocalCalculator()
+
+		response, provenance, err := service.Calculate(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if response.Information != "Fake calculation" || len(remote.Requests()) != 0 {
+			t.Error("Expected only the local engine to be used")
+		}
+		if provenance.Engine != models.EngineLocal {
+			t.Errorf("Expected the local engine in the provenance, got %q", provenance.Engine)
+		}
+	})
+
+	t.Run("unsupported year", func(t *testing.T) {
+		remote := &FakeCalculator{IncomeTaxRate: 0.2}
+		service := NewTaxService(WithRemoteCalculator(remote))
+
+		unsupported := req
+		unsupported.Year = 1999
+		if _, _, err := service.Calculate(unsupported); !errors.Is(err, bmf.ErrUnsupportedYear) {
+			t.Errorf("Expected ErrUnsupportedYear, got: %v", err)
+		}
+		if len(remote.Requests()) != 0 {
+			t.Error("Expected no engine to be asked for an unsupported year")
+		}
+	})
+}
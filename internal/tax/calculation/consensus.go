@@ -0,0 +1,131 @@
+package calculation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+)
+
+// WithConsensus makes the service run every request through both engines and
+// record where they disagree
+func WithConsensus() Option {
+	return func(s *TaxService) {
+		s.consensus = true
+	}
+}
+
+func (s *TaxService) EnableConsensus() {
+	s.consensus = true
+}
+
+func (s *TaxService) DisableConsensus() {
+	s.consensus = false
+}
+
+func (s *TaxService) ConsensusEnabled() bool {
+	return s.consensus
+}
+
+// CompareOutputs lists the output fields on which the API and local
+// responses differ, sorted by name. Values are in cents.
+func CompareOutputs(api, local *bmf.TaxCalculationResponse) []models.Discrepancy {
+	apiValues := outputValues(api)
+	localValues := outputValues(local)
+
+	names := make(map[string]bool, len(apiValues))
+	for name := range apiValues {
+		names[name] = true
+	}
+	for name := range localValues {
+		names[name] = true
+	}
+
+	var discrepancies []models.Discrepancy
+	for name := range names {
+		apiValue, localValue := apiValues[name], localValues[name]
+		apiCents, apiErr := parseCents(apiValue)
+		localCents, localErr := parseCents(localValue)
+
+		if apiErr != nil || localErr != nil {
+			// Not a number on one side, so only equality can be checked
+			if apiValue != localValue {
+				discrepancies = append(discrepancies, models.Discrepancy{Field: name, API: apiValue, Local: localValue})
+			}
+			continue
+		}
+
+		if apiCents != localCents || (apiValue == "") != (localValue == "") {
+			discrepancies = append(discrepancies, models.Discrepancy{
+				Field: name,
+				API:   apiValue,
+				Local: localValue,
+				Cents: localCents - apiCents,
+			})
+		}
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].Field < discrepancies[j].Field
+	})
+	return discrepancies
+}
+
+func outputValues(response *bmf.TaxCalculationResponse) map[string]string {
+	values := make(map[string]string)
+	if response == nil {
+		return values
+	}
+	for _, output := range response.Outputs.Output {
+		values[output.Name] = strings.TrimSpace(output.Value)
+	}
+	return values
+}
+
+func parseCents(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// calculateConsensus runs both engines at once. The fallback policy still
+// decides which response is returned; the other one is only compared.
+func (s *TaxService) calculateConsensus(req models.TaxRequest, provenance models.Provenance) (*bmf.TaxCalculationResponse, models.Provenance, error) {
+	var (
+		wg        sync.WaitGroup
+		responses [2]*bmf.TaxCalculationResponse
+		errs      [2]error
+	)
+
+	engines := [2]models.Engine{models.EngineAPI, models.EngineLocal}
+	for i, engine := range engines {
+		wg.Add(1)
+		go func(i int, engine models.Engine) {
+			defer wg.Done()
+			responses[i], errs[i] = s.calculator(engine).Calculate(req)
+		}(i, engine)
+	}
+	wg.Wait()
+
+	switch {
+	case errs[0] == nil && errs[1] == nil:
+		provenance.Compared = true
+		provenance.Discrepancies = CompareOutputs(responses[0], responses[1])
+	case errs[0] != nil:
+		provenance.ComparisonError = fmt.Sprintf("%s failed: %v", s.engineName(engines[0]), errs[0])
+	default:
+		provenance.ComparisonError = fmt.Sprintf("%s failed: %v", s.engineName(engines[1]), errs[1])
+	}
+
+	return s.applyPolicy(func(engine models.Engine) (*bmf.TaxCalculationResponse, error) {
+		if engine == engines[0] {
+			return responses[0], errs[0]
+		}
+		return responses[1], errs[1]
+	}, provenance)
+}
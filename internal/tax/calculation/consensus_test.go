@@ -0,0 +1,158 @@
+package calculation
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+)
+
+func responseWith(outputs ...string) *bmf.TaxCalculationResponse {
+	response := &bmf.TaxCalculationResponse{}
+	for i := 0; i+1 < len(outputs); i += 2 {
+		response.Outputs.Output = append(response.Outputs.Output, bmf.Output{Name: outputs[i], Value: outputs[i+1]})
+	}
+	return response
+}
+
+func TestCompareOutputs(t *testing.T) {
+	api := responseWith("LSTLZZ", "702100", "SOLZLZZ", "0", "BK", "0", "VKVLZZ", "12345")
+	local := responseWith("LSTLZZ", "702000", "SOLZLZZ", "0", "BK", "0", "VFRB", "123000")
+
+	discrepancies := CompareOutputs(api, local)
+	expected := []models.Discrepancy{
+		{Field: "LSTLZZ", API: "702100", Local: "702000", Cents: -100},
+		{Field: "VFRB", API: "", Local: "123000", Cents: 123000},
+		{Field: "VKVLZZ", API: "12345", Local: "", Cents: -12345},
+	}
+
+	if len(discrepancies) != len(expected) {
+		t.Fatalf("Expected %d discrepancies, got %v", len(expected), discrepancies)
+	}
+	for i, want := range expected {
+		if discrepancies[i] != want {
+			t.Errorf("Discrepancy %d: expected %+v, got %+v", i, want, discrepancies[i])
+		}
+	}
+
+	if same := CompareOutputs(api, api); len(same) != 0 {
+		t.Errorf("Expected no discrepancies between identical responses, got %v", same)
+	}
+
+	// An empty value and a zero are different answers
+	if zero := CompareOutputs(responseWith("BK", "0"), responseWith()); len(zero) != 1 {
+		t.Errorf("Expected a field missing on one side to be reported, got %v", zero)
+	}
+
+	text := CompareOutputs(responseWith("NOTE", "a"), responseWith("NOTE", "b"))
+	if len(text) != 1 || text[0].Cents != 0 {
+		t.Errorf("Expected a non-numeric mismatch without a cent difference, got %v", text)
+	}
+}
+
+func TestTaxServiceConsensus(t *testing.T) {
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+
+	t.Run("engines agree", func(t *testing.T) {
+		remote := &FakeCalculator{IncomeTaxRate: 0.2}
+		local := &FakeCalculator{IncomeTaxRate: 0.2}
+		service := NewTaxService(WithRemoteCalculator(remote), WithLocalCalculator(local), WithConsensus())
+
+		result, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !result.Provenance.Compared || result.Provenance.Diverged() {
+			t.Errorf("Expected a successful check without differences, got %+v", result.Provenance)
+		}
+		if len(remote.Requests()) != 1 || len(local.Requests()) != 1 {
+			t.Error("Expected both engines to be asked once")
+		}
+	})
+
+	t.Run("engines diverge", func(t *testing.T) {
+		remote := &FakeCalculator{IncomeTaxRate: 0.2}
+		local := &FakeCalculator{IncomeTaxRate: 0.19}
+		service := NewTaxService(WithRemoteCalculator(remote), WithLocalCalculator(local))
+		service.EnableConsensus()
+
+		result, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result.Provenance.Engine != models.EngineAPI || result.IncomeTax != 10000 {
+			t.Errorf("Expected the API figure to be returned, got %s with %f", result.Provenance.Engine, result.IncomeTax)
+		}
+
+		discrepancies := result.Provenance.Discrepancies
+		if len(discrepancies) != 1 || discrepancies[0].Field != "LSTLZZ" || discrepancies[0].Cents != -50000 {
+			t.Errorf("Expected LSTLZZ to differ by -50000 cents, got %+v", discrepancies)
+		}
+	})
+
+	t.Run("one engine fails", func(t *testing.T) {
+		remote := &FakeCalculator{Err: errors.New("offline")}
+		local := &FakeCalculator{IncomeTaxRate: 0.2}
+		service := NewTaxService(WithRemoteCalculator(remote), WithLocalCalculator(local), WithConsensus())
+
+		result, err := service.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected the policy to fall back, got: %v", err)
+		}
+		if result.Provenance.Compared || result.Provenance.ComparisonError == "" {
+			t.Errorf("Expected the check to fail with a reason, got %+v", result.Provenance)
+		}
+		if result.Provenance.Engine != models.EngineLocal || !result.Provenance.Fallback() {
+			t.Errorf("Expected a local fallback, got %+v", result.Provenance)
+		}
+		if len(remote.Requests()) != 1 {
+			t.Error("Expected the failed engine not to be asked again")
+		}
+	})
+
+	t.Run("policy without fallback", func(t *testing.T) {
+		remote := &FakeCalculator{Err: errors.New("offline")}
+		local := &FakeCalculator{IncomeTaxRate: 0.2}
+		service := NewTaxService(
+			WithRemoteCalculator(remote),
+			WithLocalCalculator(local),
+			WithFallbackPolicy(APIOnly),
+			WithConsensus(),
+		)
+
+		if _, err := service.CalculateTax(req); err == nil {
+			t.Error("Expected api-only to fail when the API does")
+		}
+	})
+}
+
+func TestTaxServiceWithMockBMF(t *testing.T) {
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, KVZ: 2.5, Year: 2025}
+
+	newService := func(faults bmf.MockFaults) *TaxService {
+		server := httptest.NewServer(bmf.NewMockServer(bmf.WithFaults(faults)))
+		t.Cleanup(server.Close)
+		client := bmf.NewClient(bmf.WithBaseURL(server.URL+"/interface"), bmf.WithRetryPolicy(bmf.NoRetry))
+		return NewTaxService(WithRemoteCalculator(NewAPICalculatorWithClient(client)), WithConsensus())
+	}
+
+	// The stand-in calculates with the same PAP as the local engine
+	result, err := newService(bmf.MockFaults{}).CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Provenance.Engine != models.EngineAPI || !result.Provenance.Compared || result.Provenance.Diverged() {
+		t.Errorf("Expected the API figure to agree with the local one, got %+v", result.Provenance)
+	}
+
+	// A broken answer of the API falls back to the local PAP
+	result, err = newService(bmf.MockFaults{MalformedRate: 1}).CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected a fallback, got: %v", err)
+	}
+	if result.Provenance.Engine != models.EngineLocal || !result.Provenance.Fallback() || result.IncomeTax == 0 {
+		t.Errorf("Expected a local fallback, got %+v", result)
+	}
+}
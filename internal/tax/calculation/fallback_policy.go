@@ -0,0 +1,55 @@
+package calculation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FallbackPolicy decides which engine a TaxService asks first and whether the
+// other one may step in when it fails
+type FallbackPolicy int
+
+const (
+	APIThenLocal FallbackPolicy = iota
+	APIOnly
+	LocalOnly
+	LocalThenAPI
+)
+
+// FallbackPolicies lists all policies, default first
+var FallbackPolicies = []FallbackPolicy{APIThenLocal, APIOnly, LocalOnly, LocalThenAPI}
+
+var fallbackPolicyNames = map[FallbackPolicy]string{
+	APIThenLocal: "api-then-local",
+	APIOnly:      "api-only",
+	LocalOnly:    "local-only",
+	LocalThenAPI: "local-then-api",
+}
+
+func (p FallbackPolicy) String() string {
+	if name, ok := fallbackPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("FallbackPolicy(%d)", int(p))
+}
+
+// Next returns the policy after p in FallbackPolicies, wrapping around
+func (p FallbackPolicy) Next() FallbackPolicy {
+	for i, policy := range FallbackPolicies {
+		if policy == p {
+			return FallbackPolicies[(i+1)%len(FallbackPolicies)]
+		}
+	}
+	return APIThenLocal
+}
+
+// ParseFallbackPolicy reads a policy name such as "api-only"
+func ParseFallbackPolicy(name string) (FallbackPolicy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, policy := range FallbackPolicies {
+		if fallbackPolicyNames[policy] == name {
+			return policy, nil
+		}
+	}
+	return APIThenLocal, fmt.Errorf("unknown fallback policy %q", name)
+}
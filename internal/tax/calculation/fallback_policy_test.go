@@ -0,0 +1,39 @@
+package calculation
+
+import "testing"
+
+func TestParseFallbackPolicy(t *testing.T) {
+	for _, policy := range FallbackPolicies {
+		parsed, err := ParseFallbackPolicy(policy.String())
+		if err != nil {
+			t.Errorf("%s: expected no error, got: %v", policy, err)
+		}
+		if parsed != policy {
+			t.Errorf("Expected %s, got %s", policy, parsed)
+		}
+	}
+
+	if policy, err := ParseFallbackPolicy(" API-Only "); err != nil || policy != APIOnly {
+		t.Errorf("Expected api-only, got %s (%v)", policy, err)
+	}
+
+	if _, err := ParseFallbackPolicy("api-or-whatever"); err == nil {
+		t.Error("Expected error for an unknown policy")
+	}
+}
+
+func TestFallbackPolicyNext(t *testing.T) {
+	policy := APIThenLocal
+	seen := make(map[FallbackPolicy]bool)
+	for range FallbackPolicies {
+		seen[policy] = true
+		policy = policy.Next()
+	}
+
+	if policy != APIThenLocal {
+		t.Errorf("Expected to wrap around to api-then-local, got %s", policy)
+	}
+	if len(seen) != len(FallbackPolicies) {
+		t.Errorf("Expected to visit every policy, got %v", seen)
+	}
+}
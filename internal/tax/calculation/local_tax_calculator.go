@@ -2,17 +2,24 @@ package calculation
 
 import (
 	"fmt"
-	"math/big"
+	"strconv"
 	"sync"
 	"tax-calculator/internal/tax/bmf"
 	"tax-calculator/internal/tax/models"
 )
 
+// LocalTaxCalculator runs the embedded PAPs. They are compiled once into
+// programs that are shared and never change, and every calculation gets its
+// own evaluation state, so any number of goroutines can calculate at once.
 type LocalTaxCalculator struct {
 	xmlData     *bmf.PAPData
-	calculator  *bmf.TaxCalculator
+	program     *bmf.Program
+	version     bmf.PAPVersion
 	initialized bool
 	mu          sync.RWMutex
+
+	// Programs for PAP versions other than the default one, loaded on first use
+	programs map[string]*bmf.Program
 }
 
 var (
@@ -37,13 +44,25 @@ func (l *LocalTaxCalculator) Initialize() error {
 		return nil
 	}
 
-	xmlData, err := bmf.FetchTaxCalculationXML()
+	version, err := bmf.DefaultPAPRegistry.Version(bmf.DefaultYear)
+	if err != nil {
+		return fmt.Errorf("failed to initialize local tax calculator: %w", err)
+	}
+
+	xmlData, err := bmf.DefaultPAPRegistry.LoadVersion(version)
+	if err != nil {
+		return fmt.Errorf("failed to initialize local tax calculator: %w", err)
+	}
+
+	program, err := compilePAP(xmlData)
 	if err != nil {
 		return fmt.Errorf("failed to initialize local tax calculator: %w", err)
 	}
 
 	l.xmlData = xmlData
-	l.calculator = bmf.NewTaxCalculator(xmlData)
+	l.program = program
+	l.version = version
+	l.programs = make(map[string]*bmf.Program)
 	l.initialized = true
 
 	return nil
@@ -56,81 +75,162 @@ func (l *LocalTaxCalculator) IsInitialized() bool {
 }
 
 func (l *LocalTaxCalculator) CalculateTax(req models.TaxRequest) (*bmf.TaxCalculationResponse, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+	if !l.IsInitialized() {
+		return nil, fmt.Errorf("local tax calculator not initialized")
+	}
+
+	version, err := bmf.ResolvePAPVersion(req)
+	if err != nil {
+		return nil, err
+	}
+	return l.CalculateInputs(version, requestInputs(req))
+}
 
-	if !l.initialized {
+// CalculateInputs runs the PAP of a version on inputs keyed by their PAP
+// names, such as a case of a bmf.TestTable or a recorded request to the BMF
+func (l *LocalTaxCalculator) CalculateInputs(version bmf.PAPVersion, inputs map[string]interface{}) (*bmf.TaxCalculationResponse, error) {
+	if !l.IsInitialized() {
 		return nil, fmt.Errorf("local tax calculator not initialized")
 	}
 
-	l.calculator.SetInputValue("LZZ", int(req.Period))
-	l.calculator.SetInputValue("RE4", req.Income)
-	l.calculator.SetInputValue("STKL", int(req.TaxClass))
-
-	l.calculator.SetInputValue("R", req.R)
-	l.calculator.SetInputValue("AJAHR", req.AJAHR)
-	l.calculator.SetInputValue("ALTER1", req.ALTER1)
-	l.calculator.SetInputValue("KRV", req.KRV)
-	l.calculator.SetInputValue("KVZ", req.KVZ)
-	l.calculator.SetInputValue("PVS", req.PVS)
-	l.calculator.SetInputValue("PVZ", req.PVZ)
-	l.calculator.SetInputValue("PKV", req.PKV)
-	l.calculator.SetInputValue("PVA", req.PVA)
-	l.calculator.SetInputValue("ZKF", req.ZKF)
-	l.calculator.SetInputValue("VBEZ", int(req.VBEZ * 100))
-	l.calculator.SetInputValue("VJAHR", req.VJAHR)
-	l.calculator.SetInputValue("PKPV", int(req.PKPV * 100))
-	
-	l.calculator.SetInputValue("JFREIB", 0)
-	l.calculator.SetInputValue("JHINZU", 0)
-	l.calculator.SetInputValue("JRE4", 0)
-	l.calculator.SetInputValue("JRE4ENT", 0)
-	l.calculator.SetInputValue("JVBEZ", 0)
-	l.calculator.SetInputValue("VBEZM", 0)
-	l.calculator.SetInputValue("VBEZS", 0)
-	l.calculator.SetInputValue("VBS", 0)
-	l.calculator.SetInputValue("VKAPA", 0)
-	l.calculator.SetInputValue("VMT", 0)
-	l.calculator.SetInputValue("ZMVB", 12)
-	l.calculator.SetInputValue("SONSTB", 0)
-	l.calculator.SetInputValue("SONSTENT", 0)
-	l.calculator.SetInputValue("STERBE", 0)
-	l.calculator.SetInputValue("af", 0)
-	l.calculator.SetInputValue("f", 1.0)
-
-	if err := l.calculator.Calculate(); err != nil {
+	program, err := l.programFor(version)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := program.Calculate(inputs)
+	if err != nil {
 		return nil, fmt.Errorf("tax calculation failed: %w", err)
 	}
 
+	return localResponse(version, "Local calculation based on BMF XML", outputNames(program.Data()), outputs), nil
+}
+
+// RunTestTable calculates every case of the test table of a PAP version and
+// compares the outputs with the table
+func (l *LocalTaxCalculator) RunTestTable(version bmf.PAPVersion, table *bmf.TestTable) []bmf.TestResult {
+	return table.Run(func(inputs map[string]interface{}) (map[string]interface{}, error) {
+		response, err := l.CalculateInputs(version, inputs)
+		if err != nil {
+			return nil, err
+		}
+		return response.OutputValues(), nil
+	})
+}
+
+// CalculateTaxWithTrace is CalculateTax recording every step of the PAP, to
+// follow how it arrived at its outputs. It interprets the PAP instead of
+// running compiled code, so it is much slower. A failed calculation still
+// returns the trace up to the failing step.
+func (l *LocalTaxCalculator) CalculateTaxWithTrace(req models.TaxRequest) (*bmf.TaxCalculationResponse, *bmf.Trace, error) {
+	if !l.IsInitialized() {
+		return nil, nil, fmt.Errorf("local tax calculator not initialized")
+	}
+
+	version, err := bmf.ResolvePAPVersion(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	program, err := l.programFor(version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trace := &bmf.Trace{}
+	outputs, err := program.Trace(requestInputs(req), trace)
+	if err != nil {
+		return nil, trace, fmt.Errorf("tax calculation failed: %w", err)
+	}
+
+	return localResponse(version, "Local calculation based on BMF XML", outputNames(program.Data()), outputs), trace, nil
+}
+
+// outputNames lists the outputs of a PAP in the order it declares them
+func outputNames(papData *bmf.PAPData) []string {
+	names := make([]string, 0, len(papData.Variables.Outputs.Output))
+	for _, output := range papData.Variables.Outputs.Output {
+		names = append(names, output.Name)
+	}
+	return names
+}
+
+// requestInputs returns the PAP inputs of a request keyed by name
+func requestInputs(req models.TaxRequest) map[string]interface{} {
+	inputs := make(map[string]interface{})
+	for _, input := range bmf.RequestInputs(req) {
+		inputs[input.Name] = input.Value
+	}
+	return inputs
+}
+
+// localResponse formats the outputs of a local engine like a BMF response,
+// listing them in the order the PAP declares them
+func localResponse(version bmf.PAPVersion, information string, names []string, outputs map[string]interface{}) *bmf.TaxCalculationResponse {
 	response := &bmf.TaxCalculationResponse{
-		Year:        "2025",
-		Information: "Local calculation based on BMF XML",
+		Year:        strconv.Itoa(version.Year),
+		Information: information,
 		Outputs: bmf.Outputs{
 			Output: make([]bmf.Output, 0),
 		},
 	}
 
-	for name, value := range l.calculator.OutputValues {
-		var strValue string
-		switch v := value.(type) {
-		case int:
-			strValue = fmt.Sprintf("%d", v)
-		case *big.Rat:
-			// Convert to cents integer
-			cents := new(big.Rat).Mul(v, big.NewRat(100, 1))
-			// Convert to integer string
-			f, _ := cents.Float64()
-			strValue = fmt.Sprintf("%d", int(f))
-		default:
-			strValue = fmt.Sprintf("%v", v)
+	for _, name := range names {
+		value, ok := outputs[name]
+		if !ok {
+			continue
 		}
-
 		response.Outputs.Output = append(response.Outputs.Output, bmf.Output{
 			Name:  name,
-			Value: strValue,
+			Value: bmf.FormatOutputValue(value),
 			Type:  "BigDecimal",
 		})
 	}
 
-	return response, nil
-}
\ No newline at end of file
+	return response
+}
+
+// programFor returns the program for a PAP version, loading it on first use
+func (l *LocalTaxCalculator) programFor(version bmf.PAPVersion) (*bmf.Program, error) {
+	l.mu.RLock()
+	program := l.programs[version.Version]
+	if version.Version == l.version.Version && l.program != nil {
+		program = l.program
+	}
+	l.mu.RUnlock()
+	if program != nil {
+		return program, nil
+	}
+
+	xmlData, err := bmf.DefaultPAPRegistry.LoadVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PAP %s: %w", version.Version, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Another goroutine may have built it in the meantime
+	if program, ok := l.programs[version.Version]; ok {
+		return program, nil
+	}
+	if l.programs == nil {
+		l.programs = make(map[string]*bmf.Program)
+	}
+	program, err = compilePAP(xmlData)
+	if err != nil {
+		return nil, err
+	}
+	l.programs[version.Version] = program
+	return program, nil
+}
+
+// compilePAP lints a PAP before compiling it, so a broken PAP, e.g. from
+// STEUERGO_PAP_DIR, is refused up front instead of failing or miscalculating
+// when a request takes the broken path
+func compilePAP(xmlData *bmf.PAPData) (*bmf.Program, error) {
+	if err := bmf.CheckPAP(xmlData); err != nil {
+		return nil, err
+	}
+	return bmf.Compile(xmlData)
+}
@@ -1,8 +1,15 @@
 package calculation
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
+	"tax-calculator/internal/tax/bmf"
 	"tax-calculator/internal/tax/models"
 )
 
@@ -72,16 +79,13 @@ func TestLocalTaxCalculatorInitialize(t *testing.T) {
 	calc.mu.Lock()
 	calc.initialized = false
 	calc.xmlData = nil
-	calc.calculator = nil
+	calc.program = nil
 	calc.mu.Unlock()
 
-	// Note: This test will fail if there's no internet connection
-	// In a real test environment, you might want to mock the XML fetching
+	// The PAP is embedded, so this works without a network connection
 	err := calc.Initialize()
 	if err != nil {
-		t.Logf("Initialize failed (possibly due to network): %v", err)
-		// Don't fail the test if it's a network issue
-		return
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
 	}
 
 	if !calc.IsInitialized() {
@@ -92,8 +96,8 @@ func TestLocalTaxCalculatorInitialize(t *testing.T) {
 		t.Error("Expected xmlData to be set after initialization")
 	}
 
-	if calc.calculator == nil {
-		t.Error("Expected calculator to be set after initialization")
+	if calc.program == nil {
+		t.Error("Expected program to be set after initialization")
 	}
 
 	// Test that calling Initialize again doesn't cause issues
@@ -106,7 +110,7 @@ func TestLocalTaxCalculatorInitialize(t *testing.T) {
 func TestLocalTaxCalculatorCalculateTaxBasic(t *testing.T) {
 	calc := GetLocalTaxCalculator()
 
-	// Try to initialize (skip test if it fails due to network)
+	// Try to initialize (skip test if the PAP cannot be loaded)
 	err := calc.Initialize()
 	if err != nil {
 		t.Skipf("Skipping test due to initialization failure: %v", err)
@@ -171,7 +175,7 @@ func TestLocalTaxCalculatorCalculateTaxBasic(t *testing.T) {
 func TestLocalTaxCalculatorCalculateTaxWithAdvancedOptions(t *testing.T) {
 	calc := GetLocalTaxCalculator()
 
-	// Try to initialize (skip test if it fails due to network)
+	// Try to initialize (skip test if the PAP cannot be loaded)
 	err := calc.Initialize()
 	if err != nil {
 		t.Skipf("Skipping test due to initialization failure: %v", err)
@@ -206,10 +210,150 @@ func TestLocalTaxCalculatorCalculateTaxWithAdvancedOptions(t *testing.T) {
 	}
 }
 
+// responseOutputNames lists the outputs of a response in their order
+func responseOutputNames(response *bmf.TaxCalculationResponse) []string {
+	names := make([]string, len(response.Outputs.Output))
+	for i, output := range response.Outputs.Output {
+		names[i] = output.Name
+	}
+	return names
+}
+
+func TestLocalTaxCalculatorOutputOrder(t *testing.T) {
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+
+	req := models.TaxRequest{Period: models.Year, Year: 2025, Income: 5000000, TaxClass: models.TaxClass1}
+	version, err := bmf.ResolvePAPVersion(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	papData, err := bmf.DefaultPAPRegistry.LoadVersion(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := outputNames(papData)
+
+	// The same order on every run, as the PAP and the BMF list them
+	for i := 0; i < 5; i++ {
+		response, err := calc.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if got := responseOutputNames(response); !slices.Equal(got, want) {
+			t.Fatalf("Expected the outputs in the order %v, got %v", want, got)
+		}
+	}
+}
+
+// The Prüftabellen of the BMF embedded for a PAP version must come out of
+// the local engine field by field
+func TestLocalTaxCalculatorTestTables(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+
+	for _, year := range bmf.DefaultPAPRegistry.Years() {
+		for _, version := range bmf.DefaultPAPRegistry.Versions(year) {
+			t.Run(version.Version, func(t *testing.T) {
+				papData, err := bmf.DefaultPAPRegistry.LoadVersion(version)
+				if err != nil {
+					t.Fatal(err)
+				}
+				table, err := bmf.LoadTestTable(version, papData)
+				if errors.Is(err, bmf.ErrNoTestTable) {
+					t.Skipf("No Prüftabelle of the BMF embedded for %s", version.Version)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, result := range calc.RunTestTable(version, table) {
+					if result.Err != nil {
+						t.Errorf("line %d (%s): %v", result.Case.Line, result.Case, result.Err)
+					}
+					for _, mismatch := range result.Mismatches {
+						t.Errorf("line %d (%s): %s", result.Case.Line, result.Case, mismatch)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestLocalTaxCalculatorRunTestTable(t *testing.T) {
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+	version, err := bmf.DefaultPAPRegistry.Resolve(2025, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	papData, err := bmf.DefaultPAPRegistry.LoadVersion(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No tax on no wage, and a wrong expectation
+	src := "LZZ,STKL,RE4,LSTLZZ,SOLZLZZ\n1,1,0,0,0\n1,1,0,1,0\n"
+	table, err := bmf.ParseTestTable(strings.NewReader(src), papData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := calc.RunTestTable(version, table)
+	if len(results) != 2 || !results[0].Passed() {
+		t.Fatalf("Expected the first case to pass, got %+v", results)
+	}
+	if mismatches := results[1].Mismatches; len(mismatches) != 1 || mismatches[0].String() != "LSTLZZ expected 1, got 0" {
+		t.Errorf("Expected a mismatch of LSTLZZ, got %+v", results[1])
+	}
+}
+
+// TestLocalTaxCalculatorRecordedBMF checks the local engine against answers
+// recorded from the BMF interface
+func TestLocalTaxCalculatorRecordedBMF(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+	fixtures, err := bmf.LoadFixtures("../bmf/testdata/bmf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Skip("No answers of the BMF recorded in internal/tax/bmf/testdata/bmf")
+	}
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.URL, func(t *testing.T) {
+			version, inputs, err := fixture.Calculation()
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorded, err := fixture.Response()
+			if err != nil {
+				t.Fatal(err)
+			}
+			local, err := calc.CalculateInputs(version, inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range CompareOutputs(recorded, local) {
+				t.Errorf("%s: BMF %q, local %q (%+d cents)", d.Field, d.API, d.Local, d.Cents)
+			}
+		})
+	}
+}
+
 func TestLocalTaxCalculatorConcurrency(t *testing.T) {
 	calc := GetLocalTaxCalculator()
 
-	// Try to initialize (skip test if it fails due to network)
+	// Try to initialize (skip test if the PAP cannot be loaded)
 	err := calc.Initialize()
 	if err != nil {
 		t.Skipf("Skipping test due to initialization failure: %v", err)
@@ -231,31 +375,55 @@ func TestLocalTaxCalculatorConcurrency(t *testing.T) {
 		<-done
 	}
 
-	// Note: Concurrent calculations are not safe with the current implementation
-	// The underlying tax calculator has shared state that causes race conditions
-	// In a production environment, you would need to either:
-	// 1. Use separate calculator instances per goroutine
-	// 2. Add proper synchronization to the calculator
-	// 3. Use a pool of calculators
+	// Calculations share the program but not their variables, so parallel
+	// callers must get the same results as sequential ones
+	requests := make([]models.TaxRequest, 0, 24)
+	for _, year := range []int{2024, 2025} {
+		for taxClass := models.TaxClass1; taxClass <= models.TaxClass6; taxClass++ {
+			for _, income := range []int{2000000, 6000000} {
+				requests = append(requests, models.TaxRequest{
+					Period:   models.Year,
+					Income:   income,
+					TaxClass: taxClass,
+					Year:     year,
+				})
+			}
+		}
+	}
 
-	// For now, we'll test that the calculator works in a single-threaded context
-	req := models.TaxRequest{
-		Period:   models.Year,
-		Income:   5000000,
-		TaxClass: models.TaxClass1,
+	expected := make([]*bmf.TaxCalculationResponse, len(requests))
+	for i, req := range requests {
+		expected[i], err = calc.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Sequential calculation failed: %v", err)
+		}
 	}
 
-	// Single calculation to verify functionality
-	_, err = calc.CalculateTax(req)
-	if err != nil {
-		t.Errorf("Single calculation failed: %v", err)
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for n := range requests {
+				i := (n + worker*5) % len(requests)
+				response, err := calc.CalculateTax(requests[i])
+				if err != nil {
+					t.Errorf("Concurrent calculation failed: %v", err)
+					return
+				}
+				if diff := CompareOutputs(expected[i], response); len(diff) != 0 {
+					t.Errorf("Expected the sequential result for request %d, got differences %v", i, diff)
+				}
+			}
+		}(worker)
 	}
+	wg.Wait()
 }
 
 func TestLocalTaxCalculatorDifferentTaxClasses(t *testing.T) {
 	calc := GetLocalTaxCalculator()
 
-	// Try to initialize (skip test if it fails due to network)
+	// Try to initialize (skip test if the PAP cannot be loaded)
 	err := calc.Initialize()
 	if err != nil {
 		t.Skipf("Skipping test due to initialization failure: %v", err)
@@ -291,7 +459,7 @@ func TestLocalTaxCalculatorDifferentTaxClasses(t *testing.T) {
 func TestLocalTaxCalculatorDifferentPeriods(t *testing.T) {
 	calc := GetLocalTaxCalculator()
 
-	// Try to initialize (skip test if it fails due to network)
+	// Try to initialize (skip test if the PAP cannot be loaded)
 	err := calc.Initialize()
 	if err != nil {
 		t.Skipf("Skipping test due to initialization failure: %v", err)
@@ -321,3 +489,247 @@ func TestLocalTaxCalculatorDifferentPeriods(t *testing.T) {
 		}
 	}
 }
+
+// The Versorgungsfreibetrag and Altersentlastungsbetrag come from the TAB1 to
+// TAB5 tables of the PAP, indexed by the first pension year and the year
+// after the 64th birthday
+func TestLocalTaxCalculatorPensionTables(t *testing.T) {
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+
+	outputs := func(req models.TaxRequest) map[string]string {
+		t.Helper()
+		response, err := calc.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		values := make(map[string]string)
+		for _, output := range response.Outputs.Output {
+			values[output.Name] = output.Value
+		}
+		return values
+	}
+
+	req := models.TaxRequest{Period: models.Year, Year: 2025, Income: 2400000, TaxClass: models.TaxClass1}
+
+	// 1,000 euros a month since 2019: 17.6% capped at TAB2[15] = 1,320 euros,
+	// plus the TAB3[15] surcharge of 396 euros and 102 + 1,230 euros lump sums
+	pension := req
+	pension.VBEZ, pension.VBEZM, pension.VJAHR, pension.ZMVB = 12000, 100000, 2019, 12
+	if got := outputs(pension)["VFRB"]; got != "304800" {
+		t.Errorf("Expected VFRB 304800 for a pension since 2019, got %s", got)
+	}
+
+	// 64 before 2020: 16% of the wage capped at TAB5[16] = 760 euros
+	base, _ := strconv.Atoi(outputs(req)["WVFRB"])
+	older := req
+	older.ALTER1, older.AJAHR = 1, 2020
+	relieved, _ := strconv.Atoi(outputs(older)["WVFRB"])
+	if base-relieved != 76000 {
+		t.Errorf("Expected the Altersentlastungsbetrag to lower WVFRB by 76000, got %d to %d", base, relieved)
+	}
+}
+
+func TestLocalTaxCalculatorSonstigeBezuege(t *testing.T) {
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+
+	outputs := func(req models.TaxRequest) models.TaxOutputs {
+		t.Helper()
+		response, err := calc.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		values, err := response.TaxOutputs()
+		if err != nil {
+			t.Fatalf("Expected valid outputs, got: %v", err)
+		}
+		return values
+	}
+
+	for _, year := range []int{2024, 2025} {
+		for _, tc := range []struct {
+			taxClass models.TaxClass
+			wage     int
+			bonus    int
+		}{
+			{models.TaxClass1, 4000000, 300000},
+			{models.TaxClass1, 9000000, 2000000},
+			{models.TaxClass3, 5000000, 500000},
+			{models.TaxClass6, 2000000, 150000},
+		} {
+			name := fmt.Sprintf("%d class %d %d+%d", year, tc.taxClass, tc.wage, tc.bonus)
+			req := models.TaxRequest{Period: models.Year, Year: year, Income: tc.wage, TaxClass: tc.taxClass, KVZ: 2.5}
+
+			// The Jahresarbeitslohn method: the tax on the annual wage with
+			// the payment, less the tax on the annual wage without it
+			without := outputs(req)
+			with := req
+			with.Income += tc.bonus
+			want := outputs(with).LSTLZZ - without.LSTLZZ
+
+			bonus := req
+			bonus.JRE4, bonus.SONSTB = tc.wage, tc.bonus
+			got := outputs(bonus)
+			if want <= 0 {
+				t.Fatalf("%s: expected the payment to raise the annual tax, got %d", name, want)
+			}
+			if got.STS != want {
+				t.Errorf("%s: expected STS %d, got %d", name, want, got.STS)
+			}
+			if got.LSTLZZ != without.LSTLZZ {
+				t.Errorf("%s: expected the payment to leave LSTLZZ at %d, got %d", name, without.LSTLZZ, got.LSTLZZ)
+			}
+		}
+	}
+}
+
+func TestLocalTaxCalculatorCalculateTaxYears(t *testing.T) {
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+
+	incomeTax := func(req models.TaxRequest) string {
+		t.Helper()
+		response, err := calc.CalculateTax(req)
+		if err != nil {
+			t.Fatalf("Expected no error for %d %s, got: %v", req.Year, req.PAPVersion, err)
+		}
+		if want := fmt.Sprint(req.Year); response.Year != want {
+			t.Errorf("Expected year %s, got %q", want, response.Year)
+		}
+		for _, output := range response.Outputs.Output {
+			if output.Name == "LSTLZZ" {
+				return output.Value
+			}
+		}
+		t.Fatal("Expected LSTLZZ in the response")
+		return ""
+	}
+
+	req := models.TaxRequest{
+		Period:   models.Year,
+		Income:   5000000,
+		TaxClass: models.TaxClass1,
+		KVZ:      2.5,
+	}
+
+	req.Year = 2024
+	tax2024 := incomeTax(req)
+	req.Year = 2025
+	tax2025 := incomeTax(req)
+	if tax2024 == tax2025 {
+		t.Errorf("Expected different income tax for 2024 and 2025, got %s for both", tax2024)
+	}
+
+	// The December 2024 PAP raised the basic allowance for the whole year
+	req.Year = 2024
+	req.PAPVersion = "2024Version1"
+	taxVersion1 := incomeTax(req)
+	if taxVersion1 == tax2024 {
+		t.Errorf("Expected 2024Version2 to differ from 2024Version1, got %s for both", tax2024)
+	}
+	if bmf.MustParseInt(taxVersion1) <= bmf.MustParseInt(tax2024) {
+		t.Errorf("Expected less income tax with the December 2024 PAP, got %s vs %s", tax2024, taxVersion1)
+	}
+
+	req.Year = 1999
+	req.PAPVersion = ""
+	if _, err := calc.CalculateTax(req); !errors.Is(err, bmf.ErrUnsupportedYear) {
+		t.Errorf("Expected ErrUnsupportedYear, got: %v", err)
+	}
+}
+
+func TestLocalTaxCalculatorCalculateTaxWithTrace(t *testing.T) {
+	calc := GetLocalTaxCalculator()
+	if err := calc.Initialize(); err != nil {
+		t.Skipf("Skipping test due to initialization failure: %v", err)
+	}
+
+	req := models.TaxRequest{
+		Period:   models.Year,
+		Income:   5000000,
+		TaxClass: models.TaxClass1,
+		KVZ:      1.3,
+		Year:     2025,
+	}
+
+	want, err := calc.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("CalculateTax: %v", err)
+	}
+
+	response, trace, err := calc.CalculateTaxWithTrace(req)
+	if err != nil {
+		t.Fatalf("CalculateTaxWithTrace: %v", err)
+	}
+
+	// The traced interpreter agrees with the compiled program
+	if len(response.Outputs.Output) != len(want.Outputs.Output) {
+		t.Fatalf("Expected %d outputs, got %d", len(want.Outputs.Output), len(response.Outputs.Output))
+	}
+	got := make(map[string]string)
+	for _, output := range response.Outputs.Output {
+		got[output.Name] = output.Value
+	}
+	for _, output := range want.Outputs.Output {
+		if got[output.Name] != output.Value {
+			t.Errorf("Expected %s = %s, got %s", output.Name, output.Value, got[output.Name])
+		}
+	}
+
+	if trace.Len() == 0 || trace.Events[0].Kind != bmf.TraceStart {
+		t.Fatal("Expected the trace to begin with the start event")
+	}
+
+	// The last write of LSTLZZ is the output
+	variables := trace.Variables(trace.Len() - 1)
+	for _, output := range response.Outputs.Output {
+		if output.Name == "LSTLZZ" && bmf.FormatTraceValue(variables["LSTLZZ"]) != output.Value {
+			t.Errorf("Expected traced LSTLZZ %s, got %s", output.Value, bmf.FormatTraceValue(variables["LSTLZZ"]))
+		}
+	}
+
+	if step := trace.NextBreak(0, []string{"MPARA"}); step < 0 {
+		t.Error("Expected a call of MPARA in the trace")
+	}
+}
+
+func TestLocalTaxCalculatorCalculateTaxWithTraceNotInitialized(t *testing.T) {
+	calc := &LocalTaxCalculator{}
+
+	_, trace, err := calc.CalculateTaxWithTrace(models.TaxRequest{Year: 2025})
+	if err == nil {
+		t.Error("Expected an error from an uninitialized calculator")
+	}
+	if trace != nil {
+		t.Error("Expected no trace from an uninitialized calculator")
+	}
+}
+
+func TestCompilePAPRefusesLintErrors(t *testing.T) {
+	papData, err := bmf.ParsePAP([]byte(`<PAP name="Broken">
+		<VARIABLES><OUTPUTS><OUTPUT name="LSTLZZ" type="BigDecimal"/></OUTPUTS></VARIABLES>
+		<CONSTANTS/>
+		<METHODS><MAIN><EVAL exec="LSTLZZ = WVFRB"/></MAIN></METHODS>
+	</PAP>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The PAP compiles, but reading WVFRB would only fail during a calculation
+	if _, err := bmf.Compile(papData); err != nil {
+		t.Fatalf("Expected the PAP to compile, got: %v", err)
+	}
+
+	_, err = compilePAP(papData)
+	var failure *bmf.LintFailure
+	if !errors.As(err, &failure) {
+		t.Fatalf("Expected a lint failure, got: %v", err)
+	}
+}
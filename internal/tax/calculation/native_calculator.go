@@ -0,0 +1,43 @@
+package calculation
+
+import (
+	"fmt"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+	"tax-calculator/internal/tax/native"
+)
+
+// NativeCalculator runs the embedded PAPs as Go code generated by papgen.
+// It follows the PAPs as they were when the code was generated, so a PAP
+// override in STEUERGO_PAP_DIR does not apply to it. It needs no
+// initialization and any number of goroutines can use it at once.
+type NativeCalculator struct{}
+
+func NewNativeCalculator() *NativeCalculator {
+	return &NativeCalculator{}
+}
+
+func (n *NativeCalculator) Calculate(req models.TaxRequest) (*bmf.TaxCalculationResponse, error) {
+	version, err := bmf.ResolvePAPVersion(req)
+	if err != nil {
+		return nil, err
+	}
+
+	calculate, ok := native.Lookup(version.Version)
+	if !ok {
+		return nil, fmt.Errorf("no generated code for PAP %s", version.Version)
+	}
+
+	outputs, err := calculate(requestInputs(req))
+	if err != nil {
+		return nil, fmt.Errorf("tax calculation failed: %w", err)
+	}
+
+	return localResponse(version, "Local calculation based on generated Go code", native.OutputNames(version.Version), outputs), nil
+}
+
+// Engine tells the provenance that the figures come from generated code
+func (n *NativeCalculator) Engine() models.Engine {
+	return models.EngineNative
+}
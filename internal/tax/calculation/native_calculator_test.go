@@ -0,0 +1,68 @@
+package calculation
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+)
+
+func TestNativeCalculatorMatchesLocalCalculator(t *testing.T) {
+	local := GetLocalTaxCalculator()
+	if err := local.Initialize(); err != nil {
+		t.Fatalf("Expected no error from Initialize, got: %v", err)
+	}
+	nativeCalc := NewNativeCalculator()
+
+	requests := []models.TaxRequest{
+		{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, KVZ: 2.5},
+		{Period: models.Month, Income: 420000, TaxClass: models.TaxClass3, R: 1, ZKF: 1, Year: 2024},
+		{Period: models.Year, Income: 3000000, TaxClass: models.TaxClass1, Year: 2024, PAPVersion: "2024Version1"},
+		{Period: models.Month, Income: 350000, TaxClass: models.TaxClass1, JRE4: 4200000, SONSTB: 350000, Year: 2025},
+		{Period: models.Year, Income: 2400000, TaxClass: models.TaxClass1, JRE4: 2400000, SONSTB: 500000, STERBE: 200000, VBEZ: 12000, VBEZM: 100000, VJAHR: 2019, JVBEZ: 1200000, Year: 2024},
+	}
+	for _, req := range requests {
+		want, err := local.Calculate(req)
+		if err != nil {
+			t.Fatalf("Expected no error from the local calculator, got: %v", err)
+		}
+		got, err := nativeCalc.Calculate(req)
+		if err != nil {
+			t.Fatalf("Expected no error from the native calculator, got: %v", err)
+		}
+		if got.Year != want.Year {
+			t.Errorf("Expected year %s, got %s", want.Year, got.Year)
+		}
+		if diff := CompareOutputs(want, got); len(diff) != 0 {
+			t.Errorf("Expected the generated code to match the PAP for %+v, got differences %v", req, diff)
+		}
+		if gotNames, wantNames := responseOutputNames(got), responseOutputNames(want); !slices.Equal(gotNames, wantNames) {
+			t.Errorf("Expected the outputs in the order %v, got %v", wantNames, gotNames)
+		}
+	}
+
+	if _, err := nativeCalc.Calculate(models.TaxRequest{Year: 1999}); !errors.Is(err, bmf.ErrUnsupportedYear) {
+		t.Errorf("Expected ErrUnsupportedYear, got: %v", err)
+	}
+}
+
+func TestTaxServiceWithNativeCalculator(t *testing.T) {
+	remote := &FakeCalculator{Err: errors.New("offline")}
+	service := NewTaxService(WithRemoteCalculator(remote), WithNativeCalculator())
+
+	result, err := service.CalculateTax(models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.Provenance.Engine != models.EngineNative {
+		t.Errorf("Expected the generated code to be named in the provenance, got %q", result.Provenance.Engine)
+	}
+	if result.Provenance.FallbackReason != "BMF API failed: offline" {
+		t.Errorf("Expected a fallback from the API, got %q", result.Provenance.FallbackReason)
+	}
+	if result.IncomeTax <= 0 {
+		t.Errorf("Expected income tax, got %.2f", result.IncomeTax)
+	}
+}
@@ -1,73 +1,217 @@
 package calculation
 
 import (
+	"errors"
 	"fmt"
+	"time"
+
 	"tax-calculator/internal/tax/bmf"
 	"tax-calculator/internal/tax/models"
 )
 
-type TaxService struct{
+type TaxService struct {
 	useLocalCalculator bool
+	noFallback         bool
+	consensus          bool
+	remote             Calculator
+	local              Calculator
+}
+
+// Option configures a TaxService
+type Option func(*TaxService)
+
+// WithRemoteCalculator replaces the BMF API as the default engine
+func WithRemoteCalculator(calculator Calculator) Option {
+	return func(s *TaxService) {
+		s.remote = calculator
+	}
+}
+
+// WithLocalCalculator replaces the embedded PAP as the local engine
+func WithLocalCalculator(calculator Calculator) Option {
+	return func(s *TaxService) {
+		s.local = calculator
+	}
+}
+
+// WithNativeCalculator makes the Go code generated from the PAPs the local
+// engine instead of the embedded PAP
+func WithNativeCalculator() Option {
+	return WithLocalCalculator(NewNativeCalculator())
 }
 
-func NewTaxService() *TaxService {
-	return &TaxService{
+// WithFallbackPolicy sets the policy instead of the default api-then-local
+func WithFallbackPolicy(policy FallbackPolicy) Option {
+	return func(s *TaxService) {
+		s.SetFallbackPolicy(policy)
+	}
+}
+
+func NewTaxService(opts ...Option) *TaxService {
+	s := &TaxService{
 		useLocalCalculator: false,
+		remote:             NewAPICalculator(),
+		local:              GetLocalTaxCalculator(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
+// EnableLocalCalculator switches to the local-only policy
 func (s *TaxService) EnableLocalCalculator() {
-	s.useLocalCalculator = true
+	s.SetFallbackPolicy(LocalOnly)
 }
 
+// DisableLocalCalculator switches back to the default api-then-local policy
 func (s *TaxService) DisableLocalCalculator() {
-	s.useLocalCalculator = false
+	s.SetFallbackPolicy(APIThenLocal)
 }
 
-func (s *TaxService) CalculateTax(req models.TaxRequest) (models.TaxResult, error) {
-	var response *bmf.TaxCalculationResponse
-	var err error
-	
+func (s *TaxService) SetFallbackPolicy(policy FallbackPolicy) {
+	s.useLocalCalculator = policy == LocalOnly || policy == LocalThenAPI
+	s.noFallback = policy == APIOnly || policy == LocalOnly
+}
+
+func (s *TaxService) FallbackPolicy() FallbackPolicy {
+	switch {
+	case s.useLocalCalculator && s.noFallback:
+		return LocalOnly
+	case s.useLocalCalculator:
+		return LocalThenAPI
+	case s.noFallback:
+		return APIOnly
+	default:
+		return APIThenLocal
+	}
+}
+
+// Calculate returns the raw BMF response for a request and where it comes
+// from. The fallback policy picks the engine to ask first and whether the
+// other one takes over if it fails.
+func (s *TaxService) Calculate(req models.TaxRequest) (*bmf.TaxCalculationResponse, models.Provenance, error) {
+	// An unsupported year is the caller's mistake, not something to fall back from
+	version, err := bmf.ResolvePAPVersion(req)
+	if err != nil {
+		return nil, models.Provenance{}, err
+	}
+
+	provenance := models.Provenance{
+		PAPVersion:   version.Version,
+		CalculatedAt: time.Now(),
+	}
+
+	if s.consensus {
+		return s.calculateConsensus(req, provenance)
+	}
+
+	return s.applyPolicy(func(engine models.Engine) (*bmf.TaxCalculationResponse, error) {
+		return s.calculator(engine).Calculate(req)
+	}, provenance)
+}
+
+// applyPolicy asks the engines in the order of the fallback policy, getting
+// each engine's result from run
+func (s *TaxService) applyPolicy(run func(models.Engine) (*bmf.TaxCalculationResponse, error), provenance models.Provenance) (*bmf.TaxCalculationResponse, models.Provenance, error) {
+	primary, secondary := models.EngineAPI, models.EngineLocal
 	if s.useLocalCalculator {
-		localCalc := GetLocalTaxCalculator()
-		
-		if !localCalc.IsInitialized() {
-			if err := localCalc.Initialize(); err != nil {
-				return models.TaxResult{
-					Income: float64(req.Income) / 100,
-					Error:  fmt.Errorf("failed to initialize local calculator: %w", err),
-				}, err
-			}
-		}
-		
-		response, err = localCalc.CalculateTax(req)
-	} else {
-		response, err = bmf.CalculateTax(req)
-		
-		if err != nil {
-			localCalc := GetLocalTaxCalculator()
-			
-			if !localCalc.IsInitialized() {
-				if initErr := localCalc.Initialize(); initErr != nil {
-					return models.TaxResult{
-						Income: float64(req.Income) / 100,
-						Error:  fmt.Errorf("API error: %v, local calculator error: %w", err, initErr),
-					}, err
-				}
-			}
-			
-			response, err = localCalc.CalculateTax(req)
-		}
+		primary, secondary = secondary, primary
+	}
+
+	provenance.Engine = s.engineName(primary)
+	response, err := run(primary)
+	if err == nil {
+		return response, provenance, nil
+	}
+	// Invalid inputs are the caller's mistake too; the other engine would
+	// only calculate with them. A replayed API without the fixture of a
+	// request is a broken test, which a fallback would hide, and so is a
+	// client told to both record and replay.
+	var invalid models.ValidationErrors
+	if s.noFallback || errors.As(err, &invalid) || errors.Is(err, bmf.ErrNoFixture) || errors.Is(err, bmf.ErrRecordAndReplay) {
+		return nil, provenance, err
+	}
+
+	provenance.Engine = s.engineName(secondary)
+	provenance.FallbackReason = fmt.Sprintf("%s failed: %v", s.engineName(primary), err)
+
+	response, fallbackErr := run(secondary)
+	if fallbackErr != nil {
+		return nil, provenance, fmt.Errorf("%s error: %v, %s error: %w", s.engineName(primary), err, provenance.Engine, fallbackErr)
 	}
+	return response, provenance, nil
+}
 
+func (s *TaxService) calculator(engine models.Engine) Calculator {
+	if engine == models.EngineLocal {
+		return s.localCalculator()
+	}
+	return s.remoteCalculator()
+}
+
+// engineName is what the provenance calls an engine. A calculator can name
+// itself, like the generated code standing in for the local PAP.
+func (s *TaxService) engineName(engine models.Engine) models.Engine {
+	if named, ok := s.calculator(engine).(interface{ Engine() models.Engine }); ok {
+		return named.Engine()
+	}
+	return engine
+}
+
+// The engines default as in NewTaxService, so a zero TaxService works too
+func (s *TaxService) remoteCalculator() Calculator {
+	if s.remote == nil {
+		return NewAPICalculator()
+	}
+	return s.remote
+}
+
+func (s *TaxService) localCalculator() Calculator {
+	if s.local == nil {
+		return GetLocalTaxCalculator()
+	}
+	return s.local
+}
+
+func (s *TaxService) CalculateTax(req models.TaxRequest) (models.TaxResult, error) {
+	response, provenance, err := s.Calculate(req)
 	if err != nil {
-		return models.TaxResult{
-			Income: float64(req.Income) / 100,
-			Error:  err,
-		}, err
+		result := models.TaxResult{
+			Income:     float64(req.Income) / 100,
+			Provenance: provenance,
+			Error:      err,
+		}
+		errors.As(err, &result.ValidationErrors)
+		return result, err
 	}
 
-	return s.GetTaxSummary(response, float64(req.Income)/100), nil
+	result := s.Summarize(response, req)
+	result.Provenance = provenance
+	return result, nil
+}
+
+// Summarize is GetTaxSummary for the request a response answers, including
+// the Sonstige Bezüge paid with it
+func (s *TaxService) Summarize(response *bmf.TaxCalculationResponse, req models.TaxRequest) models.TaxResult {
+	result := s.GetTaxSummary(response, float64(req.Income)/100)
+	if result.Error == nil {
+		addBonus(&result, float64(req.SONSTB)/100)
+	}
+	return result
+}
+
+// addBonus adds Sonstige Bezüge to a summary of regular pay: the payment
+// and the tax the PAP charges on it on top of the tax on the annual wage
+func addBonus(result *models.TaxResult, bonus float64) {
+	result.Bonus = bonus
+	result.BonusIncomeTax = float64(result.Outputs.STS) / 100
+	result.BonusSolidarityTax = float64(result.Outputs.SOLZS) / 100
+	result.TotalTax += result.BonusIncomeTax + result.BonusSolidarityTax
+	result.NetIncome = result.Income + bonus - result.TotalTax
+	if gross := result.Income + bonus; gross > 0 {
+		result.TaxRate = (result.TotalTax / gross) * 100
+	}
 }
 
 func (s *TaxService) GetTaxSummary(response *bmf.TaxCalculationResponse, income float64) models.TaxResult {
@@ -79,17 +223,15 @@ func (s *TaxService) GetTaxSummary(response *bmf.TaxCalculationResponse, income
 		result.Error = fmt.Errorf("no response data")
 		return result
 	}
-	var incomeTax, solidarityTax string
-	for _, output := range response.Outputs.Output {
-		if output.Name == "LSTLZZ" {
-			incomeTax = output.Value
-		} else if output.Name == "SOLZLZZ" {
-			solidarityTax = output.Value
-		}
+	outputs, err := response.TaxOutputs()
+	if err != nil {
+		result.Error = err
+		return result
 	}
 
-	result.IncomeTax = float64(bmf.MustParseInt(incomeTax)) / 100
-	result.SolidarityTax = float64(bmf.MustParseInt(solidarityTax)) / 100
+	result.Outputs = outputs
+	result.IncomeTax = float64(outputs.LSTLZZ) / 100
+	result.SolidarityTax = float64(outputs.SOLZLZZ) / 100
 	result.TotalTax = result.IncomeTax + result.SolidarityTax
 	result.NetIncome = income - result.TotalTax
 	if income > 0 {
@@ -100,18 +242,13 @@ func (s *TaxService) GetTaxSummary(response *bmf.TaxCalculationResponse, income
 }
 
 func (s *TaxService) GetReadableTaxSummary(response *bmf.TaxCalculationResponse) string {
-	var incomeTax, solidarityTax string
-
-	for _, output := range response.Outputs.Output {
-		if output.Name == "LSTLZZ" {
-			incomeTax = output.Value
-		} else if output.Name == "SOLZLZZ" {
-			solidarityTax = output.Value
-		}
+	outputs, err := response.TaxOutputs()
+	if err != nil {
+		return fmt.Sprintf("Tax Summary for %s: %v", response.Year, err)
 	}
 
-	incomeTaxEuros := float64(bmf.MustParseInt(incomeTax)) / 100
-	solidarityTaxEuros := float64(bmf.MustParseInt(solidarityTax)) / 100
+	incomeTaxEuros := float64(outputs.LSTLZZ) / 100
+	solidarityTaxEuros := float64(outputs.SOLZLZZ) / 100
 	totalTax := incomeTaxEuros + solidarityTaxEuros
 
 	return fmt.Sprintf("Tax Summary for %s:\n"+
@@ -122,6 +259,11 @@ func (s *TaxService) GetReadableTaxSummary(response *bmf.TaxCalculationResponse)
 }
 
 func (s *TaxService) CalculateComparisonTaxes(taxClass models.TaxClass, baseIncome float64) []models.TaxResult {
+	return s.CalculateComparisonTaxesForYear(taxClass, baseIncome, 0)
+}
+
+// CalculateComparisonTaxesForYear is CalculateComparisonTaxes for a given tax year
+func (s *TaxService) CalculateComparisonTaxesForYear(taxClass models.TaxClass, baseIncome float64, year int) []models.TaxResult {
 	var results []models.TaxResult
 	
 	halfIncome := baseIncome / 2
@@ -148,6 +290,7 @@ func (s *TaxService) CalculateComparisonTaxes(taxClass models.TaxClass, baseInco
 			Period:   models.Year,
 			Income:   incomeInCents, 
 			TaxClass: taxClass,
+			Year:     year,
 		}
 		
 		result, err := s.CalculateTax(taxRequest)
@@ -125,6 +125,15 @@ func TestGetTaxSummary(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name:     "Malformed output",
+			response: mockTaxResponse("8000.00", "0"),
+			income:   50000.0,
+			expected: models.TaxResult{
+				Income: 50000.0,
+			},
+			expectError: true,
+		},
 		{
 			name:     "Zero income",
 			response: mockTaxResponse("0", "0"),
@@ -183,6 +192,27 @@ func TestGetTaxSummary(t *testing.T) {
 	}
 }
 
+func TestGetTaxSummaryOutputs(t *testing.T) {
+	response := mockTaxResponse("800000", "40000")
+	response.Outputs.Output = append(response.Outputs.Output,
+		bmf.Output{Name: "BK", Value: "750000", Type: "STANDARD"},
+		bmf.Output{Name: "STS", Value: "120000", Type: "STANDARD"},
+		bmf.Output{Name: "WVFRB", Value: "2500", Type: "DBA"},
+	)
+
+	result := NewTaxService().GetTaxSummary(response, 50000)
+	if result.Error != nil {
+		t.Fatalf("Expected no error, got: %v", result.Error)
+	}
+	outputs := result.Outputs
+	if outputs.LSTLZZ != 800000 || outputs.SOLZLZZ != 40000 || outputs.BK != 750000 || outputs.STS != 120000 || outputs.WVFRB != 2500 {
+		t.Errorf("Expected every output on the result, got %+v", outputs)
+	}
+	if outputs.Has("STV") {
+		t.Error("Expected STV to be missing from a 2025 response")
+	}
+}
+
 func TestGetReadableTaxSummary(t *testing.T) {
 	service := NewTaxService()
 	response := mockTaxResponse("800000", "40000")
@@ -631,3 +661,202 @@ func (m *MockLocalCalculator) CalculateTax(req models.TaxRequest) (*bmf.TaxCalcu
 	}
 	return m.mockResponse, nil
 }
+
+func TestCalculateTaxUnsupportedYear(t *testing.T) {
+	service := NewTaxService()
+	service.EnableLocalCalculator()
+
+	req := models.TaxRequest{
+		Period:   models.Year,
+		Income:   5000000,
+		TaxClass: models.TaxClass1,
+		Year:     1999,
+	}
+
+	result, err := service.CalculateTax(req)
+	if !errors.Is(err, bmf.ErrUnsupportedYear) {
+		t.Fatalf("Expected ErrUnsupportedYear, got: %v", err)
+	}
+	if !errors.Is(result.Error, bmf.ErrUnsupportedYear) {
+		t.Errorf("Expected the result to carry the error, got: %v", result.Error)
+	}
+	if result.Income != 50000.0 {
+		t.Errorf("Expected income 50000.0, got %f", result.Income)
+	}
+}
+
+func TestCalculateTaxFallbackPolicies(t *testing.T) {
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+
+	tests := []struct {
+		policy       FallbackPolicy
+		remoteFails  bool
+		localFails   bool
+		wantEngine   models.Engine
+		wantFallback bool
+		wantErr      bool
+	}{
+		{APIThenLocal, false, false, models.EngineAPI, false, false},
+		{APIThenLocal, true, false, models.EngineLocal, true, false},
+		{APIThenLocal, true, true, models.EngineLocal, true, true},
+		{APIOnly, false, false, models.EngineAPI, false, false},
+		{APIOnly, true, false, models.EngineAPI, false, true},
+		{LocalOnly, false, false, models.EngineLocal, false, false},
+		{LocalOnly, false, true, models.EngineLocal, false, true},
+		{LocalThenAPI, false, false, models.EngineLocal, false, false},
+		{LocalThenAPI, false, true, models.EngineAPI, true, false},
+	}
+
+	for _, tc := range tests {
+		remote := &FakeCalculator{IncomeTaxRate: 0.2}
+		local := &FakeCalculator{IncomeTaxRate: 0.1}
+		if tc.remoteFails {
+			remote.Err = errors.New("connection refused")
+		}
+		if tc.localFails {
+			local.Err = errors.New("broken PAP")
+		}
+
+		service := NewTaxService(
+			WithRemoteCalculator(remote),
+			WithLocalCalculator(local),
+			WithFallbackPolicy(tc.policy),
+		)
+		if service.FallbackPolicy() != tc.policy {
+			t.Errorf("Expected policy %s, got %s", tc.policy, service.FallbackPolicy())
+		}
+
+		result, err := service.CalculateTax(req)
+		name := fmt.Sprintf("%s (remote fails: %v, local fails: %v)", tc.policy, tc.remoteFails, tc.localFails)
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: unexpected error state: %v", name, err)
+		}
+		if result.Provenance.Engine != tc.wantEngine {
+			t.Errorf("%s: expected engine %q, got %q", name, tc.wantEngine, result.Provenance.Engine)
+		}
+		if result.Provenance.Fallback() != tc.wantFallback {
+			t.Errorf("%s: expected fallback %v, got reason %q", name, tc.wantFallback, result.Provenance.FallbackReason)
+		}
+		if result.Provenance.PAPVersion != "2025Version1" {
+			t.Errorf("%s: expected PAP version 2025Version1, got %q", name, result.Provenance.PAPVersion)
+		}
+		if !tc.wantErr && result.Provenance.CalculatedAt.IsZero() {
+			t.Errorf("%s: expected a calculation time", name)
+		}
+	}
+}
+
+func TestEnableLocalCalculatorPolicy(t *testing.T) {
+	service := NewTaxService()
+	if service.FallbackPolicy() != APIThenLocal {
+		t.Errorf("Expected api-then-local by default, got %s", service.FallbackPolicy())
+	}
+
+	service.EnableLocalCalculator()
+	if service.FallbackPolicy() != LocalOnly {
+		t.Errorf("Expected local-only after EnableLocalCalculator, got %s", service.FallbackPolicy())
+	}
+
+	service.DisableLocalCalculator()
+	if service.FallbackPolicy() != APIThenLocal {
+		t.Errorf("Expected api-then-local after DisableLocalCalculator, got %s", service.FallbackPolicy())
+	}
+}
+
+func TestCalculateTaxValidationErrors(t *testing.T) {
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, KRV: 7}
+	invalid := models.ValidationErrors{{Field: "KRV", Value: "7", Message: "Eingabe ist nicht zulässig"}}
+
+	remote := &FakeCalculator{Err: invalid}
+	local := &FakeCalculator{IncomeTaxRate: 0.1}
+	service := NewTaxService(
+		WithRemoteCalculator(remote),
+		WithLocalCalculator(local),
+		WithFallbackPolicy(APIThenLocal),
+	)
+
+	result, err := service.CalculateTax(req)
+	if err == nil {
+		t.Fatal("Expected an error for the rejected input")
+	}
+	// The local PAP would only calculate with the rejected input
+	if len(local.Requests()) != 0 {
+		t.Errorf("Expected no fallback, got %d local calculations", len(local.Requests()))
+	}
+	if result.Provenance.Fallback() {
+		t.Errorf("Expected no fallback, got reason %q", result.Provenance.FallbackReason)
+	}
+	if validation, ok := result.ValidationErrors.Field("KRV"); !ok || validation.Value != "7" {
+		t.Errorf("Expected the error of KRV on the result, got %+v", result.ValidationErrors)
+	}
+	if result.IncomeTax != 0 || result.Error == nil {
+		t.Errorf("Expected no tax but an error, got %+v", result)
+	}
+}
+
+func TestCalculateTaxBonus(t *testing.T) {
+	service := NewTaxService(
+		WithLocalCalculator(&FakeCalculator{IncomeTaxRate: 0.2, SolidarityRate: 0.01}),
+		WithFallbackPolicy(LocalOnly),
+	)
+
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1, JRE4: 5000000, SONSTB: 1000000}
+	result, err := service.CalculateTax(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	// Regular pay and the payment are kept apart, the totals include both
+	if result.IncomeTax != 10000 || result.SolidarityTax != 500 {
+		t.Errorf("Expected 10000 and 500 tax on the regular pay, got %f and %f", result.IncomeTax, result.SolidarityTax)
+	}
+	if result.Bonus != 10000 || result.BonusIncomeTax != 2000 || result.BonusSolidarityTax != 100 {
+		t.Errorf("Expected 2000 and 100 tax on a 10000 bonus, got %+v", result)
+	}
+	if result.TotalTax != 12600 {
+		t.Errorf("Expected total tax 12600, got %f", result.TotalTax)
+	}
+	if result.NetIncome != 47400 {
+		t.Errorf("Expected net income 47400, got %f", result.NetIncome)
+	}
+	if result.TaxRate != 21 {
+		t.Errorf("Expected tax rate 21%%, got %f", result.TaxRate)
+	}
+}
+
+func TestCalculateTaxReplayWithoutFixture(t *testing.T) {
+	client := bmf.NewClient(bmf.WithReplay(t.TempDir()), bmf.WithRetryPolicy(bmf.NoRetry))
+	local := &FakeCalculator{IncomeTaxRate: 0.1}
+	service := NewTaxService(
+		WithRemoteCalculator(NewAPICalculatorWithClient(client)),
+		WithLocalCalculator(local),
+		WithFallbackPolicy(APIThenLocal),
+	)
+
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+	if _, err := service.CalculateTax(req); !errors.Is(err, bmf.ErrNoFixture) {
+		t.Errorf("Expected ErrNoFixture, got: %v", err)
+	}
+	if len(local.Requests()) != 0 {
+		t.Error("Expected a missing fixture not to fall back to the local engine")
+	}
+}
+
+func TestCalculateTaxRecordAndReplay(t *testing.T) {
+	client := bmf.NewClient(bmf.WithReplay(t.TempDir()), bmf.WithRecording(t.TempDir()), bmf.WithRetryPolicy(bmf.NoRetry))
+	local := &FakeCalculator{IncomeTaxRate: 0.1}
+	service := NewTaxService(
+		WithRemoteCalculator(NewAPICalculatorWithClient(client)),
+		WithLocalCalculator(local),
+		WithFallbackPolicy(APIThenLocal),
+	)
+
+	req := models.TaxRequest{Period: models.Year, Income: 5000000, TaxClass: models.TaxClass1}
+	if _, err := service.CalculateTax(req); !errors.Is(err, bmf.ErrRecordAndReplay) {
+		t.Errorf("Expected ErrRecordAndReplay, got: %v", err)
+	}
+	if len(local.Requests()) != 0 {
+		t.Error("Expected a client told to record and replay not to fall back to the local engine")
+	}
+}
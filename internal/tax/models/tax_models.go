@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type TaxClass int
 
 const (
@@ -24,7 +26,13 @@ type TaxRequest struct {
 	Period   PaymentPeriod
 	Income   int
 	TaxClass TaxClass
-	
+
+	// Year selects the tax year and PAPVersion optionally pins one of its
+	// published versions (e.g. "2024Version1"); zero values mean the
+	// default year and its current version
+	Year       int
+	PAPVersion string
+
 	AJAHR     int
 	ALTER1    int
 	KRV       int
@@ -38,6 +46,29 @@ type TaxRequest struct {
 	PKPV      int
 	PKV       int
 	PVA       int
+
+	// Further PAP inputs. Amounts are in cents like Income, while VBEZ and
+	// PKPV above are whole euros. Zero ZMVB and F mean 12 months and no factor.
+	AF       int
+	F        float64
+	ENTSCH   int
+	JFREIB   int
+	JHINZU   int
+	JRE4     int
+	JRE4ENT  int
+	JVBEZ    int
+	LZZFREIB int
+	LZZHINZU int
+	MBV      int
+	SONSTB   int
+	SONSTENT int
+	STERBE   int
+	VBEZM    int
+	VBEZS    int
+	VBS      int
+	VKAPA    int
+	VMT      int
+	ZMVB     int
 }
 
 type TaxResult struct {
@@ -47,5 +78,62 @@ type TaxResult struct {
 	TotalTax      float64
 	NetIncome     float64
 	TaxRate       float64
+	Outputs       TaxOutputs
+	Provenance    Provenance
 	Error         error
+	// The inputs the engine rejected, if that is why Error is set
+	ValidationErrors ValidationErrors
+	// Sonstige Bezüge, a one-off payment such as a bonus, and the taxes on
+	// it; TotalTax, NetIncome and TaxRate include them
+	Bonus              float64
+	BonusIncomeTax     float64
+	BonusSolidarityTax float64
+}
+
+// Engine names what produced a tax figure
+type Engine string
+
+const (
+	EngineAPI    Engine = "BMF API"
+	EngineLocal  Engine = "local PAP"
+	EngineNative Engine = "generated Go"
+)
+
+// Provenance records where a result comes from: the engine, the PAP version
+// it follows, when it was calculated and, if the preferred engine failed, why
+type Provenance struct {
+	Engine         Engine
+	PAPVersion     string
+	CalculatedAt   time.Time
+	FallbackReason string
+
+	// Set when both engines were run to cross-check each other. A failed
+	// check leaves Compared false and says why in ComparisonError.
+	Compared        bool
+	ComparisonError string
+	Discrepancies   []Discrepancy
+}
+
+// Discrepancy is an output field on which the BMF API and the local PAP
+// disagree. A value missing from one response is empty there and counts as 0.
+type Discrepancy struct {
+	Field string
+	API   string
+	Local string
+	Cents int64
+}
+
+// Official reports whether the figure comes from the BMF itself
+func (p Provenance) Official() bool {
+	return p.Engine == EngineAPI
+}
+
+// Diverged reports whether the cross-check found differences
+func (p Provenance) Diverged() bool {
+	return len(p.Discrepancies) > 0
+}
+
+// Fallback reports whether the preferred engine failed
+func (p Provenance) Fallback() bool {
+	return p.FallbackReason != ""
 }
\ No newline at end of file
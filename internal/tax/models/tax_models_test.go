@@ -102,4 +102,19 @@ func TestTaxResult(t *testing.T) {
 	if result.Error != testErr {
 		t.Errorf("Expected Error %v, got %v", testErr, result.Error)
 	}
-}
\ No newline at end of file
+}
+
+func TestProvenance(t *testing.T) {
+	api := Provenance{Engine: EngineAPI, PAPVersion: "2025Version1"}
+	if !api.Official() || api.Fallback() {
+		t.Error("Expected an API result to be official and not a fallback")
+	}
+
+	local := Provenance{Engine: EngineLocal, PAPVersion: "2025Version1", FallbackReason: "BMF API failed: timeout"}
+	if local.Official() {
+		t.Error("Expected a local result not to be official")
+	}
+	if !local.Fallback() {
+		t.Error("Expected a result with a fallback reason to be a fallback")
+	}
+}
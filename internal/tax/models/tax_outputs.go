@@ -0,0 +1,141 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Unit is the unit of an amount the PAP returns
+type Unit string
+
+const (
+	Cents Unit = "cents"
+	Euros Unit = "euros"
+)
+
+// TaxOutputs holds every output of the BMF interface and the PAP, each as
+// the whole number the PAP returns in the unit of its OutputField. Outputs a
+// PAP version does not have, such as STV from 2025 on, stay zero; Has tells
+// them apart from a zero result.
+type TaxOutputs struct {
+	// Regular pay for the payment period
+	LSTLZZ  int64
+	SOLZLZZ int64
+	BK      int64
+
+	// Sonstige Bezüge, one-off payments such as a bonus
+	STS   int64
+	SOLZS int64
+	BKS   int64
+
+	// Pay for several years of work, up to 2024
+	STV   int64
+	SOLZV int64
+	BKV   int64
+
+	// Private health and care insurance contributions taken into account
+	VKVLZZ   int64
+	VKVSONST int64
+
+	// Allowances under a double taxation agreement (DBA)
+	VFRB   int64
+	VFRBS1 int64
+	VFRBS2 int64
+	WVFRB  int64
+	WVFRBO int64
+	WVFRBM int64
+
+	present map[string]bool
+}
+
+// OutputField describes an output of the PAP and where TaxOutputs keeps it
+type OutputField struct {
+	Name  string
+	Label string
+	Group string
+	Unit  Unit
+
+	value func(*TaxOutputs) *int64
+}
+
+// Output groups in the order they are shown
+const (
+	RegularPay    = "Regular pay"
+	OtherPayments = "Sonstige Bezüge"
+	MultiYearPay  = "Multi-year pay"
+	PrivateCover  = "Private health insurance"
+	TreatyUsed    = "DBA allowance used"
+	TreatyIncome  = "DBA income above the Grundfreibetrag"
+)
+
+// OutputFields lists the documented outputs of the PAPs
+var OutputFields = []OutputField{
+	{"LSTLZZ", "Lohnsteuer", RegularPay, Cents, func(o *TaxOutputs) *int64 { return &o.LSTLZZ }},
+	{"SOLZLZZ", "Solidarity surcharge", RegularPay, Cents, func(o *TaxOutputs) *int64 { return &o.SOLZLZZ }},
+	{"BK", "Church tax base", RegularPay, Cents, func(o *TaxOutputs) *int64 { return &o.BK }},
+	{"STS", "Lohnsteuer", OtherPayments, Cents, func(o *TaxOutputs) *int64 { return &o.STS }},
+	{"SOLZS", "Solidarity surcharge", OtherPayments, Cents, func(o *TaxOutputs) *int64 { return &o.SOLZS }},
+	{"BKS", "Church tax base", OtherPayments, Cents, func(o *TaxOutputs) *int64 { return &o.BKS }},
+	{"STV", "Lohnsteuer", MultiYearPay, Cents, func(o *TaxOutputs) *int64 { return &o.STV }},
+	{"SOLZV", "Solidarity surcharge", MultiYearPay, Cents, func(o *TaxOutputs) *int64 { return &o.SOLZV }},
+	{"BKV", "Church tax base", MultiYearPay, Cents, func(o *TaxOutputs) *int64 { return &o.BKV }},
+	{"VKVLZZ", "Regular pay", PrivateCover, Cents, func(o *TaxOutputs) *int64 { return &o.VKVLZZ }},
+	{"VKVSONST", "Sonstige Bezüge", PrivateCover, Cents, func(o *TaxOutputs) *int64 { return &o.VKVSONST }},
+	{"VFRB", "Regular pay", TreatyUsed, Cents, func(o *TaxOutputs) *int64 { return &o.VFRB }},
+	{"VFRBS1", "Annual pay", TreatyUsed, Cents, func(o *TaxOutputs) *int64 { return &o.VFRBS1 }},
+	{"VFRBS2", "Sonstige Bezüge", TreatyUsed, Cents, func(o *TaxOutputs) *int64 { return &o.VFRBS2 }},
+	{"WVFRB", "Regular pay", TreatyIncome, Euros, func(o *TaxOutputs) *int64 { return &o.WVFRB }},
+	{"WVFRBO", "Annual pay", TreatyIncome, Euros, func(o *TaxOutputs) *int64 { return &o.WVFRBO }},
+	{"WVFRBM", "Sonstige Bezüge", TreatyIncome, Euros, func(o *TaxOutputs) *int64 { return &o.WVFRBM }},
+}
+
+// LookupOutputField returns the field of a documented output
+func LookupOutputField(name string) (OutputField, bool) {
+	for _, field := range OutputFields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return OutputField{}, false
+}
+
+// Value returns the output of the field, in its unit
+func (f OutputField) Value(o TaxOutputs) int64 {
+	return *f.value(&o)
+}
+
+// Euros returns the output of the field in euros
+func (f OutputField) Euros(o TaxOutputs) float64 {
+	if f.Unit == Cents {
+		return float64(f.Value(o)) / 100
+	}
+	return float64(f.Value(o))
+}
+
+// ParseTaxOutputs reads outputs given by name as whole numbers, the way the
+// BMF interface returns them. Names that are not documented are left out.
+func ParseTaxOutputs(values map[string]string) (TaxOutputs, error) {
+	var outputs TaxOutputs
+	for _, field := range OutputFields {
+		value, ok := values[field.Name]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return TaxOutputs{}, fmt.Errorf("invalid value %q for output %s", value, field.Name)
+		}
+		*field.value(&outputs) = n
+		if outputs.present == nil {
+			outputs.present = make(map[string]bool)
+		}
+		outputs.present[field.Name] = true
+	}
+	return outputs, nil
+}
+
+// Has reports whether the outputs include the named one
+func (o TaxOutputs) Has(name string) bool {
+	return o.present[name]
+}
@@ -0,0 +1,58 @@
+package models
+
+import "testing"
+
+func TestParseTaxOutputs(t *testing.T) {
+	outputs, err := ParseTaxOutputs(map[string]string{
+		"LSTLZZ": "800000",
+		"BK":     "750000",
+		"STS":    "0",
+		"WVFRB":  "1234",
+		"XYZ":    "not a number",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if outputs.LSTLZZ != 800000 || outputs.BK != 750000 || outputs.WVFRB != 1234 {
+		t.Errorf("Unexpected outputs: %+v", outputs)
+	}
+	if !outputs.Has("STS") || outputs.Has("STV") || outputs.Has("XYZ") {
+		t.Error("Expected STS to be present and STV and XYZ not")
+	}
+
+	// WVFRB is in whole euros, the others in cents
+	lstlzz, _ := LookupOutputField("LSTLZZ")
+	wvfrb, _ := LookupOutputField("WVFRB")
+	if lstlzz.Euros(outputs) != 8000 || wvfrb.Unit != Euros || wvfrb.Euros(outputs) != 1234 {
+		t.Errorf("Expected 8000 and 1234 euros, got %v and %v", lstlzz.Euros(outputs), wvfrb.Euros(outputs))
+	}
+
+	if _, err := ParseTaxOutputs(map[string]string{"SOLZLZZ": "4.00"}); err == nil || err.Error() != `invalid value "4.00" for output SOLZLZZ` {
+		t.Errorf("Expected an invalid value error, got: %v", err)
+	}
+	if _, ok := LookupOutputField("XYZ"); ok {
+		t.Error("Expected XYZ to be unknown")
+	}
+}
+
+// Every field reads and writes its own value
+func TestOutputFields(t *testing.T) {
+	for _, field := range OutputFields {
+		outputs, err := ParseTaxOutputs(map[string]string{field.Name: "7"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		for _, other := range OutputFields {
+			expected := int64(0)
+			if other.Name == field.Name {
+				expected = 7
+			}
+			if got := other.Value(outputs); got != expected {
+				t.Errorf("Setting %s: expected %s = %d, got %d", field.Name, other.Name, expected, got)
+			}
+		}
+		if field.Label == "" || field.Group == "" || field.Unit == "" {
+			t.Errorf("%s: expected a label, group and unit, got %+v", field.Name, field)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is an input of a request that was rejected, named as in
+// the PAP, e.g. KVZ. An error without a field applies to the whole request.
+type ValidationError struct {
+	Field   string
+	Value   string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("invalid %s %q: %s", e.Field, e.Value, e.Message)
+}
+
+// ValidationErrors are the rejected inputs of a request, which has no
+// result. Callers find them with errors.As.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Field returns the error of the named input
+func (e ValidationErrors) Field(name string) (ValidationError, bool) {
+	for _, err := range e {
+		if err.Field == name {
+			return err, true
+		}
+	}
+	return ValidationError{}, false
+}
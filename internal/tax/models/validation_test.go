@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestValidationErrors(t *testing.T) {
+	errs := ValidationErrors{
+		{Field: "KVZ", Value: "1,3", Message: "Eingabe ist fehlerhaft"},
+		{Message: "Die Berechnung konnte nicht durchgeführt werden"},
+	}
+
+	expected := `invalid KVZ "1,3": Eingabe ist fehlerhaft; Die Berechnung konnte nicht durchgeführt werden`
+	if errs.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, errs.Error())
+	}
+
+	if err, ok := errs.Field("KVZ"); !ok || err.Value != "1,3" {
+		t.Errorf("Expected the error of KVZ, got %+v, %v", err, ok)
+	}
+	if _, ok := errs.Field("RE4"); ok {
+		t.Error("Expected no error for RE4")
+	}
+
+	var found ValidationErrors
+	if !errors.As(fmt.Errorf("api failed: %w", errs), &found) || len(found) != 2 {
+		t.Errorf("Expected to find the errors in a wrapped error, got %v", found)
+	}
+}
@@ -0,0 +1,1371 @@
+// Code generated by papgen from Lohnsteuer2025.xml; DO NOT EDIT.
+
+// Package lohnsteuer2025 is the PAP Lohnsteuer2025 version 1.0 as Go code.
+package lohnsteuer2025
+
+import (
+	"fmt"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+// Inputs are the input variables of the PAP.
+type Inputs struct {
+	Af       int         // af
+	AJAHR    int         // AJAHR
+	ALTER1   int         // ALTER1
+	F        float64     // f
+	JFREIB   bmf.Decimal // JFREIB
+	JHINZU   bmf.Decimal // JHINZU
+	JRE4     bmf.Decimal // JRE4
+	JRE4ENT  bmf.Decimal // JRE4ENT
+	JVBEZ    bmf.Decimal // JVBEZ
+	KRV      int         // KRV
+	KVZ      bmf.Decimal // KVZ
+	LZZ      int         // LZZ
+	LZZFREIB bmf.Decimal // LZZFREIB
+	LZZHINZU bmf.Decimal // LZZHINZU
+	MBV      bmf.Decimal // MBV
+	PKPV     bmf.Decimal // PKPV
+	PKV      int         // PKV
+	PVA      bmf.Decimal // PVA
+	PVS      int         // PVS
+	PVZ      int         // PVZ
+	R        int         // R
+	RE4      bmf.Decimal // RE4
+	SONSTB   bmf.Decimal // SONSTB
+	SONSTENT bmf.Decimal // SONSTENT
+	STERBE   bmf.Decimal // STERBE
+	STKL     int         // STKL
+	VBEZ     bmf.Decimal // VBEZ
+	VBEZM    bmf.Decimal // VBEZM
+	VBEZS    bmf.Decimal // VBEZS
+	VBS      bmf.Decimal // VBS
+	VJAHR    int         // VJAHR
+	ZKF      bmf.Decimal // ZKF
+	ZMVB     int         // ZMVB
+}
+
+// Outputs are the output variables of the PAP.
+type Outputs struct {
+	BK       bmf.Decimal // BK
+	BKS      bmf.Decimal // BKS
+	LSTLZZ   bmf.Decimal // LSTLZZ
+	SOLZLZZ  bmf.Decimal // SOLZLZZ
+	SOLZS    bmf.Decimal // SOLZS
+	STS      bmf.Decimal // STS
+	VKVLZZ   bmf.Decimal // VKVLZZ
+	VKVSONST bmf.Decimal // VKVSONST
+	VFRB     bmf.Decimal // VFRB
+	VFRBS1   bmf.Decimal // VFRBS1
+	VFRBS2   bmf.Decimal // VFRBS2
+	WVFRB    bmf.Decimal // WVFRB
+	WVFRBO   bmf.Decimal // WVFRBO
+	WVFRBM   bmf.Decimal // WVFRBM
+}
+
+// Constants of the PAP
+var (
+	tab1 = []bmf.Decimal{
+		bmf.DecimalFromFloat(0.0),
+		bmf.DecimalFromFloat(0.4),
+		bmf.DecimalFromFloat(0.384),
+		bmf.DecimalFromFloat(0.368),
+		bmf.DecimalFromFloat(0.352),
+		bmf.DecimalFromFloat(0.336),
+		bmf.DecimalFromFloat(0.320),
+		bmf.DecimalFromFloat(0.304),
+		bmf.DecimalFromFloat(0.288),
+		bmf.DecimalFromFloat(0.272),
+		bmf.DecimalFromFloat(0.256),
+		bmf.DecimalFromFloat(0.240),
+		bmf.DecimalFromFloat(0.224),
+		bmf.DecimalFromFloat(0.208),
+		bmf.DecimalFromFloat(0.192),
+		bmf.DecimalFromFloat(0.176),
+		bmf.DecimalFromFloat(0.160),
+		bmf.DecimalFromFloat(0.152),
+		bmf.DecimalFromFloat(0.144),
+		bmf.DecimalFromFloat(0.140),
+		bmf.DecimalFromFloat(0.136),
+		bmf.DecimalFromFloat(0.132),
+		bmf.DecimalFromFloat(0.128),
+		bmf.DecimalFromFloat(0.124),
+		bmf.DecimalFromFloat(0.120),
+		bmf.DecimalFromFloat(0.116),
+		bmf.DecimalFromFloat(0.112),
+		bmf.DecimalFromFloat(0.108),
+		bmf.DecimalFromFloat(0.104),
+		bmf.DecimalFromFloat(0.100),
+		bmf.DecimalFromFloat(0.096),
+		bmf.DecimalFromFloat(0.092),
+		bmf.DecimalFromFloat(0.088),
+		bmf.DecimalFromFloat(0.084),
+		bmf.DecimalFromFloat(0.080),
+		bmf.DecimalFromFloat(0.076),
+		bmf.DecimalFromFloat(0.072),
+		bmf.DecimalFromFloat(0.068),
+		bmf.DecimalFromFloat(0.064),
+		bmf.DecimalFromFloat(0.060),
+		bmf.DecimalFromFloat(0.056),
+		bmf.DecimalFromFloat(0.052),
+		bmf.DecimalFromFloat(0.048),
+		bmf.DecimalFromFloat(0.044),
+		bmf.DecimalFromFloat(0.040),
+		bmf.DecimalFromFloat(0.036),
+		bmf.DecimalFromFloat(0.032),
+		bmf.DecimalFromFloat(0.028),
+		bmf.DecimalFromFloat(0.024),
+		bmf.DecimalFromFloat(0.020),
+		bmf.DecimalFromFloat(0.016),
+		bmf.DecimalFromFloat(0.012),
+		bmf.DecimalFromFloat(0.008),
+		bmf.DecimalFromFloat(0.004),
+		bmf.DecimalFromFloat(0.000),
+	}
+	tab2 = []bmf.Decimal{
+		bmf.DecimalFromInt(0),
+		bmf.DecimalFromInt(3000),
+		bmf.DecimalFromInt(2880),
+		bmf.DecimalFromInt(2760),
+		bmf.DecimalFromInt(2640),
+		bmf.DecimalFromInt(2520),
+		bmf.DecimalFromInt(2400),
+		bmf.DecimalFromInt(2280),
+		bmf.DecimalFromInt(2160),
+		bmf.DecimalFromInt(2040),
+		bmf.DecimalFromInt(1920),
+		bmf.DecimalFromInt(1800),
+		bmf.DecimalFromInt(1680),
+		bmf.DecimalFromInt(1560),
+		bmf.DecimalFromInt(1440),
+		bmf.DecimalFromInt(1320),
+		bmf.DecimalFromInt(1200),
+		bmf.DecimalFromInt(1140),
+		bmf.DecimalFromInt(1080),
+		bmf.DecimalFromInt(1050),
+		bmf.DecimalFromInt(1020),
+		bmf.DecimalFromInt(990),
+		bmf.DecimalFromInt(960),
+		bmf.DecimalFromInt(930),
+		bmf.DecimalFromInt(900),
+		bmf.DecimalFromInt(870),
+		bmf.DecimalFromInt(840),
+		bmf.DecimalFromInt(810),
+		bmf.DecimalFromInt(780),
+		bmf.DecimalFromInt(750),
+		bmf.DecimalFromInt(720),
+		bmf.DecimalFromInt(690),
+		bmf.DecimalFromInt(660),
+		bmf.DecimalFromInt(630),
+		bmf.DecimalFromInt(600),
+		bmf.DecimalFromInt(570),
+		bmf.DecimalFromInt(540),
+		bmf.DecimalFromInt(510),
+		bmf.DecimalFromInt(480),
+		bmf.DecimalFromInt(450),
+		bmf.DecimalFromInt(420),
+		bmf.DecimalFromInt(390),
+		bmf.DecimalFromInt(360),
+		bmf.DecimalFromInt(330),
+		bmf.DecimalFromInt(300),
+		bmf.DecimalFromInt(270),
+		bmf.DecimalFromInt(240),
+		bmf.DecimalFromInt(210),
+		bmf.DecimalFromInt(180),
+		bmf.DecimalFromInt(150),
+		bmf.DecimalFromInt(120),
+		bmf.DecimalFromInt(90),
+		bmf.DecimalFromInt(60),
+		bmf.DecimalFromInt(30),
+		bmf.DecimalFromInt(0),
+	}
+	tab3 = []bmf.Decimal{
+		bmf.DecimalFromInt(0),
+		bmf.DecimalFromInt(900),
+		bmf.DecimalFromInt(864),
+		bmf.DecimalFromInt(828),
+		bmf.DecimalFromInt(792),
+		bmf.DecimalFromInt(756),
+		bmf.DecimalFromInt(720),
+		bmf.DecimalFromInt(684),
+		bmf.DecimalFromInt(648),
+		bmf.DecimalFromInt(612),
+		bmf.DecimalFromInt(576),
+		bmf.DecimalFromInt(540),
+		bmf.DecimalFromInt(504),
+		bmf.DecimalFromInt(468),
+		bmf.DecimalFromInt(432),
+		bmf.DecimalFromInt(396),
+		bmf.DecimalFromInt(360),
+		bmf.DecimalFromInt(342),
+		bmf.DecimalFromInt(324),
+		bmf.DecimalFromInt(315),
+		bmf.DecimalFromInt(306),
+		bmf.DecimalFromInt(297),
+		bmf.DecimalFromInt(288),
+		bmf.DecimalFromInt(279),
+		bmf.DecimalFromInt(270),
+		bmf.DecimalFromInt(261),
+		bmf.DecimalFromInt(252),
+		bmf.DecimalFromInt(243),
+		bmf.DecimalFromInt(234),
+		bmf.DecimalFromInt(225),
+		bmf.DecimalFromInt(216),
+		bmf.DecimalFromInt(207),
+		bmf.DecimalFromInt(198),
+		bmf.DecimalFromInt(189),
+		bmf.DecimalFromInt(180),
+		bmf.DecimalFromInt(171),
+		bmf.DecimalFromInt(162),
+		bmf.DecimalFromInt(153),
+		bmf.DecimalFromInt(144),
+		bmf.DecimalFromInt(135),
+		bmf.DecimalFromInt(126),
+		bmf.DecimalFromInt(117),
+		bmf.DecimalFromInt(108),
+		bmf.DecimalFromInt(99),
+		bmf.DecimalFromInt(90),
+		bmf.DecimalFromInt(81),
+		bmf.DecimalFromInt(72),
+		bmf.DecimalFromInt(63),
+		bmf.DecimalFromInt(54),
+		bmf.DecimalFromInt(45),
+		bmf.DecimalFromInt(36),
+		bmf.DecimalFromInt(27),
+		bmf.DecimalFromInt(18),
+		bmf.DecimalFromInt(9),
+		bmf.DecimalFromInt(0),
+	}
+	tab4 = []bmf.Decimal{
+		bmf.DecimalFromFloat(0.0),
+		bmf.DecimalFromFloat(0.4),
+		bmf.DecimalFromFloat(0.384),
+		bmf.DecimalFromFloat(0.368),
+		bmf.DecimalFromFloat(0.352),
+		bmf.DecimalFromFloat(0.336),
+		bmf.DecimalFromFloat(0.320),
+		bmf.DecimalFromFloat(0.304),
+		bmf.DecimalFromFloat(0.288),
+		bmf.DecimalFromFloat(0.272),
+		bmf.DecimalFromFloat(0.256),
+		bmf.DecimalFromFloat(0.240),
+		bmf.DecimalFromFloat(0.224),
+		bmf.DecimalFromFloat(0.208),
+		bmf.DecimalFromFloat(0.192),
+		bmf.DecimalFromFloat(0.176),
+		bmf.DecimalFromFloat(0.160),
+		bmf.DecimalFromFloat(0.152),
+		bmf.DecimalFromFloat(0.144),
+		bmf.DecimalFromFloat(0.140),
+		bmf.DecimalFromFloat(0.136),
+		bmf.DecimalFromFloat(0.132),
+		bmf.DecimalFromFloat(0.128),
+		bmf.DecimalFromFloat(0.124),
+		bmf.DecimalFromFloat(0.120),
+		bmf.DecimalFromFloat(0.116),
+		bmf.DecimalFromFloat(0.112),
+		bmf.DecimalFromFloat(0.108),
+		bmf.DecimalFromFloat(0.104),
+		bmf.DecimalFromFloat(0.100),
+		bmf.DecimalFromFloat(0.096),
+		bmf.DecimalFromFloat(0.092),
+		bmf.DecimalFromFloat(0.088),
+		bmf.DecimalFromFloat(0.084),
+		bmf.DecimalFromFloat(0.080),
+		bmf.DecimalFromFloat(0.076),
+		bmf.DecimalFromFloat(0.072),
+		bmf.DecimalFromFloat(0.068),
+		bmf.DecimalFromFloat(0.064),
+		bmf.DecimalFromFloat(0.060),
+		bmf.DecimalFromFloat(0.056),
+		bmf.DecimalFromFloat(0.052),
+		bmf.DecimalFromFloat(0.048),
+		bmf.DecimalFromFloat(0.044),
+		bmf.DecimalFromFloat(0.040),
+		bmf.DecimalFromFloat(0.036),
+		bmf.DecimalFromFloat(0.032),
+		bmf.DecimalFromFloat(0.028),
+		bmf.DecimalFromFloat(0.024),
+		bmf.DecimalFromFloat(0.020),
+		bmf.DecimalFromFloat(0.016),
+		bmf.DecimalFromFloat(0.012),
+		bmf.DecimalFromFloat(0.008),
+		bmf.DecimalFromFloat(0.004),
+		bmf.DecimalFromFloat(0.000),
+	}
+	tab5 = []bmf.Decimal{
+		bmf.DecimalFromInt(0),
+		bmf.DecimalFromInt(1900),
+		bmf.DecimalFromInt(1824),
+		bmf.DecimalFromInt(1748),
+		bmf.DecimalFromInt(1672),
+		bmf.DecimalFromInt(1596),
+		bmf.DecimalFromInt(1520),
+		bmf.DecimalFromInt(1444),
+		bmf.DecimalFromInt(1368),
+		bmf.DecimalFromInt(1292),
+		bmf.DecimalFromInt(1216),
+		bmf.DecimalFromInt(1140),
+		bmf.DecimalFromInt(1064),
+		bmf.DecimalFromInt(988),
+		bmf.DecimalFromInt(912),
+		bmf.DecimalFromInt(836),
+		bmf.DecimalFromInt(760),
+		bmf.DecimalFromInt(722),
+		bmf.DecimalFromInt(684),
+		bmf.DecimalFromInt(665),
+		bmf.DecimalFromInt(646),
+		bmf.DecimalFromInt(627),
+		bmf.DecimalFromInt(608),
+		bmf.DecimalFromInt(589),
+		bmf.DecimalFromInt(570),
+		bmf.DecimalFromInt(551),
+		bmf.DecimalFromInt(532),
+		bmf.DecimalFromInt(513),
+		bmf.DecimalFromInt(494),
+		bmf.DecimalFromInt(475),
+		bmf.DecimalFromInt(456),
+		bmf.DecimalFromInt(437),
+		bmf.DecimalFromInt(418),
+		bmf.DecimalFromInt(399),
+		bmf.DecimalFromInt(380),
+		bmf.DecimalFromInt(361),
+		bmf.DecimalFromInt(342),
+		bmf.DecimalFromInt(323),
+		bmf.DecimalFromInt(304),
+		bmf.DecimalFromInt(285),
+		bmf.DecimalFromInt(266),
+		bmf.DecimalFromInt(247),
+		bmf.DecimalFromInt(228),
+		bmf.DecimalFromInt(209),
+		bmf.DecimalFromInt(190),
+		bmf.DecimalFromInt(171),
+		bmf.DecimalFromInt(152),
+		bmf.DecimalFromInt(133),
+		bmf.DecimalFromInt(114),
+		bmf.DecimalFromInt(95),
+		bmf.DecimalFromInt(76),
+		bmf.DecimalFromInt(57),
+		bmf.DecimalFromInt(38),
+		bmf.DecimalFromInt(19),
+		bmf.DecimalFromInt(0),
+	}
+	zahl1     = bmf.DecimalOne
+	zahl2     = bmf.DecimalFromInt(2)
+	zahl5     = bmf.DecimalFromInt(5)
+	zahl7     = bmf.DecimalFromInt(7)
+	zahl12    = bmf.DecimalFromInt(12)
+	zahl100   = bmf.DecimalFromInt(100)
+	zahl360   = bmf.DecimalFromInt(360)
+	zahl500   = bmf.DecimalFromInt(500)
+	zahl700   = bmf.DecimalFromInt(700)
+	zahl1000  = bmf.DecimalFromInt(1000)
+	zahl10000 = bmf.DecimalFromInt(10000)
+)
+
+// DefaultInputs returns Inputs with the defaults the PAP declares
+func DefaultInputs() Inputs {
+	return Inputs{
+		Af:       1,
+		F:        1.0,
+		JRE4ENT:  bmf.DecimalZero,
+		PKPV:     bmf.DecimalFromInt(0),
+		PKV:      0,
+		PVA:      bmf.DecimalFromInt(0),
+		PVS:      0,
+		PVZ:      0,
+		SONSTENT: bmf.DecimalZero,
+	}
+}
+
+type state struct {
+	Inputs
+	Outputs
+
+	ALTE     bmf.Decimal
+	ANP      bmf.Decimal
+	ANTEIL1  bmf.Decimal
+	BBGKVPV  bmf.Decimal
+	BBGRV    bmf.Decimal
+	BMG      bmf.Decimal
+	DIFF     bmf.Decimal
+	EFA      bmf.Decimal
+	FVB      bmf.Decimal
+	FVBSO    bmf.Decimal
+	FVBZ     bmf.Decimal
+	FVBZSO   bmf.Decimal
+	GFB      bmf.Decimal
+	HBALTE   bmf.Decimal
+	HFVB     bmf.Decimal
+	HFVBZ    bmf.Decimal
+	HFVBZSO  bmf.Decimal
+	HOCH     bmf.Decimal
+	J        int
+	JBMG     bmf.Decimal
+	JLFREIB  bmf.Decimal
+	JLHINZU  bmf.Decimal
+	JW       bmf.Decimal
+	K        int
+	KFB      bmf.Decimal
+	KVSATZAG bmf.Decimal
+	KVSATZAN bmf.Decimal
+	KZTAB    int
+	LSTJAHR  bmf.Decimal
+	LSTOSO   bmf.Decimal
+	LSTSO    bmf.Decimal
+	MIST     bmf.Decimal
+	PVSATZAG bmf.Decimal
+	PVSATZAN bmf.Decimal
+	RVSATZAN bmf.Decimal
+	RW       bmf.Decimal
+	SAP      bmf.Decimal
+	SOLZFREI bmf.Decimal
+	SOLZJ    bmf.Decimal
+	SOLZMIN  bmf.Decimal
+	SOLZSBMG bmf.Decimal
+	SOLZSZVE bmf.Decimal
+	ST       bmf.Decimal
+	ST1      bmf.Decimal
+	ST2      bmf.Decimal
+	VBEZB    bmf.Decimal
+	VBEZBSO  bmf.Decimal
+	VERGL    bmf.Decimal
+	VHB      bmf.Decimal
+	VKV      bmf.Decimal
+	VSP      bmf.Decimal
+	VSPN     bmf.Decimal
+	VSP1     bmf.Decimal
+	VSP2     bmf.Decimal
+	VSP3     bmf.Decimal
+	W1STKL5  bmf.Decimal
+	W2STKL5  bmf.Decimal
+	W3STKL5  bmf.Decimal
+	X        bmf.Decimal
+	Y        bmf.Decimal
+	ZRE4     bmf.Decimal
+	ZRE4J    bmf.Decimal
+	ZRE4VP   bmf.Decimal
+	ZTABFB   bmf.Decimal
+	ZVBEZ    bmf.Decimal
+	ZVBEZJ   bmf.Decimal
+	ZVE      bmf.Decimal
+	ZX       bmf.Decimal
+	ZZX      bmf.Decimal
+}
+
+func newState(in Inputs) *state {
+	s := &state{Inputs: in}
+	s.BK = bmf.DecimalFromInt(0)
+	s.BKS = bmf.DecimalFromInt(0)
+	s.LSTLZZ = bmf.DecimalFromInt(0)
+	s.SOLZLZZ = bmf.DecimalFromInt(0)
+	s.SOLZS = bmf.DecimalFromInt(0)
+	s.STS = bmf.DecimalFromInt(0)
+	s.VKVLZZ = bmf.DecimalFromInt(0)
+	s.VKVSONST = bmf.DecimalFromInt(0)
+	s.VFRB = bmf.DecimalFromInt(0)
+	s.VFRBS1 = bmf.DecimalFromInt(0)
+	s.VFRBS2 = bmf.DecimalFromInt(0)
+	s.WVFRB = bmf.DecimalFromInt(0)
+	s.WVFRBO = bmf.DecimalFromInt(0)
+	s.WVFRBM = bmf.DecimalFromInt(0)
+	s.ALTE = bmf.DecimalFromInt(0)
+	s.ANP = bmf.DecimalFromInt(0)
+	s.ANTEIL1 = bmf.DecimalFromInt(0)
+	s.BBGKVPV = bmf.DecimalFromInt(0)
+	s.BBGRV = bmf.DecimalFromInt(0)
+	s.BMG = bmf.DecimalFromInt(0)
+	s.DIFF = bmf.DecimalFromInt(0)
+	s.EFA = bmf.DecimalFromInt(0)
+	s.FVB = bmf.DecimalFromInt(0)
+	s.FVBSO = bmf.DecimalFromInt(0)
+	s.FVBZ = bmf.DecimalFromInt(0)
+	s.FVBZSO = bmf.DecimalFromInt(0)
+	s.GFB = bmf.DecimalFromInt(0)
+	s.HBALTE = bmf.DecimalFromInt(0)
+	s.HFVB = bmf.DecimalFromInt(0)
+	s.HFVBZ = bmf.DecimalFromInt(0)
+	s.HFVBZSO = bmf.DecimalFromInt(0)
+	s.HOCH = bmf.DecimalFromInt(0)
+	s.JBMG = bmf.DecimalFromInt(0)
+	s.JLFREIB = bmf.DecimalFromInt(0)
+	s.JLHINZU = bmf.DecimalFromInt(0)
+	s.JW = bmf.DecimalFromInt(0)
+	s.KFB = bmf.DecimalFromInt(0)
+	s.KVSATZAG = bmf.DecimalFromInt(0)
+	s.KVSATZAN = bmf.DecimalFromInt(0)
+	s.KZTAB = 1
+	s.LSTJAHR = bmf.DecimalFromInt(0)
+	s.LSTOSO = bmf.DecimalFromInt(0)
+	s.LSTSO = bmf.DecimalFromInt(0)
+	s.MIST = bmf.DecimalFromInt(0)
+	s.PVSATZAG = bmf.DecimalFromInt(0)
+	s.PVSATZAN = bmf.DecimalFromInt(0)
+	s.RVSATZAN = bmf.DecimalFromInt(0)
+	s.RW = bmf.DecimalFromInt(0)
+	s.SAP = bmf.DecimalFromInt(0)
+	s.SOLZFREI = bmf.DecimalFromInt(0)
+	s.SOLZJ = bmf.DecimalFromInt(0)
+	s.SOLZMIN = bmf.DecimalFromInt(0)
+	s.SOLZSBMG = bmf.DecimalFromInt(0)
+	s.SOLZSZVE = bmf.DecimalFromInt(0)
+	s.ST = bmf.DecimalFromInt(0)
+	s.ST1 = bmf.DecimalFromInt(0)
+	s.ST2 = bmf.DecimalFromInt(0)
+	s.VBEZB = bmf.DecimalFromInt(0)
+	s.VBEZBSO = bmf.DecimalFromInt(0)
+	s.VERGL = bmf.DecimalFromInt(0)
+	s.VHB = bmf.DecimalFromInt(0)
+	s.VKV = bmf.DecimalFromInt(0)
+	s.VSP = bmf.DecimalFromInt(0)
+	s.VSPN = bmf.DecimalFromInt(0)
+	s.VSP1 = bmf.DecimalFromInt(0)
+	s.VSP2 = bmf.DecimalFromInt(0)
+	s.VSP3 = bmf.DecimalFromInt(0)
+	s.W1STKL5 = bmf.DecimalFromInt(0)
+	s.W2STKL5 = bmf.DecimalFromInt(0)
+	s.W3STKL5 = bmf.DecimalFromInt(0)
+	s.X = bmf.DecimalFromInt(0)
+	s.Y = bmf.DecimalFromInt(0)
+	s.ZRE4 = bmf.DecimalFromInt(0)
+	s.ZRE4J = bmf.DecimalFromInt(0)
+	s.ZRE4VP = bmf.DecimalFromInt(0)
+	s.ZTABFB = bmf.DecimalFromInt(0)
+	s.ZVBEZ = bmf.DecimalFromInt(0)
+	s.ZVBEZJ = bmf.DecimalFromInt(0)
+	s.ZVE = bmf.DecimalFromInt(0)
+	s.ZX = bmf.DecimalFromInt(0)
+	s.ZZX = bmf.DecimalFromInt(0)
+	return s
+}
+
+// Lohnsteuer2025 runs the PAP. It fails on the errors BigDecimal would throw,
+// such as a division by zero, which must raises as panics and this function
+// recovers.
+func Lohnsteuer2025(in Inputs) (out Outputs, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = fmt.Errorf("lohnsteuer2025: %w", e)
+				return
+			}
+			err = fmt.Errorf("lohnsteuer2025: %v", r)
+		}
+	}()
+
+	s := newState(in)
+	s.run()
+	return s.Outputs, nil
+}
+
+// Calculate runs Lohnsteuer2025 on values keyed by their PAP names, such as the
+// inputs of bmf.RequestInputs, and returns the outputs the same way.
+// Unknown names are ignored.
+func Calculate(values map[string]interface{}) (map[string]interface{}, error) {
+	in := DefaultInputs()
+	var err error
+	if value, ok := values["af"]; ok {
+		if in.Af, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input af: %w", err)
+		}
+	}
+	if value, ok := values["AJAHR"]; ok {
+		if in.AJAHR, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input AJAHR: %w", err)
+		}
+	}
+	if value, ok := values["ALTER1"]; ok {
+		if in.ALTER1, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input ALTER1: %w", err)
+		}
+	}
+	if value, ok := values["f"]; ok {
+		if in.F, err = floatValue(value); err != nil {
+			return nil, fmt.Errorf("input f: %w", err)
+		}
+	}
+	if value, ok := values["JFREIB"]; ok {
+		if in.JFREIB, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input JFREIB: %w", err)
+		}
+	}
+	if value, ok := values["JHINZU"]; ok {
+		if in.JHINZU, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input JHINZU: %w", err)
+		}
+	}
+	if value, ok := values["JRE4"]; ok {
+		if in.JRE4, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input JRE4: %w", err)
+		}
+	}
+	if value, ok := values["JRE4ENT"]; ok {
+		if in.JRE4ENT, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input JRE4ENT: %w", err)
+		}
+	}
+	if value, ok := values["JVBEZ"]; ok {
+		if in.JVBEZ, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input JVBEZ: %w", err)
+		}
+	}
+	if value, ok := values["KRV"]; ok {
+		if in.KRV, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input KRV: %w", err)
+		}
+	}
+	if value, ok := values["KVZ"]; ok {
+		if in.KVZ, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input KVZ: %w", err)
+		}
+	}
+	if value, ok := values["LZZ"]; ok {
+		if in.LZZ, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input LZZ: %w", err)
+		}
+	}
+	if value, ok := values["LZZFREIB"]; ok {
+		if in.LZZFREIB, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input LZZFREIB: %w", err)
+		}
+	}
+	if value, ok := values["LZZHINZU"]; ok {
+		if in.LZZHINZU, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input LZZHINZU: %w", err)
+		}
+	}
+	if value, ok := values["MBV"]; ok {
+		if in.MBV, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input MBV: %w", err)
+		}
+	}
+	if value, ok := values["PKPV"]; ok {
+		if in.PKPV, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input PKPV: %w", err)
+		}
+	}
+	if value, ok := values["PKV"]; ok {
+		if in.PKV, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input PKV: %w", err)
+		}
+	}
+	if value, ok := values["PVA"]; ok {
+		if in.PVA, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input PVA: %w", err)
+		}
+	}
+	if value, ok := values["PVS"]; ok {
+		if in.PVS, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input PVS: %w", err)
+		}
+	}
+	if value, ok := values["PVZ"]; ok {
+		if in.PVZ, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input PVZ: %w", err)
+		}
+	}
+	if value, ok := values["R"]; ok {
+		if in.R, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input R: %w", err)
+		}
+	}
+	if value, ok := values["RE4"]; ok {
+		if in.RE4, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input RE4: %w", err)
+		}
+	}
+	if value, ok := values["SONSTB"]; ok {
+		if in.SONSTB, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input SONSTB: %w", err)
+		}
+	}
+	if value, ok := values["SONSTENT"]; ok {
+		if in.SONSTENT, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input SONSTENT: %w", err)
+		}
+	}
+	if value, ok := values["STERBE"]; ok {
+		if in.STERBE, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input STERBE: %w", err)
+		}
+	}
+	if value, ok := values["STKL"]; ok {
+		if in.STKL, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input STKL: %w", err)
+		}
+	}
+	if value, ok := values["VBEZ"]; ok {
+		if in.VBEZ, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input VBEZ: %w", err)
+		}
+	}
+	if value, ok := values["VBEZM"]; ok {
+		if in.VBEZM, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input VBEZM: %w", err)
+		}
+	}
+	if value, ok := values["VBEZS"]; ok {
+		if in.VBEZS, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input VBEZS: %w", err)
+		}
+	}
+	if value, ok := values["VBS"]; ok {
+		if in.VBS, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input VBS: %w", err)
+		}
+	}
+	if value, ok := values["VJAHR"]; ok {
+		if in.VJAHR, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input VJAHR: %w", err)
+		}
+	}
+	if value, ok := values["ZKF"]; ok {
+		if in.ZKF, err = decimalValue(value); err != nil {
+			return nil, fmt.Errorf("input ZKF: %w", err)
+		}
+	}
+	if value, ok := values["ZMVB"]; ok {
+		if in.ZMVB, err = intValue(value); err != nil {
+			return nil, fmt.Errorf("input ZMVB: %w", err)
+		}
+	}
+
+	out, err := Lohnsteuer2025(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"BK":       out.BK,
+		"BKS":      out.BKS,
+		"LSTLZZ":   out.LSTLZZ,
+		"SOLZLZZ":  out.SOLZLZZ,
+		"SOLZS":    out.SOLZS,
+		"STS":      out.STS,
+		"VKVLZZ":   out.VKVLZZ,
+		"VKVSONST": out.VKVSONST,
+		"VFRB":     out.VFRB,
+		"VFRBS1":   out.VFRBS1,
+		"VFRBS2":   out.VFRBS2,
+		"WVFRB":    out.WVFRB,
+		"WVFRBO":   out.WVFRBO,
+		"WVFRBM":   out.WVFRBM,
+	}, nil
+}
+
+// OutputNames lists the outputs Calculate returns in the order the PAP
+// declares them
+func OutputNames() []string {
+	return []string{
+		"BK",
+		"BKS",
+		"LSTLZZ",
+		"SOLZLZZ",
+		"SOLZS",
+		"STS",
+		"VKVLZZ",
+		"VKVSONST",
+		"VFRB",
+		"VFRBS1",
+		"VFRBS2",
+		"WVFRB",
+		"WVFRBO",
+		"WVFRBM",
+	}
+}
+
+func (s *state) run() {
+	s.MPARA()
+	s.MRE4JL()
+	s.VBEZBSO = bmf.DecimalZero
+	s.MRE4()
+	s.MRE4ABZ()
+	s.MBERECH()
+	s.MSONST()
+}
+
+func (s *state) MPARA() {
+	if s.KRV == 0 {
+		s.BBGRV = bmf.DecimalFromInt(96600)
+		s.RVSATZAN = bmf.DecimalFromFloat(0.093)
+	}
+	s.BBGKVPV = bmf.DecimalFromInt(66150)
+	s.KVSATZAN = must(must(s.KVZ.Div(zahl2)).Div(zahl100)).Add(bmf.DecimalFromFloat(0.07))
+	s.KVSATZAG = bmf.DecimalFromFloat(0.0125).Add(bmf.DecimalFromFloat(0.07))
+	if s.PVS == 1 {
+		s.PVSATZAN = bmf.DecimalFromFloat(0.023)
+		s.PVSATZAG = bmf.DecimalFromFloat(0.013)
+	} else {
+		s.PVSATZAN = bmf.DecimalFromFloat(0.018)
+		s.PVSATZAG = bmf.DecimalFromFloat(0.018)
+	}
+	if s.PVZ == 1 {
+		s.PVSATZAN = s.PVSATZAN.Add(bmf.DecimalFromFloat(0.006))
+	} else {
+		s.PVSATZAN = s.PVSATZAN.Sub(s.PVA.Mul(bmf.DecimalFromFloat(0.0025)))
+	}
+	s.W1STKL5 = bmf.DecimalFromInt(13785)
+	s.W2STKL5 = bmf.DecimalFromInt(34240)
+	s.W3STKL5 = bmf.DecimalFromInt(222260)
+	s.GFB = bmf.DecimalFromInt(12096)
+	s.SOLZFREI = bmf.DecimalFromInt(19950)
+}
+
+func (s *state) MRE4JL() {
+	if s.LZZ == 1 {
+		s.ZRE4J = must(s.RE4.DivScale(zahl100, 2, bmf.RoundDown))
+		s.ZVBEZJ = must(s.VBEZ.DivScale(zahl100, 2, bmf.RoundDown))
+		s.JLFREIB = must(s.LZZFREIB.DivScale(zahl100, 2, bmf.RoundDown))
+		s.JLHINZU = must(s.LZZHINZU.DivScale(zahl100, 2, bmf.RoundDown))
+	} else {
+		if s.LZZ == 2 {
+			s.ZRE4J = must(s.RE4.Mul(zahl12).DivScale(zahl100, 2, bmf.RoundDown))
+			s.ZVBEZJ = must(s.VBEZ.Mul(zahl12).DivScale(zahl100, 2, bmf.RoundDown))
+			s.JLFREIB = must(s.LZZFREIB.Mul(zahl12).DivScale(zahl100, 2, bmf.RoundDown))
+			s.JLHINZU = must(s.LZZHINZU.Mul(zahl12).DivScale(zahl100, 2, bmf.RoundDown))
+		} else {
+			if s.LZZ == 3 {
+				s.ZRE4J = must(s.RE4.Mul(zahl360).DivScale(zahl700, 2, bmf.RoundDown))
+				s.ZVBEZJ = must(s.VBEZ.Mul(zahl360).DivScale(zahl700, 2, bmf.RoundDown))
+				s.JLFREIB = must(s.LZZFREIB.Mul(zahl360).DivScale(zahl700, 2, bmf.RoundDown))
+				s.JLHINZU = must(s.LZZHINZU.Mul(zahl360).DivScale(zahl700, 2, bmf.RoundDown))
+			} else {
+				s.ZRE4J = must(s.RE4.Mul(zahl360).DivScale(zahl100, 2, bmf.RoundDown))
+				s.ZVBEZJ = must(s.VBEZ.Mul(zahl360).DivScale(zahl100, 2, bmf.RoundDown))
+				s.JLFREIB = must(s.LZZFREIB.Mul(zahl360).DivScale(zahl100, 2, bmf.RoundDown))
+				s.JLHINZU = must(s.LZZHINZU.Mul(zahl360).DivScale(zahl100, 2, bmf.RoundDown))
+			}
+		}
+	}
+	if s.Af == 0 {
+		s.F = float64(1)
+	}
+}
+
+func (s *state) MRE4() {
+	if s.ZVBEZJ.Cmp(bmf.DecimalZero) == 0 {
+		s.FVBZ = bmf.DecimalZero
+		s.FVB = bmf.DecimalZero
+		s.FVBZSO = bmf.DecimalZero
+		s.FVBSO = bmf.DecimalZero
+	} else {
+		if s.VJAHR < 2006 {
+			s.J = 1
+		} else {
+			if s.VJAHR < 2058 {
+				s.J = s.VJAHR - 2004
+			} else {
+				s.J = 54
+			}
+		}
+		if s.LZZ == 1 {
+			s.VBEZB = s.VBEZM.Mul(bmf.DecimalFromInt(s.ZMVB)).Add(s.VBEZS)
+			s.HFVB = must(must(tab2[s.J].Div(zahl12)).Mul(bmf.DecimalFromInt(s.ZMVB)).SetScale(0, bmf.RoundUp))
+			s.FVBZ = must(must(tab3[s.J].Div(zahl12)).Mul(bmf.DecimalFromInt(s.ZMVB)).SetScale(0, bmf.RoundUp))
+		} else {
+			s.VBEZB = must(s.VBEZM.Mul(zahl12).Add(s.VBEZS).SetScale(2, bmf.RoundDown))
+			s.HFVB = tab2[s.J]
+			s.FVBZ = tab3[s.J]
+		}
+		s.FVB = must(must(s.VBEZB.Mul(tab1[s.J]).Div(zahl100)).SetScale(2, bmf.RoundUp))
+		if s.FVB.Cmp(s.HFVB) == 1 {
+			s.FVB = s.HFVB
+		}
+		if s.FVB.Cmp(s.ZVBEZJ) == 1 {
+			s.FVB = s.ZVBEZJ
+		}
+		s.FVBSO = must(s.FVB.Add(must(s.VBEZBSO.Mul(tab1[s.J]).Div(zahl100))).SetScale(2, bmf.RoundUp))
+		if s.FVBSO.Cmp(tab2[s.J]) == 1 {
+			s.FVBSO = tab2[s.J]
+		}
+		s.HFVBZSO = must(must(s.VBEZB.Add(s.VBEZBSO).Div(zahl100)).Sub(s.FVBSO).SetScale(2, bmf.RoundDown))
+		s.FVBZSO = must(s.FVBZ.Add(must(s.VBEZBSO.Div(zahl100))).SetScale(0, bmf.RoundUp))
+		if s.FVBZSO.Cmp(s.HFVBZSO) == 1 {
+			s.FVBZSO = must(s.HFVBZSO.SetScale(0, bmf.RoundUp))
+		}
+		if s.FVBZSO.Cmp(tab3[s.J]) == 1 {
+			s.FVBZSO = tab3[s.J]
+		}
+		s.HFVBZ = must(must(s.VBEZB.Div(zahl100)).Sub(s.FVB).SetScale(2, bmf.RoundDown))
+		if s.FVBZ.Cmp(s.HFVBZ) == 1 {
+			s.FVBZ = must(s.HFVBZ.SetScale(0, bmf.RoundUp))
+		}
+	}
+	s.MRE4ALTE()
+}
+
+func (s *state) MRE4ALTE() {
+	if s.ALTER1 == 0 {
+		s.ALTE = bmf.DecimalZero
+	} else {
+		if s.AJAHR < 2006 {
+			s.K = 1
+		} else {
+			if s.AJAHR < 2058 {
+				s.K = s.AJAHR - 2004
+			} else {
+				s.K = 54
+			}
+		}
+		s.BMG = s.ZRE4J.Sub(s.ZVBEZJ)
+		s.ALTE = must(s.BMG.Mul(tab4[s.K]).SetScale(0, bmf.RoundUp))
+		s.HBALTE = tab5[s.K]
+		if s.ALTE.Cmp(s.HBALTE) == 1 {
+			s.ALTE = s.HBALTE
+		}
+	}
+}
+
+func (s *state) MRE4ABZ() {
+	s.ZRE4 = must(s.ZRE4J.Sub(s.FVB).Sub(s.ALTE).Sub(s.JLFREIB).Add(s.JLHINZU).SetScale(2, bmf.RoundDown))
+	if s.ZRE4.Cmp(bmf.DecimalZero) == -1 {
+		s.ZRE4 = bmf.DecimalZero
+	}
+	s.ZRE4VP = s.ZRE4J
+	s.ZVBEZ = must(s.ZVBEZJ.Sub(s.FVB).SetScale(2, bmf.RoundDown))
+	if s.ZVBEZ.Cmp(bmf.DecimalZero) == -1 {
+		s.ZVBEZ = bmf.DecimalZero
+	}
+}
+
+func (s *state) MBERECH() {
+	s.MZTABFB()
+	s.VFRB = must(s.ANP.Add(s.FVB.Add(s.FVBZ)).Mul(zahl100).SetScale(0, bmf.RoundDown))
+	s.MLSTJAHR()
+	s.WVFRB = must(s.ZVE.Sub(s.GFB).Mul(zahl100).SetScale(0, bmf.RoundDown))
+	if s.WVFRB.Cmp(bmf.DecimalZero) == -1 {
+		s.WVFRB = bmf.DecimalFromInt(0)
+	}
+	s.LSTJAHR = must(s.ST.Mul(bmf.DecimalFromFloat(s.F)).SetScale(0, bmf.RoundDown))
+	s.UPLSTLZZ()
+	s.UPVKVLZZ()
+	if s.ZKF.Cmp(bmf.DecimalZero) == 1 {
+		s.ZTABFB = s.ZTABFB.Add(s.KFB)
+		s.MRE4ABZ()
+		s.MLSTJAHR()
+		s.JBMG = must(s.ST.Mul(bmf.DecimalFromFloat(s.F)).SetScale(0, bmf.RoundDown))
+	} else {
+		s.JBMG = s.LSTJAHR
+	}
+	s.MSOLZ()
+}
+
+func (s *state) MZTABFB() {
+	s.ANP = bmf.DecimalZero
+	if (s.ZVBEZ.Cmp(bmf.DecimalZero) >= 0) && (s.ZVBEZ.Cmp(s.FVBZ) == -1) {
+		s.FVBZ = bmf.DecimalFromInt(s.ZVBEZ.IntValue())
+	}
+	if s.STKL < 6 {
+		if s.ZVBEZ.Cmp(bmf.DecimalZero) == 1 {
+			if s.ZVBEZ.Sub(s.FVBZ).Cmp(bmf.DecimalFromInt(102)) == -1 {
+				s.ANP = must(s.ZVBEZ.Sub(s.FVBZ).SetScale(0, bmf.RoundUp))
+			} else {
+				s.ANP = bmf.DecimalFromInt(102)
+			}
+		}
+	} else {
+		s.FVBZ = bmf.DecimalFromInt(0)
+		s.FVBZSO = bmf.DecimalFromInt(0)
+	}
+	if s.STKL < 6 {
+		if s.ZRE4.Cmp(s.ZVBEZ) == 1 {
+			if s.ZRE4.Sub(s.ZVBEZ).Cmp(bmf.DecimalFromInt(1230)) == -1 {
+				s.ANP = must(s.ANP.Add(s.ZRE4).Sub(s.ZVBEZ).SetScale(0, bmf.RoundUp))
+			} else {
+				s.ANP = s.ANP.Add(bmf.DecimalFromInt(1230))
+			}
+		}
+	}
+	s.KZTAB = 1
+	if s.STKL == 1 {
+		s.SAP = bmf.DecimalFromInt(36)
+		s.KFB = must(s.ZKF.Mul(bmf.DecimalFromInt(9600)).SetScale(0, bmf.RoundDown))
+	} else {
+		if s.STKL == 2 {
+			s.EFA = bmf.DecimalFromInt(4260)
+			s.SAP = bmf.DecimalFromInt(36)
+			s.KFB = must(s.ZKF.Mul(bmf.DecimalFromInt(9600)).SetScale(0, bmf.RoundDown))
+		} else {
+			if s.STKL == 3 {
+				s.KZTAB = 2
+				s.SAP = bmf.DecimalFromInt(36)
+				s.KFB = must(s.ZKF.Mul(bmf.DecimalFromInt(9600)).SetScale(0, bmf.RoundDown))
+			} else {
+				if s.STKL == 4 {
+					s.SAP = bmf.DecimalFromInt(36)
+					s.KFB = must(s.ZKF.Mul(bmf.DecimalFromInt(4800)).SetScale(0, bmf.RoundDown))
+				} else {
+					if s.STKL == 5 {
+						s.SAP = bmf.DecimalFromInt(36)
+						s.KFB = bmf.DecimalZero
+					} else {
+						s.KFB = bmf.DecimalZero
+					}
+				}
+			}
+		}
+	}
+	s.ZTABFB = must(s.EFA.Add(s.ANP).Add(s.SAP).Add(s.FVBZ).SetScale(2, bmf.RoundDown))
+}
+
+func (s *state) MLSTJAHR() {
+	s.UPEVP()
+	s.ZVE = s.ZRE4.Sub(s.ZTABFB).Sub(s.VSP)
+	s.UPMLST()
+}
+
+func (s *state) UPVKVLZZ() {
+	s.UPVKV()
+	s.JW = s.VKV
+	s.UPANTEIL()
+	s.VKVLZZ = s.ANTEIL1
+}
+
+func (s *state) UPVKV() {
+	if s.PKV > 0 {
+		if s.VSP2.Cmp(s.VSP3) == 1 {
+			s.VKV = s.VSP2.Mul(zahl100)
+		} else {
+			s.VKV = s.VSP3.Mul(zahl100)
+		}
+	} else {
+		s.VKV = bmf.DecimalZero
+	}
+}
+
+func (s *state) UPLSTLZZ() {
+	s.JW = s.LSTJAHR.Mul(zahl100)
+	s.UPANTEIL()
+	s.LSTLZZ = s.ANTEIL1
+}
+
+func (s *state) UPMLST() {
+	if s.ZVE.Cmp(zahl1) == -1 {
+		s.ZVE = bmf.DecimalZero
+		s.X = bmf.DecimalZero
+	} else {
+		s.X = must(s.ZVE.DivScale(bmf.DecimalFromInt(s.KZTAB), 0, bmf.RoundDown))
+	}
+	if s.STKL < 5 {
+		s.UPTAB25()
+	} else {
+		s.MST5_6()
+	}
+}
+
+func (s *state) UPEVP() {
+	if s.KRV == 1 {
+		s.VSP1 = bmf.DecimalZero
+	} else {
+		if s.ZRE4VP.Cmp(s.BBGRV) == 1 {
+			s.ZRE4VP = s.BBGRV
+		}
+		s.VSP1 = must(s.ZRE4VP.Mul(s.RVSATZAN).SetScale(2, bmf.RoundDown))
+	}
+	s.VSP2 = must(s.ZRE4VP.Mul(bmf.DecimalFromFloat(0.12)).SetScale(2, bmf.RoundDown))
+	if s.STKL == 3 {
+		s.VHB = bmf.DecimalFromInt(3000)
+	} else {
+		s.VHB = bmf.DecimalFromInt(1900)
+	}
+	if s.VSP2.Cmp(s.VHB) == 1 {
+		s.VSP2 = s.VHB
+	}
+	s.VSPN = must(s.VSP1.Add(s.VSP2).SetScale(0, bmf.RoundUp))
+	s.MVSP()
+	if s.VSPN.Cmp(s.VSP) == 1 {
+		s.VSP = must(s.VSPN.SetScale(2, bmf.RoundDown))
+	}
+}
+
+func (s *state) MVSP() {
+	if s.ZRE4VP.Cmp(s.BBGKVPV) == 1 {
+		s.ZRE4VP = s.BBGKVPV
+	}
+	if s.PKV > 0 {
+		if s.STKL == 6 {
+			s.VSP3 = bmf.DecimalZero
+		} else {
+			s.VSP3 = must(s.PKPV.Mul(zahl12).Div(zahl100))
+			if s.PKV == 2 {
+				s.VSP3 = must(s.VSP3.Sub(s.ZRE4VP.Mul(s.KVSATZAG.Add(s.PVSATZAG))).SetScale(2, bmf.RoundDown))
+			}
+		}
+	} else {
+		s.VSP3 = must(s.ZRE4VP.Mul(s.KVSATZAN.Add(s.PVSATZAN)).SetScale(2, bmf.RoundDown))
+	}
+	s.VSP = must(s.VSP3.Add(s.VSP1).SetScale(0, bmf.RoundUp))
+}
+
+func (s *state) MST5_6() {
+	s.ZZX = s.X
+	if s.ZZX.Cmp(s.W2STKL5) == 1 {
+		s.ZX = s.W2STKL5
+		s.UP5_6()
+		if s.ZZX.Cmp(s.W3STKL5) == 1 {
+			s.ST = must(s.ST.Add(s.W3STKL5.Sub(s.W2STKL5).Mul(bmf.DecimalFromFloat(0.42))).SetScale(0, bmf.RoundDown))
+			s.ST = must(s.ST.Add(s.ZZX.Sub(s.W3STKL5).Mul(bmf.DecimalFromFloat(0.45))).SetScale(0, bmf.RoundDown))
+		} else {
+			s.ST = must(s.ST.Add(s.ZZX.Sub(s.W2STKL5).Mul(bmf.DecimalFromFloat(0.42))).SetScale(0, bmf.RoundDown))
+		}
+	} else {
+		s.ZX = s.ZZX
+		s.UP5_6()
+		if s.ZZX.Cmp(s.W1STKL5) == 1 {
+			s.VERGL = s.ST
+			s.ZX = s.W1STKL5
+			s.UP5_6()
+			s.HOCH = must(s.ST.Add(s.ZZX.Sub(s.W1STKL5).Mul(bmf.DecimalFromFloat(0.42))).SetScale(0, bmf.RoundDown))
+			if s.HOCH.Cmp(s.VERGL) == -1 {
+				s.ST = s.HOCH
+			} else {
+				s.ST = s.VERGL
+			}
+		}
+	}
+}
+
+func (s *state) UP5_6() {
+	s.X = must(s.ZX.Mul(bmf.DecimalFromFloat(1.25)).SetScale(2, bmf.RoundDown))
+	s.UPTAB25()
+	s.ST1 = s.ST
+	s.X = must(s.ZX.Mul(bmf.DecimalFromFloat(0.75)).SetScale(2, bmf.RoundDown))
+	s.UPTAB25()
+	s.ST2 = s.ST
+	s.DIFF = s.ST1.Sub(s.ST2).Mul(zahl2)
+	s.MIST = must(s.ZX.Mul(bmf.DecimalFromFloat(0.14)).SetScale(0, bmf.RoundDown))
+	if s.MIST.Cmp(s.DIFF) == 1 {
+		s.ST = s.MIST
+	} else {
+		s.ST = s.DIFF
+	}
+}
+
+func (s *state) MSOLZ() {
+	s.SOLZFREI = s.SOLZFREI.Mul(bmf.DecimalFromInt(s.KZTAB))
+	if s.JBMG.Cmp(s.SOLZFREI) == 1 {
+		s.SOLZJ = must(must(s.JBMG.Mul(bmf.DecimalFromFloat(5.5)).Div(zahl100)).SetScale(2, bmf.RoundDown))
+		s.SOLZMIN = must(must(s.JBMG.Sub(s.SOLZFREI).Mul(bmf.DecimalFromFloat(11.9)).Div(zahl100)).SetScale(2, bmf.RoundDown))
+		if s.SOLZMIN.Cmp(s.SOLZJ) == -1 {
+			s.SOLZJ = s.SOLZMIN
+		}
+		s.JW = must(s.SOLZJ.Mul(zahl100).SetScale(0, bmf.RoundDown))
+		s.UPANTEIL()
+		s.SOLZLZZ = s.ANTEIL1
+	} else {
+		s.SOLZLZZ = bmf.DecimalZero
+	}
+	if s.R > 0 {
+		s.JW = s.JBMG.Mul(zahl100)
+		s.UPANTEIL()
+		s.BK = s.ANTEIL1
+	} else {
+		s.BK = bmf.DecimalZero
+	}
+}
+
+func (s *state) UPANTEIL() {
+	if s.LZZ == 1 {
+		s.ANTEIL1 = s.JW
+	} else {
+		if s.LZZ == 2 {
+			s.ANTEIL1 = must(s.JW.DivScale(zahl12, 0, bmf.RoundDown))
+		} else {
+			if s.LZZ == 3 {
+				s.ANTEIL1 = must(s.JW.Mul(zahl7).DivScale(zahl360, 0, bmf.RoundDown))
+			} else {
+				s.ANTEIL1 = must(s.JW.DivScale(zahl360, 0, bmf.RoundDown))
+			}
+		}
+	}
+}
+
+func (s *state) MSONST() {
+	s.LZZ = 1
+	if s.ZMVB == 0 {
+		s.ZMVB = 12
+	}
+	if (s.SONSTB.Cmp(bmf.DecimalZero) == 0) && (s.MBV.Cmp(bmf.DecimalZero) == 0) {
+		s.VKVSONST = bmf.DecimalZero
+		s.LSTSO = bmf.DecimalZero
+		s.STS = bmf.DecimalZero
+		s.SOLZS = bmf.DecimalZero
+		s.BKS = bmf.DecimalZero
+	} else {
+		s.MOSONST()
+		s.UPVKV()
+		s.VKVSONST = s.VKV
+		s.ZRE4J = must(must(s.JRE4.Add(s.SONSTB).Div(zahl100)).SetScale(2, bmf.RoundDown))
+		s.ZVBEZJ = must(must(s.JVBEZ.Add(s.VBS).Div(zahl100)).SetScale(2, bmf.RoundDown))
+		s.VBEZBSO = s.STERBE
+		s.MRE4SONST()
+		s.MLSTJAHR()
+		s.WVFRBM = must(s.ZVE.Sub(s.GFB).Mul(zahl100).SetScale(2, bmf.RoundDown))
+		if s.WVFRBM.Cmp(bmf.DecimalZero) == -1 {
+			s.WVFRBM = bmf.DecimalZero
+		}
+		s.UPVKV()
+		s.VKVSONST = s.VKV.Sub(s.VKVSONST)
+		s.LSTSO = s.ST.Mul(zahl100)
+		s.STS = must(s.LSTSO.Sub(s.LSTOSO).Mul(bmf.DecimalFromFloat(s.F)).DivScale(zahl100, 0, bmf.RoundDown)).Mul(zahl100)
+		s.STSMIN()
+	}
+}
+
+func (s *state) STSMIN() {
+	if s.STS.Cmp(bmf.DecimalZero) == -1 {
+		if s.MBV.Cmp(bmf.DecimalZero) == 0 {
+		} else {
+			s.LSTLZZ = s.LSTLZZ.Add(s.STS)
+			if s.LSTLZZ.Cmp(bmf.DecimalZero) == -1 {
+				s.LSTLZZ = bmf.DecimalZero
+			}
+			s.SOLZLZZ = must(s.SOLZLZZ.Add(s.STS.Mul(must(bmf.DecimalFromFloat(5.5).Div(zahl100)))).SetScale(0, bmf.RoundDown))
+			if s.SOLZLZZ.Cmp(bmf.DecimalZero) == -1 {
+				s.SOLZLZZ = bmf.DecimalZero
+			}
+			s.BK = s.BK.Add(s.STS)
+			if s.BK.Cmp(bmf.DecimalZero) == -1 {
+				s.BK = bmf.DecimalZero
+			}
+		}
+		s.STS = bmf.DecimalZero
+		s.SOLZS = bmf.DecimalZero
+	} else {
+		s.MSOLZSTS()
+	}
+	if s.R > 0 {
+		s.BKS = s.STS
+	} else {
+		s.BKS = bmf.DecimalZero
+	}
+}
+
+func (s *state) MSOLZSTS() {
+	if s.ZKF.Cmp(bmf.DecimalZero) == 1 {
+		s.SOLZSZVE = s.ZVE.Sub(s.KFB)
+	} else {
+		s.SOLZSZVE = s.ZVE
+	}
+	if s.SOLZSZVE.Cmp(bmf.DecimalOne) == -1 {
+		s.SOLZSZVE = bmf.DecimalZero
+		s.X = bmf.DecimalZero
+	} else {
+		s.X = must(s.SOLZSZVE.DivScale(bmf.DecimalFromInt(s.KZTAB), 0, bmf.RoundDown))
+	}
+	if s.STKL < 5 {
+		s.UPTAB25()
+	} else {
+		s.MST5_6()
+	}
+	s.SOLZSBMG = must(s.ST.Mul(bmf.DecimalFromFloat(s.F)).SetScale(0, bmf.RoundDown))
+	if s.SOLZSBMG.Cmp(s.SOLZFREI) == 1 {
+		s.SOLZS = must(s.STS.Mul(bmf.DecimalFromFloat(5.5)).DivScale(zahl100, 0, bmf.RoundDown))
+	} else {
+		s.SOLZS = bmf.DecimalZero
+	}
+}
+
+func (s *state) MOSONST() {
+	s.ZRE4J = must(must(s.JRE4.Div(zahl100)).SetScale(2, bmf.RoundDown))
+	s.ZVBEZJ = must(must(s.JVBEZ.Div(zahl100)).SetScale(2, bmf.RoundDown))
+	s.JLFREIB = must(s.JFREIB.DivScale(zahl100, 2, bmf.RoundDown))
+	s.JLHINZU = must(s.JHINZU.DivScale(zahl100, 2, bmf.RoundDown))
+	s.MRE4()
+	s.MRE4ABZ()
+	s.ZRE4VP = s.ZRE4VP.Sub(must(s.JRE4ENT.Div(zahl100)))
+	s.MZTABFB()
+	s.VFRBS1 = must(s.ANP.Add(s.FVB.Add(s.FVBZ)).Mul(zahl100).SetScale(2, bmf.RoundDown))
+	s.MLSTJAHR()
+	s.WVFRBO = must(s.ZVE.Sub(s.GFB).Mul(zahl100).SetScale(2, bmf.RoundDown))
+	if s.WVFRBO.Cmp(bmf.DecimalZero) == -1 {
+		s.WVFRBO = bmf.DecimalZero
+	}
+	s.LSTOSO = s.ST.Mul(zahl100)
+}
+
+func (s *state) MRE4SONST() {
+	s.MRE4()
+	s.FVB = s.FVBSO
+	s.MRE4ABZ()
+	s.ZRE4VP = s.ZRE4VP.Add(must(s.MBV.Div(zahl100))).Sub(must(s.JRE4ENT.Div(zahl100))).Sub(must(s.SONSTENT.Div(zahl100)))
+	s.FVBZ = s.FVBZSO
+	s.MZTABFB()
+	s.VFRBS2 = s.ANP.Add(s.FVB).Add(s.FVBZ).Mul(zahl100).Sub(s.VFRBS1)
+}
+
+func (s *state) UPTAB25() {
+	if s.X.Cmp(s.GFB.Add(zahl1)) == -1 {
+		s.ST = bmf.DecimalZero
+	} else {
+		if s.X.Cmp(bmf.DecimalFromInt(17444)) == -1 {
+			s.Y = must(s.X.Sub(s.GFB).DivScale(zahl10000, 6, bmf.RoundDown))
+			s.RW = s.Y.Mul(bmf.DecimalFromFloat(932.30))
+			s.RW = s.RW.Add(bmf.DecimalFromInt(1400))
+			s.ST = must(s.RW.Mul(s.Y).SetScale(0, bmf.RoundDown))
+		} else {
+			if s.X.Cmp(bmf.DecimalFromInt(68481)) == -1 {
+				s.Y = must(s.X.Sub(bmf.DecimalFromInt(17443)).DivScale(zahl10000, 6, bmf.RoundDown))
+				s.RW = s.Y.Mul(bmf.DecimalFromFloat(176.64))
+				s.RW = s.RW.Add(bmf.DecimalFromInt(2397))
+				s.RW = s.RW.Mul(s.Y)
+				s.ST = must(s.RW.Add(bmf.DecimalFromFloat(1015.13)).SetScale(0, bmf.RoundDown))
+			} else {
+				if s.X.Cmp(bmf.DecimalFromInt(277826)) == -1 {
+					s.ST = must(s.X.Mul(bmf.DecimalFromFloat(0.42)).Sub(bmf.DecimalFromFloat(10911.92)).SetScale(0, bmf.RoundDown))
+				} else {
+					s.ST = must(s.X.Mul(bmf.DecimalFromFloat(0.45)).Sub(bmf.DecimalFromFloat(19246.67)).SetScale(0, bmf.RoundDown))
+				}
+			}
+		}
+	}
+	s.ST = s.ST.Mul(bmf.DecimalFromInt(s.KZTAB))
+}
+
+// must unwraps a Decimal operation that can fail, like a BigDecimal call
+// that throws, so a PAP expression stays one expression. The panic is
+// recovered by Lohnsteuer2025 and never leaves this package.
+func must(d bmf.Decimal, err error) bmf.Decimal {
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func intValue(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case bmf.Decimal:
+		return v.IntValue(), nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to int", value)
+}
+
+func floatValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bmf.Decimal:
+		f, _ := v.Rat().Float64()
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to float64", value)
+}
+
+func decimalValue(value interface{}) (bmf.Decimal, error) {
+	switch v := value.(type) {
+	case int:
+		return bmf.DecimalFromInt(v), nil
+	case float64:
+		return bmf.DecimalFromFloat(v), nil
+	case bmf.Decimal:
+		return v, nil
+	}
+	return bmf.Decimal{}, fmt.Errorf("cannot convert %T to bmf.Decimal", value)
+}
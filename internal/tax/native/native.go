@@ -0,0 +1,46 @@
+// Package native holds the embedded PAPs translated to Go by papgen. Each
+// tax year is a plain Go package, so the code of two years can be diffed and
+// every expression is checked by the compiler. Run go generate in this
+// directory after changing a PAP or the generator.
+package native
+
+//go:generate go run ../../../cmd/papgen -pap ../bmf/paps/Lohnsteuer2024.xml -out lohnsteuer2024/lohnsteuer2024.go
+//go:generate go run ../../../cmd/papgen -pap ../bmf/paps/Lohnsteuer2024Version2.xml -out lohnsteuer2024version2/lohnsteuer2024version2.go
+//go:generate go run ../../../cmd/papgen -pap ../bmf/paps/Lohnsteuer2025.xml -out lohnsteuer2025/lohnsteuer2025.go
+
+import (
+	"tax-calculator/internal/tax/native/lohnsteuer2024"
+	"tax-calculator/internal/tax/native/lohnsteuer2024version2"
+	"tax-calculator/internal/tax/native/lohnsteuer2025"
+)
+
+// CalculateFunc runs a generated PAP on inputs keyed by their PAP names and
+// returns the outputs the same way, like bmf.Program.Calculate
+type CalculateFunc func(inputs map[string]interface{}) (map[string]interface{}, error)
+
+var calculators = map[string]CalculateFunc{
+	"2024Version1": lohnsteuer2024.Calculate,
+	"2024Version2": lohnsteuer2024version2.Calculate,
+	"2025Version1": lohnsteuer2025.Calculate,
+}
+
+var outputNames = map[string]func() []string{
+	"2024Version1": lohnsteuer2024.OutputNames,
+	"2024Version2": lohnsteuer2024version2.OutputNames,
+	"2025Version1": lohnsteuer2025.OutputNames,
+}
+
+// Lookup returns the generated code for a PAP version such as "2025Version1"
+func Lookup(version string) (CalculateFunc, bool) {
+	calculate, ok := calculators[version]
+	return calculate, ok
+}
+
+// OutputNames returns the outputs of a PAP version in the order the PAP
+// declares them
+func OutputNames(version string) []string {
+	if names, ok := outputNames[version]; ok {
+		return names()
+	}
+	return nil
+}
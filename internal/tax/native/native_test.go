@@ -0,0 +1,147 @@
+package native
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
+	"tax-calculator/internal/tax/papgen"
+)
+
+func embeddedVersions(t *testing.T) []bmf.PAPVersion {
+	t.Helper()
+	var versions []bmf.PAPVersion
+	for _, year := range bmf.DefaultPAPRegistry.Years() {
+		versions = append(versions, bmf.DefaultPAPRegistry.Versions(year)...)
+	}
+	if len(versions) == 0 {
+		t.Fatal("Expected embedded PAP versions")
+	}
+	return versions
+}
+
+func TestGeneratedCodeIsUpToDate(t *testing.T) {
+	for _, version := range embeddedVersions(t) {
+		papFile := filepath.Join("..", "bmf", version.File)
+		papData, err := bmf.LoadPAPFile(papFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pkg, fn := papgen.NamesFor(papFile)
+		want, err := papgen.Generate(papData, papgen.Options{Package: pkg, Func: fn, Source: filepath.Base(papFile)})
+		if err != nil {
+			t.Fatalf("%s: %v", version.Version, err)
+		}
+		got, err := os.ReadFile(filepath.Join(pkg, pkg+".go"))
+		if err != nil {
+			t.Fatalf("%s: %v", version.Version, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: generated code is out of date, run go generate in internal/tax/native", version.Version)
+		}
+	}
+}
+
+func TestGeneratedCodeMatchesCompiledPAP(t *testing.T) {
+	t.Setenv(bmf.PAPDirEnv, "")
+	registry := bmf.NewPAPRegistry()
+
+	requests := []models.TaxRequest{}
+	for taxClass := models.TaxClass1; taxClass <= models.TaxClass6; taxClass++ {
+		for _, income := range []int{0, 1200000, 4500000, 9000000, 30000000} {
+			requests = append(requests, models.TaxRequest{Period: models.Year, Income: income, TaxClass: taxClass, KVZ: 2.5})
+		}
+	}
+	requests = append(requests,
+		models.TaxRequest{Period: models.Month, Income: 450000, TaxClass: models.TaxClass3, R: 1, ZKF: 1.5, PVS: 1, PVZ: 1},
+		models.TaxRequest{Period: models.Week, Income: 90000, TaxClass: models.TaxClass2, ZKF: 1, PVA: 1},
+		models.TaxRequest{Period: models.Day, Income: 15000, TaxClass: models.TaxClass5, KRV: 1},
+		models.TaxRequest{Period: models.Year, Income: 6000000, TaxClass: models.TaxClass1, PKV: 1, PKPV: 350, ALTER1: 1, AJAHR: 2020},
+		models.TaxRequest{Period: models.Year, Income: 2400000, TaxClass: models.TaxClass4, VBEZ: 1200000, VJAHR: 2019, ZMVB: 12},
+	)
+
+	for _, version := range embeddedVersions(t) {
+		calculate, ok := Lookup(version.Version)
+		if !ok {
+			t.Errorf("Expected generated code for %s", version.Version)
+			continue
+		}
+
+		papData, err := registry.LoadVersion(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		program, err := bmf.Compile(papData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, req := range requests {
+			inputs := make(map[string]interface{})
+			for _, input := range bmf.RequestInputs(req) {
+				inputs[input.Name] = input.Value
+			}
+
+			want, err := program.Calculate(inputs)
+			if err != nil {
+				t.Fatalf("%s: compiled PAP failed: %v", version.Version, err)
+			}
+			got, err := calculate(inputs)
+			if err != nil {
+				t.Fatalf("%s: generated code failed: %v", version.Version, err)
+			}
+
+			if len(got) != len(want) {
+				t.Errorf("%s: expected %d outputs, got %d", version.Version, len(want), len(got))
+			}
+			for name, value := range want {
+				if fmt.Sprint(got[name]) != fmt.Sprint(value) {
+					t.Errorf("%s %+v: %s: expected %v, got %v", version.Version, req, name, value, got[name])
+				}
+			}
+		}
+	}
+}
+
+func TestGeneratedCodeInputErrors(t *testing.T) {
+	calculate, ok := Lookup("2025Version1")
+	if !ok {
+		t.Fatal("Expected generated code for 2025Version1")
+	}
+	if _, err := calculate(map[string]interface{}{"STKL": "x"}); err == nil || err.Error() != "input STKL: cannot convert string to int" {
+		t.Errorf("Expected an input conversion error, got: %v", err)
+	}
+	if _, ok := Lookup("1999Version1"); ok {
+		t.Error("Expected no generated code for 1999")
+	}
+}
+
+func TestOutputNamesAreCopies(t *testing.T) {
+	names := OutputNames("2025Version1")
+	if len(names) == 0 {
+		t.Fatal("Expected the outputs of 2025Version1")
+	}
+	first := names[0]
+	names[0] = "changed"
+	if got := OutputNames("2025Version1")[0]; got != first {
+		t.Errorf("Expected changing the names not to change later calls, got %q", got)
+	}
+	if OutputNames("1999Version1") != nil {
+		t.Error("Expected no outputs for 1999")
+	}
+}
+
+func BenchmarkGeneratedCode(b *testing.B) {
+	calculate, _ := Lookup("2025Version1")
+	for i := 0; i < b.N; i++ {
+		inputs := map[string]interface{}{"LZZ": 1, "STKL": 1, "KVZ": 2.5, "RE4": 2000000 + i%8000000}
+		if _, err := calculate(inputs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
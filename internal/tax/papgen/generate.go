@@ -0,0 +1,1056 @@
+// Package papgen turns a BMF PAP into Go source: a package with typed Inputs
+// and Outputs and one function that runs the PAP with exact decimal
+// arithmetic. The generated code mirrors the PAP statement by statement, so
+// two years can be compared with an ordinary diff.
+//
+// The constants of the PAP, such as the TAB1 to TAB5 tables, become
+// unexported package variables, so no importer can change them under a
+// later calculation.
+//
+// A PAP expression chains BigDecimal calls that can throw, e.g. a divide by
+// zero. The generated code keeps each expression on one line as the PAP
+// writes it and wraps such calls in must, which panics with the error; the
+// tax function recovers it and returns it as its error. Plain error returns
+// would split every expression into a statement per call and lose the
+// line-by-line correspondence with the PAP. The panics never leave the
+// generated package.
+package papgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+// DefaultBMFImport is the import path of the package providing bmf.Decimal
+const DefaultBMFImport = "tax-calculator/internal/tax/bmf"
+
+// Options controls the generated package
+type Options struct {
+	// Package and Func name the package and the tax function, e.g.
+	// lohnsteuer2025 and Lohnsteuer2025. NamesFor derives both from a file name.
+	Package string
+	Func    string
+
+	// Source is mentioned in the header of the generated file
+	Source string
+
+	// BMFImport defaults to DefaultBMFImport
+	BMFImport string
+}
+
+// NamesFor returns the package and function name for a PAP file such as
+// Lohnsteuer2024Version2.xml
+func NamesFor(file string) (pkg string, fn string) {
+	fn = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	fn = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, fn)
+	if fn == "" || !unicode.IsLetter(rune(fn[0])) {
+		fn = "PAP" + fn
+	}
+	fn = exported(fn)
+	return strings.ToLower(fn), fn
+}
+
+type goType int
+
+const (
+	tInvalid goType = iota
+	tInt
+	tFloat
+	tBool
+	tDecimal
+	tDecimalArray
+	tIntArray
+	tClass
+)
+
+var goTypeNames = map[goType]string{
+	tInt:          "int",
+	tFloat:        "float64",
+	tBool:         "bool",
+	tDecimal:      "bmf.Decimal",
+	tDecimalArray: "[]bmf.Decimal",
+	tIntArray:     "[]int",
+}
+
+func (t goType) String() string {
+	if t == tClass {
+		return "BigDecimal"
+	}
+	if name, ok := goTypeNames[t]; ok {
+		return name
+	}
+	return "invalid"
+}
+
+func parseType(papType string) (goType, error) {
+	switch papType {
+	case "int", "long":
+		return tInt, nil
+	case "double":
+		return tFloat, nil
+	case "boolean":
+		return tBool, nil
+	case "BigDecimal":
+		return tDecimal, nil
+	case "BigDecimal[]":
+		return tDecimalArray, nil
+	case "int[]":
+		return tIntArray, nil
+	}
+	return tInvalid, fmt.Errorf("unsupported type %q", papType)
+}
+
+var roundingModes = map[string]string{
+	"ROUND_UP":          "bmf.RoundUp",
+	"ROUND_DOWN":        "bmf.RoundDown",
+	"ROUND_CEILING":     "bmf.RoundCeiling",
+	"ROUND_FLOOR":       "bmf.RoundFloor",
+	"ROUND_HALF_UP":     "bmf.RoundHalfUp",
+	"ROUND_HALF_DOWN":   "bmf.RoundHalfDown",
+	"ROUND_HALF_EVEN":   "bmf.RoundHalfEven",
+	"ROUND_UNNECESSARY": "bmf.RoundUnnecessary",
+}
+
+type variableKind int
+
+const (
+	inputVariable variableKind = iota
+	outputVariable
+	internalVariable
+	constantVariable
+)
+
+type variable struct {
+	name    string
+	field   string
+	typ     goType
+	kind    variableKind
+	papType string
+	def     string
+}
+
+// ref is how generated code refers to the variable
+func (v *variable) ref() string {
+	if v.kind == constantVariable {
+		return v.field
+	}
+	return "s." + v.field
+}
+
+type generator struct {
+	opts      Options
+	pap       *bmf.PAPData
+	variables map[string]*variable
+	ordered   []*variable
+	methods   map[string]*bmf.PAPMethod
+	buf       bytes.Buffer
+}
+
+// Generate returns the gofmt'ed source of the package for a PAP
+func Generate(papData *bmf.PAPData, opts Options) ([]byte, error) {
+	if opts.BMFImport == "" {
+		opts.BMFImport = DefaultBMFImport
+	}
+	if opts.Package == "" || opts.Func == "" {
+		pkg, fn := NamesFor(papData.Name)
+		if opts.Package == "" {
+			opts.Package = pkg
+		}
+		if opts.Func == "" {
+			opts.Func = fn
+		}
+	}
+
+	g := &generator{
+		opts:      opts,
+		pap:       papData,
+		variables: make(map[string]*variable),
+		methods:   make(map[string]*bmf.PAPMethod),
+	}
+	if err := g.declare(); err != nil {
+		return nil, err
+	}
+	if err := g.generate(); err != nil {
+		return nil, err
+	}
+
+	source, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go: %w", err)
+	}
+	return source, nil
+}
+
+// declare collects variables, constants and methods and checks that their
+// Go names do not clash
+func (g *generator) declare() error {
+	if len(g.pap.Methods.Main) == 0 {
+		return fmt.Errorf("no main method found in XML data")
+	}
+
+	// Names the generated file uses itself
+	taken := map[string]string{
+		g.opts.Func: "the tax function", "Inputs": "a type", "Outputs": "a type",
+		"DefaultInputs": "a function", "Calculate": "a function", "OutputNames": "a function",
+		"state": "a type", "newState": "a function", "run": "a method", "must": "a function",
+		"intValue": "a function", "floatValue": "a function", "decimalValue": "a function",
+		"bmf": "the bmf package", "fmt": "the fmt package",
+	}
+	// Predeclared names the generated file uses, which a lower-cased
+	// constant would shadow
+	for _, name := range []string{"bool", "error", "false", "float64", "int", "interface", "nil", "panic", "recover", "string", "true"} {
+		taken[name] = "a predeclared name"
+	}
+	fields := make(map[string]string)
+
+	add := func(name, papType, def string, kind variableKind) error {
+		if _, ok := g.variables[name]; ok {
+			return fmt.Errorf("%s is declared twice", name)
+		}
+		typ, err := parseType(papType)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		v := &variable{name: name, field: name, typ: typ, kind: kind, papType: papType, def: def}
+		switch kind {
+		case inputVariable, outputVariable:
+			v.field = exported(name)
+		case constantVariable:
+			v.field = strings.ToLower(name)
+		}
+		if !isIdentifier(v.field) {
+			return fmt.Errorf("%s is not a valid Go name", name)
+		}
+
+		used := taken
+		if kind != constantVariable {
+			used = fields
+		}
+		if other, ok := used[v.field]; ok {
+			return fmt.Errorf("%s clashes with %s as %s", name, other, v.field)
+		}
+		used[v.field] = name
+
+		g.variables[name] = v
+		g.ordered = append(g.ordered, v)
+		return nil
+	}
+
+	for _, input := range g.pap.Variables.Inputs.Input {
+		if err := add(input.Name, input.Type, input.Default, inputVariable); err != nil {
+			return err
+		}
+	}
+	for _, output := range g.pap.Variables.Outputs.Output {
+		if err := add(output.Name, output.Type, output.Default, outputVariable); err != nil {
+			return err
+		}
+	}
+	for _, internal := range g.pap.Variables.Internals.Internal {
+		if err := add(internal.Name, internal.Type, internal.Default, internalVariable); err != nil {
+			return err
+		}
+	}
+	for _, constant := range g.pap.Constants.Constant {
+		if err := add(constant.Name, constant.Type, constant.Value, constantVariable); err != nil {
+			return err
+		}
+	}
+
+	for i := range g.pap.Methods.Method {
+		method := &g.pap.Methods.Method[i]
+		if _, ok := g.methods[method.Name]; ok {
+			continue
+		}
+		if !isIdentifier(method.Name) {
+			return fmt.Errorf("method %s is not a valid Go name", method.Name)
+		}
+		if other, ok := fields[method.Name]; ok {
+			return fmt.Errorf("method %s clashes with variable %s", method.Name, other)
+		}
+		if method.Name == "run" || method.Name == "Inputs" || method.Name == "Outputs" {
+			return fmt.Errorf("method %s clashes with the generated state", method.Name)
+		}
+		g.methods[method.Name] = method
+	}
+	return nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate() error {
+	source := g.opts.Source
+	if source == "" {
+		source = "a BMF PAP"
+	}
+	name := g.pap.Name
+	if g.pap.Version != "" {
+		name += " version " + g.pap.Version
+	}
+
+	g.printf("// Code generated by papgen from %s; DO NOT EDIT.\n\n", source)
+	g.printf("// Package %s is the PAP %s as Go code.\n", g.opts.Package, name)
+	g.printf("package %s\n\n", g.opts.Package)
+	g.printf("import (\n\t\"fmt\"\n\n\t%q\n)\n\n", g.opts.BMFImport)
+
+	g.generateStruct("Inputs", "Inputs are the input variables of the PAP.", inputVariable)
+	g.generateStruct("Outputs", "Outputs are the output variables of the PAP.", outputVariable)
+
+	if err := g.generateConstants(); err != nil {
+		return err
+	}
+	if err := g.generateDefaultInputs(); err != nil {
+		return err
+	}
+	if err := g.generateState(); err != nil {
+		return err
+	}
+	g.generateFunc()
+	g.generateCalculate()
+
+	if err := g.generateMethod("run", g.pap.Methods.Main[0].Statements); err != nil {
+		return fmt.Errorf("MAIN: %w", err)
+	}
+	for i := range g.pap.Methods.Method {
+		method := &g.pap.Methods.Method[i]
+		if g.methods[method.Name] != method {
+			// Like the interpreter, only the first method of a name runs
+			continue
+		}
+		if err := g.generateMethod(method.Name, method.Statements); err != nil {
+			return fmt.Errorf("method %s: %w", method.Name, err)
+		}
+	}
+
+	g.generateHelpers()
+	return nil
+}
+
+func (g *generator) generateStruct(name, doc string, kind variableKind) {
+	g.printf("// %s\ntype %s struct {\n", doc, name)
+	for _, v := range g.ordered {
+		if v.kind == kind {
+			g.printf("\t%s %s // %s\n", v.field, v.typ, v.name)
+		}
+	}
+	g.printf("}\n\n")
+}
+
+func (g *generator) generateConstants() error {
+	var constants []*variable
+	for _, v := range g.ordered {
+		if v.kind == constantVariable {
+			constants = append(constants, v)
+		}
+	}
+	if len(constants) == 0 {
+		return nil
+	}
+
+	g.printf("// Constants of the PAP\nvar (\n")
+	for _, v := range constants {
+		value, err := g.value(v.def, v.typ)
+		if err != nil {
+			return fmt.Errorf("constant %s: %w", v.name, err)
+		}
+		g.printf("\t%s = %s\n", v.field, value)
+	}
+	g.printf(")\n\n")
+	return nil
+}
+
+func (g *generator) generateDefaultInputs() error {
+	g.printf("// DefaultInputs returns Inputs with the defaults the PAP declares\nfunc DefaultInputs() Inputs {\n\treturn Inputs{\n")
+	for _, v := range g.ordered {
+		if v.kind != inputVariable || v.def == "" {
+			continue
+		}
+		value, err := g.value(v.def, v.typ)
+		if err != nil {
+			return fmt.Errorf("input %s: %w", v.name, err)
+		}
+		g.printf("\t\t%s: %s,\n", v.field, value)
+	}
+	g.printf("\t}\n}\n\n")
+	return nil
+}
+
+func (g *generator) generateState() error {
+	g.printf("type state struct {\n\tInputs\n\tOutputs\n\n")
+	for _, v := range g.ordered {
+		if v.kind == internalVariable {
+			g.printf("\t%s %s\n", v.field, v.typ)
+		}
+	}
+	g.printf("}\n\n")
+
+	g.printf("func newState(in Inputs) *state {\n\ts := &state{Inputs: in}\n")
+	for _, v := range g.ordered {
+		if (v.kind != outputVariable && v.kind != internalVariable) || v.def == "" {
+			continue
+		}
+		value, err := g.value(v.def, v.typ)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.name, err)
+		}
+		g.printf("\ts.%s = %s\n", v.field, value)
+	}
+	g.printf("\treturn s\n}\n\n")
+	return nil
+}
+
+func (g *generator) generateFunc() {
+	g.printf(`// %[1]s runs the PAP. It fails on the errors BigDecimal would throw,
+// such as a division by zero, which must raises as panics and this function
+// recovers.
+func %[1]s(in Inputs) (out Outputs, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = fmt.Errorf("%[2]s: %%w", e)
+				return
+			}
+			err = fmt.Errorf("%[2]s: %%v", r)
+		}
+	}()
+
+	s := newState(in)
+	s.run()
+	return s.Outputs, nil
+}
+
+`, g.opts.Func, g.opts.Package)
+}
+
+func (g *generator) generateCalculate() {
+	g.printf("// Calculate runs %s on values keyed by their PAP names, such as the\n", g.opts.Func)
+	g.printf("// inputs of bmf.RequestInputs, and returns the outputs the same way.\n")
+	g.printf("// Unknown names are ignored.\n")
+	g.printf("func Calculate(values map[string]interface{}) (map[string]interface{}, error) {\n")
+	g.printf("\tin := DefaultInputs()\n\tvar err error\n")
+	for _, v := range g.ordered {
+		if v.kind != inputVariable {
+			continue
+		}
+		convert, ok := map[goType]string{tInt: "intValue", tFloat: "floatValue", tDecimal: "decimalValue"}[v.typ]
+		if !ok {
+			g.printf("\tif value, ok := values[%q]; ok {\n\t\tif in.%s, ok = value.(%s); !ok {\n", v.name, v.field, v.typ)
+			g.printf("\t\t\treturn nil, fmt.Errorf(\"input %s: cannot convert %%T to %s\", value)\n\t\t}\n\t}\n", v.name, v.typ)
+			continue
+		}
+		g.printf("\tif value, ok := values[%q]; ok {\n\t\tif in.%s, err = %s(value); err != nil {\n", v.name, v.field, convert)
+		g.printf("\t\t\treturn nil, fmt.Errorf(\"input %s: %%w\", err)\n\t\t}\n\t}\n", v.name)
+	}
+	g.printf("\n\tout, err := %s(in)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n", g.opts.Func)
+	g.printf("\treturn map[string]interface{}{\n")
+	for _, v := range g.ordered {
+		if v.kind == outputVariable {
+			g.printf("\t\t%q: out.%s,\n", v.name, v.field)
+		}
+	}
+	g.printf("\t}, nil\n}\n\n")
+
+	g.printf("// OutputNames lists the outputs Calculate returns in the order the PAP\n// declares them\n")
+	g.printf("func OutputNames() []string {\n\treturn []string{\n")
+	for _, v := range g.ordered {
+		if v.kind == outputVariable {
+			g.printf("\t\t%q,\n", v.name)
+		}
+	}
+	g.printf("\t}\n}\n\n")
+}
+
+func (g *generator) generateHelpers() {
+	g.printf(`// must unwraps a Decimal operation that can fail, like a BigDecimal call
+// that throws, so a PAP expression stays one expression. The panic is
+// recovered by %[1]s and never leaves this package.
+func must(d bmf.Decimal, err error) bmf.Decimal {
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func intValue(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case bmf.Decimal:
+		return v.IntValue(), nil
+	}
+	return 0, fmt.Errorf("cannot convert %%T to int", value)
+}
+
+func floatValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bmf.Decimal:
+		f, _ := v.Rat().Float64()
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %%T to float64", value)
+}
+
+func decimalValue(value interface{}) (bmf.Decimal, error) {
+	switch v := value.(type) {
+	case int:
+		return bmf.DecimalFromInt(v), nil
+	case float64:
+		return bmf.DecimalFromFloat(v), nil
+	case bmf.Decimal:
+		return v, nil
+	}
+	return bmf.Decimal{}, fmt.Errorf("cannot convert %%T to bmf.Decimal", value)
+}
+`, g.opts.Func)
+}
+
+func (g *generator) generateMethod(name string, statements []bmf.Statement) error {
+	g.printf("func (s *state) %s() {\n", name)
+	if err := g.statements(statements); err != nil {
+		return err
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+func (g *generator) statements(statements []bmf.Statement) error {
+	for _, statement := range statements {
+		if err := g.statement(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) statement(statement bmf.Statement) error {
+	switch s := statement.(type) {
+	case *bmf.ExecuteStatement:
+		if _, ok := g.methods[s.Method]; !ok {
+			return fmt.Errorf("method %s not found", s.Method)
+		}
+		g.printf("s.%s()\n", s.Method)
+
+	case *bmf.EvalStatement:
+		if s.Assignment == nil {
+			return fmt.Errorf("EVAL without exec attribute is not supported")
+		}
+		target, targetType, err := g.assignTarget(s.Assignment.Target)
+		if err != nil {
+			return fmt.Errorf("%q: %w", s.Exec, err)
+		}
+		value, typ, err := g.expr(s.Assignment.Value)
+		if err != nil {
+			return fmt.Errorf("%q: %w", s.Exec, err)
+		}
+		value, err = convert(value, typ, targetType)
+		if err != nil {
+			return fmt.Errorf("%q: %w", s.Exec, err)
+		}
+		g.printf("%s = %s\n", target, trimParens(value))
+
+	case *bmf.IfStatement:
+		if s.Condition == nil {
+			return fmt.Errorf("IF without expr attribute is not supported")
+		}
+		cond, typ, err := g.expr(s.Condition)
+		if err != nil {
+			return fmt.Errorf("%q: %w", s.Expr, err)
+		}
+		if typ != tBool {
+			return fmt.Errorf("%q: condition is %s, not boolean", s.Expr, typ)
+		}
+		g.printf("if %s {\n", trimParens(cond))
+		if err := g.statements(s.Then); err != nil {
+			return err
+		}
+		if len(s.Else) > 0 {
+			g.printf("} else {\n")
+			if err := g.statements(s.Else); err != nil {
+				return err
+			}
+		}
+		g.printf("}\n")
+
+	case *bmf.CompareStatement:
+		return fmt.Errorf("COMPARE is not supported")
+
+	case *bmf.BausteinFinishStatement:
+		// End of a building block, nothing to generate
+
+	default:
+		return fmt.Errorf("unsupported statement %T", statement)
+	}
+	return nil
+}
+
+// assignTarget generates the left side of an assignment, a variable or an
+// element of an array, and reports its type
+func (g *generator) assignTarget(target bmf.Expr) (string, goType, error) {
+	name := target
+	if element, ok := target.(*bmf.IndexExpr); ok {
+		name = element.Array
+	}
+	ident, ok := name.(*bmf.Ident)
+	if !ok {
+		return "", tInvalid, fmt.Errorf("unsupported assignment target %s", target.String())
+	}
+	v, ok := g.variables[ident.Name]
+	if !ok {
+		return "", tInvalid, fmt.Errorf("unknown variable %s", ident.Name)
+	}
+	if v.kind == constantVariable {
+		return "", tInvalid, fmt.Errorf("cannot assign to constant %s", ident.Name)
+	}
+	if target == name {
+		return v.ref(), v.typ, nil
+	}
+	return g.expr(target)
+}
+
+// value generates a constant or default value. Plain numbers keep their
+// literal scale like new BigDecimal(String) does.
+func (g *generator) value(text string, typ goType) (string, error) {
+	text = strings.TrimSpace(text)
+	switch typ {
+	case tDecimal:
+		if _, err := bmf.ParseDecimal(text); err == nil {
+			return fmt.Sprintf("bmf.MustParseDecimal(%q)", text), nil
+		}
+	case tInt:
+		if _, err := strconv.Atoi(text); err == nil {
+			return text, nil
+		}
+	case tFloat:
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return text, nil
+		}
+	case tBool:
+		if b, err := strconv.ParseBool(text); err == nil {
+			return strconv.FormatBool(b), nil
+		}
+	}
+
+	expr, err := bmf.ParseExpression(text)
+	if err != nil {
+		return "", err
+	}
+	if array, ok := expr.(*bmf.ArrayLit); ok {
+		return g.arrayLit(array, typ)
+	}
+	code, exprType, err := g.expr(expr)
+	if err != nil {
+		return "", err
+	}
+	return convert(code, exprType, typ)
+}
+
+func (g *generator) arrayLit(array *bmf.ArrayLit, typ goType) (string, error) {
+	element := tDecimal
+	switch typ {
+	case tDecimalArray:
+	case tIntArray:
+		element = tInt
+	default:
+		return "", fmt.Errorf("array value for %s", typ)
+	}
+
+	elements := make([]string, len(array.Elements))
+	for i, e := range array.Elements {
+		code, exprType, err := g.expr(e)
+		if err != nil {
+			return "", err
+		}
+		if elements[i], err = convert(code, exprType, element); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s{\n%s,\n}", typ, strings.Join(elements, ",\n")), nil
+}
+
+// expr generates a Go expression and reports its type
+func (g *generator) expr(expr bmf.Expr) (string, goType, error) {
+	switch e := expr.(type) {
+	case *bmf.NumberLit:
+		if !strings.ContainsAny(e.Text, ".eE") {
+			if _, err := strconv.Atoi(e.Text); err != nil {
+				return "", tInvalid, fmt.Errorf("invalid integer literal %q", e.Text)
+			}
+			return e.Text, tInt, nil
+		}
+		if _, err := strconv.ParseFloat(e.Text, 64); err != nil {
+			return "", tInvalid, fmt.Errorf("invalid decimal literal %q", e.Text)
+		}
+		return e.Text, tFloat, nil
+
+	case *bmf.BoolLit:
+		return strconv.FormatBool(e.Value), tBool, nil
+
+	case *bmf.Ident:
+		if e.Name == "BigDecimal" {
+			return "", tClass, nil
+		}
+		v, ok := g.variables[e.Name]
+		if !ok {
+			return "", tInvalid, fmt.Errorf("unknown variable %s", e.Name)
+		}
+		return v.ref(), v.typ, nil
+
+	case *bmf.FieldAccess:
+		_, typ, err := g.expr(e.Target)
+		if err != nil {
+			return "", tInvalid, err
+		}
+		if typ != tClass {
+			return "", tInvalid, fmt.Errorf("unknown field %s on %s", e.Name, typ)
+		}
+		switch e.Name {
+		case "ZERO":
+			return "bmf.DecimalZero", tDecimal, nil
+		case "ONE":
+			return "bmf.DecimalOne", tDecimal, nil
+		case "TEN":
+			return "bmf.DecimalTen", tDecimal, nil
+		}
+		if mode, ok := roundingModes[e.Name]; ok {
+			return mode, tInt, nil
+		}
+		return "", tInvalid, fmt.Errorf("unknown field BigDecimal.%s", e.Name)
+
+	case *bmf.MethodCall:
+		return g.methodCall(e)
+
+	case *bmf.NewObject:
+		if e.Type != "BigDecimal" || len(e.Args) != 1 {
+			return "", tInvalid, fmt.Errorf("cannot generate %s", e.String())
+		}
+		// Unlike BigDecimal.valueOf, the constructor takes a double exactly
+		code, typ, err := g.expr(e.Args[0])
+		if err != nil {
+			return "", tInvalid, err
+		}
+		if typ == tFloat {
+			return fmt.Sprintf("must(bmf.DecimalFromFloatExact(%s))", code), tDecimal, nil
+		}
+		return g.decimalArg(e.Args[0])
+
+	case *bmf.IndexExpr:
+		array, arrayType, err := g.expr(e.Array)
+		if err != nil {
+			return "", tInvalid, err
+		}
+		index, indexType, err := g.expr(e.Index)
+		if err != nil {
+			return "", tInvalid, err
+		}
+		if indexType != tInt {
+			return "", tInvalid, fmt.Errorf("array index must be an int, got %s", indexType)
+		}
+		switch arrayType {
+		case tDecimalArray:
+			return fmt.Sprintf("%s[%s]", array, index), tDecimal, nil
+		case tIntArray:
+			return fmt.Sprintf("%s[%s]", array, index), tInt, nil
+		}
+		return "", tInvalid, fmt.Errorf("cannot index %s", arrayType)
+
+	case *bmf.UnaryExpr:
+		operand, typ, err := g.expr(e.Operand)
+		if err != nil {
+			return "", tInvalid, err
+		}
+		switch {
+		case e.Op == "!" && typ == tBool:
+			return "!" + operand, tBool, nil
+		case e.Op == "-" && (typ == tInt || typ == tFloat):
+			if strings.HasPrefix(operand, "-") {
+				// --1 would be a decrement in Go
+				return "-(" + operand + ")", typ, nil
+			}
+			return "-" + operand, typ, nil
+		case e.Op == "-" && typ == tDecimal:
+			return operand + ".Neg()", tDecimal, nil
+		}
+		return "", tInvalid, fmt.Errorf("operator %s on %s", e.Op, typ)
+
+	case *bmf.BinaryExpr:
+		return g.binary(e)
+
+	case *bmf.ArrayLit:
+		return "", tInvalid, fmt.Errorf("array literal outside a constant")
+	}
+
+	return "", tInvalid, fmt.Errorf("unsupported expression %T", expr)
+}
+
+// decimalArg generates BigDecimal.valueOf(x), and new BigDecimal(x) of
+// anything but a double
+func (g *generator) decimalArg(arg bmf.Expr) (string, goType, error) {
+	code, typ, err := g.expr(arg)
+	if err != nil {
+		return "", tInvalid, err
+	}
+	code, err = convert(code, typ, tDecimal)
+	return code, tDecimal, err
+}
+
+func (g *generator) methodCall(e *bmf.MethodCall) (string, goType, error) {
+	receiver, receiverType, err := g.expr(e.Receiver)
+	if err != nil {
+		return "", tInvalid, err
+	}
+
+	if receiverType == tClass {
+		if e.Method == "valueOf" && len(e.Args) == 1 {
+			return g.decimalArg(e.Args[0])
+		}
+		return "", tInvalid, fmt.Errorf("unknown static method BigDecimal.%s/%d", e.Method, len(e.Args))
+	}
+
+	receiver, err = convert(receiver, receiverType, tDecimal)
+	if err != nil {
+		return "", tInvalid, fmt.Errorf("cannot call %s: %w", e.Method, err)
+	}
+
+	args := make([]string, len(e.Args))
+	types := make([]goType, len(e.Args))
+	for i, arg := range e.Args {
+		if args[i], types[i], err = g.expr(arg); err != nil {
+			return "", tInvalid, err
+		}
+	}
+
+	decimalArgs := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%s expects %d argument(s), got %d", e.Method, n, len(args))
+		}
+		for i := range args {
+			if args[i], err = convert(args[i], types[i], tDecimal); err != nil {
+				return fmt.Errorf("%s: %w", e.Method, err)
+			}
+		}
+		return nil
+	}
+	intArgs := func(from int) error {
+		for i := from; i < len(args); i++ {
+			if types[i] != tInt {
+				return fmt.Errorf("%s: argument %d must be an int, got %s", e.Method, i+1, types[i])
+			}
+		}
+		return nil
+	}
+
+	simple := map[string]string{"add": "Add", "subtract": "Sub", "multiply": "Mul", "max": "Max", "min": "Min"}
+	if method, ok := simple[e.Method]; ok {
+		if err := decimalArgs(1); err != nil {
+			return "", tInvalid, err
+		}
+		return fmt.Sprintf("%s.%s(%s)", receiver, method, args[0]), tDecimal, nil
+	}
+
+	switch e.Method {
+	case "compareTo":
+		if err := decimalArgs(1); err != nil {
+			return "", tInvalid, err
+		}
+		return fmt.Sprintf("%s.Cmp(%s)", receiver, args[0]), tInt, nil
+
+	case "remainder":
+		if err := decimalArgs(1); err != nil {
+			return "", tInvalid, err
+		}
+		return fmt.Sprintf("must(%s.Remainder(%s))", receiver, args[0]), tDecimal, nil
+
+	case "divide":
+		if len(args) < 1 || len(args) > 3 {
+			return "", tInvalid, fmt.Errorf("divide expects 1 to 3 arguments, got %d", len(args))
+		}
+		divisor, err := convert(args[0], types[0], tDecimal)
+		if err != nil {
+			return "", tInvalid, fmt.Errorf("divide: %w", err)
+		}
+		if err := intArgs(1); err != nil {
+			return "", tInvalid, err
+		}
+		switch len(args) {
+		case 1:
+			return fmt.Sprintf("must(%s.Div(%s))", receiver, divisor), tDecimal, nil
+		case 2:
+			return fmt.Sprintf("must(%s.DivRound(%s, %s))", receiver, divisor, args[1]), tDecimal, nil
+		default:
+			return fmt.Sprintf("must(%s.DivScale(%s, %s, %s))", receiver, divisor, args[1], args[2]), tDecimal, nil
+		}
+
+	case "setScale":
+		if len(args) != 1 && len(args) != 2 {
+			return "", tInvalid, fmt.Errorf("setScale expects 1 or 2 arguments, got %d", len(args))
+		}
+		if err := intArgs(0); err != nil {
+			return "", tInvalid, err
+		}
+		mode := "bmf.RoundUnnecessary"
+		if len(args) == 2 {
+			mode = args[1]
+		}
+		return fmt.Sprintf("must(%s.SetScale(%s, %s))", receiver, args[0], mode), tDecimal, nil
+
+	case "negate", "abs", "signum", "scale", "intValue", "longValue":
+		if len(args) != 0 {
+			return "", tInvalid, fmt.Errorf("%s expects no arguments, got %d", e.Method, len(args))
+		}
+		method, typ := map[string]string{
+			"negate": "Neg", "abs": "Abs", "signum": "Sign", "scale": "Scale", "intValue": "IntValue", "longValue": "IntValue",
+		}[e.Method], tInt
+		if e.Method == "negate" || e.Method == "abs" {
+			typ = tDecimal
+		}
+		return fmt.Sprintf("%s.%s()", receiver, method), typ, nil
+	}
+
+	return "", tInvalid, fmt.Errorf("unknown method %s/%d", e.Method, len(args))
+}
+
+func (g *generator) binary(e *bmf.BinaryExpr) (string, goType, error) {
+	left, leftType, err := g.expr(e.Left)
+	if err != nil {
+		return "", tInvalid, err
+	}
+	right, rightType, err := g.expr(e.Right)
+	if err != nil {
+		return "", tInvalid, err
+	}
+
+	switch e.Op {
+	case "&&", "||":
+		if leftType != tBool || rightType != tBool {
+			return "", tInvalid, fmt.Errorf("operator %s requires boolean operands, got %s and %s", e.Op, leftType, rightType)
+		}
+		return fmt.Sprintf("(%s %s %s)", left, e.Op, right), tBool, nil
+
+	case "==", "!=", "<", "<=", ">", ">=":
+		switch {
+		case leftType == tBool && rightType == tBool && (e.Op == "==" || e.Op == "!="):
+			return fmt.Sprintf("(%s %s %s)", left, e.Op, right), tBool, nil
+		case leftType == tInt && rightType == tInt, leftType == tFloat && rightType == tFloat:
+			return fmt.Sprintf("(%s %s %s)", left, e.Op, right), tBool, nil
+		}
+		if left, err = convert(left, leftType, tDecimal); err != nil {
+			return "", tInvalid, fmt.Errorf("operator %s: %w", e.Op, err)
+		}
+		if right, err = convert(right, rightType, tDecimal); err != nil {
+			return "", tInvalid, fmt.Errorf("operator %s: %w", e.Op, err)
+		}
+		return fmt.Sprintf("(%s.Cmp(%s) %s 0)", left, right, e.Op), tBool, nil
+
+	case "+", "-", "*", "/", "%":
+		if leftType == tInt && rightType == tInt {
+			return fmt.Sprintf("(%s %s %s)", left, e.Op, right), tInt, nil
+		}
+		if left, err = convert(left, leftType, tDecimal); err != nil {
+			return "", tInvalid, fmt.Errorf("operator %s: %w", e.Op, err)
+		}
+		if right, err = convert(right, rightType, tDecimal); err != nil {
+			return "", tInvalid, fmt.Errorf("operator %s: %w", e.Op, err)
+		}
+		method := map[string]string{"+": "Add", "-": "Sub", "*": "Mul", "/": "Div", "%": "Remainder"}[e.Op]
+		if e.Op == "/" || e.Op == "%" {
+			return fmt.Sprintf("must(%s.%s(%s))", left, method, right), tDecimal, nil
+		}
+		return fmt.Sprintf("%s.%s(%s)", left, method, right), tDecimal, nil
+	}
+
+	return "", tInvalid, fmt.Errorf("unknown operator %s", e.Op)
+}
+
+// convert turns generated code of one type into another where the
+// evaluator would convert too
+func convert(code string, from, to goType) (string, error) {
+	if from == to {
+		return code, nil
+	}
+	switch {
+	case to == tDecimal && from == tInt:
+		return fmt.Sprintf("bmf.DecimalFromInt(%s)", code), nil
+	case to == tDecimal && from == tFloat:
+		return fmt.Sprintf("bmf.DecimalFromFloat(%s)", code), nil
+	case to == tFloat && from == tInt:
+		return fmt.Sprintf("float64(%s)", code), nil
+	}
+	return "", fmt.Errorf("cannot use %s as %s", from, to)
+}
+
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return !isKeyword(name)
+}
+
+func isKeyword(name string) bool {
+	keywords := []string{
+		"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+		"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+		"return", "select", "struct", "switch", "type", "var",
+	}
+	i := sort.SearchStrings(keywords, name)
+	return i < len(keywords) && keywords[i] == name
+}
+
+func trimParens(code string) string {
+	if strings.HasPrefix(code, "(") && strings.HasSuffix(code, ")") && balanced(code[1:len(code)-1]) {
+		return code[1 : len(code)-1]
+	}
+	return code
+}
+
+// balanced reports whether every parenthesis in code is closed in order
+func balanced(code string) bool {
+	depth := 0
+	for _, r := range code {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
@@ -0,0 +1,134 @@
+package papgen
+
+import (
+	"strings"
+	"testing"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+func parsePAP(t *testing.T, variables, constants, methods string) *bmf.PAPData {
+	t.Helper()
+	src := `<PAP name="Test"><VARIABLES>` + variables + `</VARIABLES><CONSTANTS>` + constants +
+		`</CONSTANTS><METHODS>` + methods + `</METHODS></PAP>`
+	papData, err := bmf.ParsePAP([]byte(src))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return papData
+}
+
+func TestNamesFor(t *testing.T) {
+	tests := []struct {
+		file string
+		pkg  string
+		fn   string
+	}{
+		{"paps/Lohnsteuer2025.xml", "lohnsteuer2025", "Lohnsteuer2025"},
+		{"Lohnsteuer2024Version2.xml", "lohnsteuer2024version2", "Lohnsteuer2024Version2"},
+		{"lohnsteuer-2026.xml", "lohnsteuer2026", "Lohnsteuer2026"},
+		{"2026.xml", "pap2026", "PAP2026"},
+	}
+
+	for _, tt := range tests {
+		pkg, fn := NamesFor(tt.file)
+		if pkg != tt.pkg || fn != tt.fn {
+			t.Errorf("NamesFor(%q) = %q, %q; expected %q, %q", tt.file, pkg, fn, tt.pkg, tt.fn)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	papData := parsePAP(t,
+		`<INPUTS><INPUT name="STKL" type="int"/><INPUT name="f" type="double" default="1.0"/><INPUT name="RE4" type="BigDecimal"/></INPUTS>
+		<OUTPUTS><OUTPUT name="LST" type="BigDecimal" default="BigDecimal.ZERO"/></OUTPUTS>
+		<INTERNALS><INTERNAL name="J" type="int"/><INTERNAL name="H" type="BigDecimal[]" default="{BigDecimal.ZERO, BigDecimal.ZERO}"/></INTERNALS>`,
+		`<CONSTANT name="TAB" type="BigDecimal[]" value="{BigDecimal.valueOf (0.0), BigDecimal.valueOf(0.5)}"/>
+		<CONSTANT name="ZAHL100" type="BigDecimal" value="new BigDecimal(100)"/>`,
+		`<MAIN><EXECUTE method="MCALC"/></MAIN>
+		<METHOD name="MCALC">
+			<IF expr="STKL &gt; 2 &amp;&amp; RE4.compareTo(BigDecimal.ZERO) == 1">
+				<THEN><EVAL exec="J = STKL - 2"/></THEN>
+				<ELSE><EVAL exec="J = - -2"/></ELSE>
+			</IF>
+			<EVAL exec="LST = RE4.multiply(TAB[1]).multiply(BigDecimal.valueOf(f)).divide(ZAHL100, 2, BigDecimal.ROUND_DOWN).add(BigDecimal.valueOf(J))"/>
+			<EVAL exec="H[J] = LST"/>
+			<EVAL exec="H[0] = new BigDecimal(f)"/>
+		</METHOD>`)
+
+	source, err := Generate(papData, Options{Source: "Test.xml"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, want := range []string{
+		"// Code generated by papgen from Test.xml; DO NOT EDIT.",
+		"package test\n",
+		"func Test(in Inputs) (out Outputs, err error) {",
+		"F    float64     // f",
+		"zahl100 = bmf.DecimalFromInt(100)",
+		"F: 1.0,",
+		"s.LST = bmf.DecimalZero",
+		"if (s.STKL > 2) && (s.RE4.Cmp(bmf.DecimalZero) == 1) {",
+		"s.J = s.STKL - 2",
+		"s.J = -(-2)",
+		"s.LST = must(s.RE4.Mul(tab[1]).Mul(bmf.DecimalFromFloat(s.F)).DivScale(zahl100, 2, bmf.RoundDown)).Add(bmf.DecimalFromInt(s.J))",
+		"s.H = []bmf.Decimal{",
+		"s.H[s.J] = s.LST",
+		"s.H[0] = must(bmf.DecimalFromFloatExact(s.F))",
+		`"LST": out.LST,`,
+		"func OutputNames() []string {\n\treturn []string{\n\t\t\"LST\",\n\t}\n}",
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("Expected the generated code to contain %q, got:\n%s", want, source)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables string
+		constants string
+		methods   string
+		expected  string
+	}{
+		{"no main", ``, ``, `<METHOD name="MA"/>`, "no main method"},
+		{"unknown method", ``, ``, `<MAIN><EXECUTE method="MISSING"/></MAIN>`, "MAIN: method MISSING not found"},
+		{"unknown variable", ``, ``, `<MAIN><EVAL exec="X = Y"/></MAIN>`, "unknown variable"},
+		{"unsupported type", `<INPUTS><INPUT name="A" type="String"/></INPUTS>`, ``, `<MAIN/>`, `unsupported type "String"`},
+		{"name clash", `<INPUTS><INPUT name="af" type="int"/><INPUT name="Af" type="int"/></INPUTS>`, ``, `<MAIN/>`, "Af clashes with af"},
+		{"constant clash", ``, `<CONSTANT name="MUST" type="int" value="1"/>`, `<MAIN/>`, "MUST clashes with a function as must"},
+		{"predeclared constant", ``, `<CONSTANT name="INT" type="int" value="1"/>`, `<MAIN/>`, "INT clashes with a predeclared name as int"},
+		{"assign constant", ``, `<CONSTANT name="C" type="int" value="1"/>`, `<MAIN><EVAL exec="C = 2"/></MAIN>`, "cannot assign to constant C"},
+		{
+			"unsupported target", `<INTERNALS><INTERNAL name="X" type="int[]"/></INTERNALS>`, ``,
+			`<MAIN><EVAL exec="X[0][1] = 1"/></MAIN>`, "unsupported assignment target",
+		},
+		{
+			"assign constant element", ``, `<CONSTANT name="TAB" type="int[]" value="{1, 2}"/>`,
+			`<MAIN><EVAL exec="TAB[0] = 2"/></MAIN>`, "cannot assign to constant TAB",
+		},
+		{
+			"type mismatch", `<INTERNALS><INTERNAL name="X" type="int"/></INTERNALS>`, ``,
+			`<MAIN><EVAL exec="X = BigDecimal.ONE"/></MAIN>`, "cannot use bmf.Decimal as int",
+		},
+		{
+			"condition not boolean", `<INTERNALS><INTERNAL name="X" type="int"/></INTERNALS>`, ``,
+			`<MAIN><IF expr="X"><THEN/></IF></MAIN>`, "not boolean",
+		},
+		{"legacy eval", ``, ``, `<MAIN><EVAL target="X" left="1" op="+" right="2"/></MAIN>`, "without exec attribute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Generate(parsePAP(t, tt.variables, tt.constants, tt.methods), Options{})
+			if err == nil {
+				t.Fatal("Expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error about %q, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -12,12 +12,25 @@ type DebugLogMsg struct {
 	Message string
 }
 
-type CalculationStartedMsg struct{
+type CalculationStartedMsg struct {
 	UseLocalCalculator bool
+	Policy             calculation.FallbackPolicy
 }
 type CalculationMsg struct {
-	Result *bmf.TaxCalculationResponse
-	Error  error
+	Request    models.TaxRequest
+	Result     *bmf.TaxCalculationResponse
+	Provenance models.Provenance
+	Error      error
+
+	// The figures the results screen shows, as the service summarizes Result
+	Summary models.TaxResult
+}
+
+// TraceMsg carries a traced local calculation. A failed calculation comes
+// with the trace up to the failing step.
+type TraceMsg struct {
+	Trace *bmf.Trace
+	Error error
 }
 
 type ComparisonStartedMsg struct{}
@@ -40,11 +53,17 @@ func PerformCalculationCmd(taxClass int, income float64, year string) tea.Cmd {
 				}
 			},
 			func() tea.Msg {
+				taxYear, err := parseTaxYear(year)
+				if err != nil {
+					return CalculationMsg{Error: err}
+				}
+
 				// Convert basic parameters to tax request
 				taxRequest := models.TaxRequest{
 					Period:   models.Year,
 					Income:   int(income * 100),
 					TaxClass: models.TaxClass(taxClass),
+					Year:     taxYear,
 				}
 
 				// Then perform the actual calculation
@@ -55,39 +74,34 @@ func PerformCalculationCmd(taxClass int, income float64, year string) tea.Cmd {
 }
 
 func PerformCalculationWithAdvancedOptionsCmd(taxClass int, income float64, year string, advancedParams models.TaxRequest, useLocalCalculator bool) tea.Cmd {
+	return PerformCalculationWithPolicyCmd(taxClass, income, year, advancedParams, policyFor(useLocalCalculator))
+}
+
+func PerformCalculationWithPolicyCmd(taxClass int, income float64, year string, advancedParams models.TaxRequest, policy calculation.FallbackPolicy, opts ...calculation.Option) tea.Cmd {
 	return func() tea.Msg {
-		// Create a basic request with main parameters
-		taxRequest := models.TaxRequest{
-			Period:   models.Year,
-			Income:   int(income * 100),
-			TaxClass: models.TaxClass(taxClass),
-			
-			// Copy all advanced parameters
-			AJAHR:  advancedParams.AJAHR,
-			ALTER1: advancedParams.ALTER1,
-			KRV:    advancedParams.KRV,
-			KVZ:    advancedParams.KVZ,
-			PVS:    advancedParams.PVS,
-			PVZ:    advancedParams.PVZ,
-			R:      advancedParams.R,
-			ZKF:    advancedParams.ZKF,
-			VBEZ:   advancedParams.VBEZ,
-			VJAHR:  advancedParams.VJAHR,
-			PKPV:   advancedParams.PKPV,
-			PKV:    advancedParams.PKV,
-			PVA:    advancedParams.PVA,
+		taxYear, err := parseTaxYear(year)
+		if err != nil {
+			return CalculationMsg{Error: err}
 		}
+
+		// Start from the advanced parameters, so every field reaches the engines
+		taxRequest := advancedParams
+		taxRequest.Period = models.Year
+		taxRequest.Income = int(income * 100)
+		taxRequest.TaxClass = models.TaxClass(taxClass)
+		taxRequest.Year = taxYear
 		
 		// Return the calculation started message first
 		return tea.Batch(
 			func() tea.Msg {
 				return CalculationStartedMsg{
-					UseLocalCalculator: useLocalCalculator,
+					UseLocalCalculator: policy == calculation.LocalOnly || policy == calculation.LocalThenAPI,
+					Policy:             policy,
 				}
 			},
 			func() tea.Msg {
 				// Then perform the actual calculation with advanced parameters
-				return FetchResultsWithAdvancedParamsCmd(taxRequest, useLocalCalculator)()
+				return FetchResultsWithPolicyCmd(taxRequest, policy, opts...)()
 			},
 		)()
 	}
@@ -119,44 +133,35 @@ func FetchResultsWithModeCmd(taxClass int, income float64, useLocalCalculator bo
 }
 
 func FetchResultsWithAdvancedParamsCmd(taxRequest models.TaxRequest, useLocalCalculator bool) tea.Cmd {
+	return FetchResultsWithPolicyCmd(taxRequest, policyFor(useLocalCalculator))
+}
+
+// FetchResultsWithPolicyCmd calculates with the given policy; further options
+// such as calculation.WithConsensus are passed on to the service
+func FetchResultsWithPolicyCmd(taxRequest models.TaxRequest, policy calculation.FallbackPolicy, opts ...calculation.Option) tea.Cmd {
 	return func() tea.Msg {
 		var cmds []tea.Cmd
 
-		taxService := calculation.NewTaxService()
-		if useLocalCalculator {
-			taxService.EnableLocalCalculator()
-			cmds = append(cmds, CaptureDebugCmd("Using local tax calculator"))
+		opts = append([]calculation.Option{calculation.WithFallbackPolicy(policy)}, opts...)
+		taxService := calculation.NewTaxService(opts...)
+		if policy != calculation.APIThenLocal {
+			cmds = append(cmds, CaptureDebugCmd("Using the "+policy.String()+" policy"))
 		}
-
-		// Calculate tax using the service (with local or remote calculator based on flag)
-		_, err := taxService.CalculateTax(taxRequest)
-		
-		var response *bmf.TaxCalculationResponse
-		if err == nil {
-			if useLocalCalculator {
-				// Use the local calculator directly to get the raw response
-				localCalc := calculation.GetLocalTaxCalculator()
-				if !localCalc.IsInitialized() {
-					if initErr := localCalc.Initialize(); initErr != nil {
-						calcMsg := CalculationMsg{
-							Result: nil,
-							Error:  initErr,
-						}
-						cmds = append(cmds, func() tea.Msg { return calcMsg })
-						return tea.Batch(cmds...)()
-					}
-				}
-				// Get the response from local calculator
-				response, err = localCalc.CalculateTax(taxRequest)
-			} else {
-				// Use the API for remote calculation
-				response, _ = bmf.CalculateTax(taxRequest)
-			}
+		if taxService.ConsensusEnabled() {
+			cmds = append(cmds, CaptureDebugCmd("Cross-checking the BMF API and the local PAP"))
 		}
 
+		// Calculate tax using the service with the engines the policy picks
+		response, provenance, err := taxService.Calculate(taxRequest)
+
 		calcMsg := CalculationMsg{
-			Result: response,
-			Error:  err,
+			Request:    taxRequest,
+			Result:     response,
+			Provenance: provenance,
+			Error:      err,
+		}
+		if err == nil {
+			calcMsg.Summary = taxService.Summarize(response, taxRequest)
 		}
 
 		cmds = append(cmds, func() tea.Msg { return calcMsg })
@@ -165,6 +170,27 @@ func FetchResultsWithAdvancedParamsCmd(taxRequest models.TaxRequest, useLocalCal
 	}
 }
 
+// TraceCalculationCmd runs a request through the local PAP, recording every step
+func TraceCalculationCmd(taxRequest models.TaxRequest) tea.Cmd {
+	return func() tea.Msg {
+		calculator := calculation.GetLocalTaxCalculator()
+		if err := calculator.Initialize(); err != nil {
+			return TraceMsg{Error: err}
+		}
+
+		_, trace, err := calculator.CalculateTaxWithTrace(taxRequest)
+		return TraceMsg{Trace: trace, Error: err}
+	}
+}
+
+// The local toggle of the older commands maps to local-only or the default policy
+func policyFor(useLocalCalculator bool) calculation.FallbackPolicy {
+	if useLocalCalculator {
+		return calculation.LocalOnly
+	}
+	return calculation.APIThenLocal
+}
+
 func PerformComparisonCmd() tea.Cmd {
 	return func() tea.Msg {
 		return ComparisonStartedMsg{}
@@ -188,35 +214,38 @@ func CompletedResultsCmd(results []models.TaxResult) tea.Cmd {
 	}
 }
 
-func FetchComparisonCmd(taxClass int, income float64) tea.Cmd {
+func FetchComparisonCmd(taxClass int, income float64, year int) tea.Cmd {
+	return fetchComparisonWithService(calculation.NewTaxService(), taxClass, income, year)
+}
+
+func fetchComparisonWithService(taxService *calculation.TaxService, taxClass int, income float64, year int) tea.Cmd {
 	return func() tea.Msg {
-		taxService := calculation.NewTaxService()
 
 		halfIncome := income / 2
 		doubleIncome := income * 2
 
 		var results []models.TaxResult
 
-		originalResult := calculateTaxForIncome(taxClass, income, taxService)
+		originalResult := calculateTaxForIncome(taxClass, income, year, taxService)
 		results = append(results, originalResult)
 
-		halfResult := calculateTaxForIncome(taxClass, halfIncome, taxService)
+		halfResult := calculateTaxForIncome(taxClass, halfIncome, year, taxService)
 		results = append(results, halfResult)
 
-		doubleResult := calculateTaxForIncome(taxClass, doubleIncome, taxService)
+		doubleResult := calculateTaxForIncome(taxClass, doubleIncome, year, taxService)
 		results = append(results, doubleResult)
 
 		increment := (income - halfIncome) / 10
 		for i := 1; i <= 9; i++ {
 			point := halfIncome + (float64(i) * increment)
-			result := calculateTaxForIncome(taxClass, point, taxService)
+			result := calculateTaxForIncome(taxClass, point, year, taxService)
 			results = append(results, result)
 		}
 
 		increment = (doubleIncome - income) / 10
 		for i := 1; i <= 9; i++ {
 			point := income + (float64(i) * increment)
-			result := calculateTaxForIncome(taxClass, point, taxService)
+			result := calculateTaxForIncome(taxClass, point, year, taxService)
 			results = append(results, result)
 		}
 
@@ -228,25 +257,22 @@ func FetchComparisonCmd(taxClass int, income float64) tea.Cmd {
 	}
 }
 
-func calculateTaxForIncome(taxClass int, income float64, taxService *calculation.TaxService) models.TaxResult {
+func calculateTaxForIncome(taxClass int, income float64, year int, taxService *calculation.TaxService) models.TaxResult {
 	incomeInCents := int(income * 100)
 
 	taxRequest := models.TaxRequest{
 		Period:   models.Year,
 		Income:   incomeInCents,
 		TaxClass: models.TaxClass(taxClass),
+		Year:     year,
 	}
 
-	response, err := bmf.CalculateTax(taxRequest)
-
-	var result models.TaxResult
+	result, err := taxService.CalculateTax(taxRequest)
 	if err != nil {
 		result = models.TaxResult{
 			Income: income,
 			Error:  err,
 		}
-	} else {
-		result = taxService.GetTaxSummary(response, income)
 	}
 
 	return result
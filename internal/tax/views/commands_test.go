@@ -159,7 +159,7 @@ func TestFetchResultsWithAdvancedParamsCmd(t *testing.T) {
 }
 
 func TestFetchComparisonCmd(t *testing.T) {
-	cmd := FetchComparisonCmd(1, 50000.0)
+	cmd := FetchComparisonCmd(1, 50000.0, 0)
 
 	if cmd == nil {
 		t.Error("FetchComparisonCmd should return a non-nil command")
@@ -177,7 +177,7 @@ func TestCalculateTaxForIncome(t *testing.T) {
 	// by testing the commands that use it
 
 	// Test through FetchComparisonCmd which uses calculateTaxForIncome
-	cmd := FetchComparisonCmd(1, 50000.0)
+	cmd := FetchComparisonCmd(1, 50000.0, 0)
 	if cmd == nil {
 		t.Error("FetchComparisonCmd should work with calculateTaxForIncome")
 	}
@@ -0,0 +1,347 @@
+package views
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/views/styles"
+)
+
+// Steps shown around the current one
+const debuggerWindow = 15
+
+// debuggerState steps through the trace of a local calculation
+type debuggerState struct {
+	loading bool
+	err     string
+	trace   *bmf.Trace
+	step    int
+
+	// Variables and methods to stop at
+	breakpoints     []string
+	breakpointInput textinput.Model
+	showVariables   bool
+}
+
+func newDebuggerState() debuggerState {
+	input := textinput.New()
+	input.Placeholder = "ZVE or MPARA"
+	input.Width = 20
+	input.CharLimit = 20
+	input.Prompt = "● "
+	input.TextStyle = lipgloss.NewStyle().Foreground(styles.FgColor)
+	input.PromptStyle = lipgloss.NewStyle().Foreground(styles.DangerColor)
+
+	return debuggerState{breakpointInput: input}
+}
+
+// start forgets the previous trace while a new one is recorded
+func (d *debuggerState) start() {
+	d.loading = true
+	d.err = ""
+	d.trace = nil
+	d.step = 0
+}
+
+// finish shows a recorded trace, at the failing step if the calculation failed
+func (d *debuggerState) finish(msg TraceMsg) {
+	d.loading = false
+	d.trace = msg.Trace
+	d.step = 0
+	if msg.Error != nil {
+		d.err = msg.Error.Error()
+		if d.trace != nil && d.trace.Len() > 0 {
+			d.step = d.trace.Len() - 1
+		}
+	}
+}
+
+func (d *debuggerState) steps() int {
+	if d.trace == nil {
+		return 0
+	}
+	return d.trace.Len()
+}
+
+// goTo moves to a step, staying within the trace
+func (d *debuggerState) goTo(step int) {
+	d.step = max(0, min(step, d.steps()-1))
+}
+
+// stepOver skips the body of a called method, landing on its return
+func (d *debuggerState) stepOver() {
+	if d.steps() == 0 {
+		return
+	}
+	current := d.trace.Events[d.step]
+	if current.Kind == bmf.TraceCall {
+		for i := d.step + 1; i < d.steps(); i++ {
+			if event := d.trace.Events[i]; event.Kind == bmf.TraceReturn && event.Depth == current.Depth {
+				d.step = i
+				return
+			}
+		}
+	}
+	d.goTo(d.step + 1)
+}
+
+// continueTo runs to the next breakpoint, or the previous one going backwards
+func (d *debuggerState) continueTo(backwards bool) {
+	if d.steps() == 0 {
+		return
+	}
+	next := d.trace.NextBreak(d.step, d.breakpoints)
+	if backwards {
+		next = d.trace.PrevBreak(d.step, d.breakpoints)
+	}
+	if next >= 0 {
+		d.step = next
+	}
+}
+
+// toggleBreakpoint sets or removes a breakpoint on a variable or method
+func (d *debuggerState) toggleBreakpoint(name string) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return
+	}
+	if i := slices.Index(d.breakpoints, name); i >= 0 {
+		d.breakpoints = slices.Delete(d.breakpoints, i, i+1)
+		return
+	}
+	d.breakpoints = append(d.breakpoints, name)
+}
+
+// handleDebuggerKey handles the keys of the debugger screen, reporting
+// whether the key was one of them
+func (m *RetroApp) handleDebuggerKey(key string) bool {
+	d := &m.debugger
+
+	switch key {
+	case "esc":
+		m.screen = ResultsScreen
+	case "down", "n":
+		d.goTo(d.step + 1)
+	case "up", "p":
+		d.goTo(d.step - 1)
+	case "o":
+		d.stepOver()
+	case "c":
+		d.continueTo(false)
+	case "r":
+		d.continueTo(true)
+	case "g":
+		d.goTo(0)
+	case "G":
+		d.goTo(d.steps() - 1)
+	case "b":
+		d.breakpointInput.Focus()
+	case "x":
+		d.breakpoints = nil
+	case "v":
+		d.showVariables = !d.showVariables
+	default:
+		return false
+	}
+	return true
+}
+
+// submitBreakpoint toggles the breakpoint typed into the input
+func (m *RetroApp) submitBreakpoint() {
+	m.debugger.toggleBreakpoint(m.debugger.breakpointInput.Value())
+	m.debugger.breakpointInput.SetValue("")
+	m.debugger.breakpointInput.Blur()
+}
+
+// Debugger screen stepping through a traced local calculation
+func (m *RetroApp) renderDebuggerScreen() string {
+	d := &m.debugger
+
+	width := m.windowSize.Width
+	if width == 0 {
+		width = 100
+	}
+
+	center := lipgloss.NewStyle().Width(width).Align(lipgloss.Center)
+	title := formatTitle("PAP Debugger")
+
+	if d.loading {
+		return lipgloss.JoinVertical(
+			lipgloss.Center,
+			title,
+			"",
+			center.Render(lipgloss.JoinVertical(
+				lipgloss.Center,
+				lipgloss.NewStyle().
+					Foreground(styles.PrimaryColor).
+					Bold(true).
+					Render("Tracing the local calculation..."),
+				"",
+				m.spinner.View(),
+			)),
+		)
+	}
+
+	var errorLine string
+	if d.err != "" {
+		errorLine = lipgloss.NewStyle().Foreground(styles.DangerColor).Render(d.err)
+	}
+
+	if d.steps() == 0 {
+		return lipgloss.JoinVertical(
+			lipgloss.Center,
+			title,
+			"",
+			center.Render(errorLine),
+			"",
+			center.Render(formatKeyHint("Esc", "Back")),
+		)
+	}
+
+	current := d.trace.Events[d.step]
+
+	// Where we are
+	status := fmt.Sprintf("step %d/%d · %s", d.step+1, d.steps(), current.Method)
+	if version, err := bmf.ResolvePAPVersion(m.request); err == nil {
+		status = version.Version + " · " + status
+	}
+
+	// The steps around the current one, indented by call depth
+	first := max(0, min(d.step-debuggerWindow/2, d.steps()-debuggerWindow))
+	last := min(d.steps(), first+debuggerWindow)
+
+	var steps strings.Builder
+	for i := first; i < last; i++ {
+		event := d.trace.Events[i]
+
+		breakpoint := " "
+		if event.Hits(d.breakpoints) {
+			breakpoint = lipgloss.NewStyle().Foreground(styles.DangerColor).Render("●")
+		}
+
+		line := fmt.Sprintf("%5d %s%s", i+1, strings.Repeat("  ", event.Depth), event)
+		line = truncate(line, width-18)
+		if i == d.step {
+			line = "▶" + styles.HighlightStyle.Render(line)
+		} else {
+			line = " " + line
+		}
+		steps.WriteString(breakpoint + line + "\n")
+	}
+
+	var panel string
+	if d.showVariables {
+		panel = formatSubTitle("Variables") + "\n\n" + formatTraceVariables(d.trace.Variables(d.step), current, width-16)
+	} else {
+		panel = formatSubTitle("Step") + "\n\n" + formatTraceEvent(current)
+	}
+
+	// Breakpoints set so far, and the input for another one
+	breakpoints := "none"
+	if len(d.breakpoints) > 0 {
+		breakpoints = strings.Join(d.breakpoints, ", ")
+	}
+	breakpointLine := formatTableRow("Breakpoints:", breakpoints, false)
+	if d.breakpointInput.Focused() {
+		breakpointLine = lipgloss.JoinHorizontal(lipgloss.Center, breakpointLine, "  ", styles.ActiveInputStyle.Render(d.breakpointInput.View()))
+	}
+
+	helpText := formatKeyHints(
+		formatKeyHint("↑/↓", "Step"),
+		formatKeyHint("O", "Step Over"),
+		formatKeyHint("C/R", "Next/Prev Break"),
+		formatKeyHint("G/⇧G", "First/Last"),
+		formatKeyHint("B", "Breakpoint"),
+		formatKeyHint("X", "Clear"),
+		formatKeyHint("V", "Variables"),
+		formatKeyHint("Esc", "Back"),
+	)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.HelpStyle.Render(status),
+		"",
+		steps.String(),
+		panel,
+	)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Center,
+		"",
+		title,
+		"",
+		center.Render(styles.ResultsContainerStyle.Width(width-10).Render(content)),
+		"",
+		center.Render(breakpointLine),
+		center.Render(errorLine),
+		"",
+		center.Render(helpText),
+	)
+}
+
+// Details of a single step
+func formatTraceEvent(event bmf.TraceEvent) string {
+	var details strings.Builder
+
+	details.WriteString(formatTableRow("Method:", event.Method, false))
+	details.WriteString("\n")
+	details.WriteString(formatTableRow("Kind:", string(event.Kind), false))
+	details.WriteString("\n")
+
+	switch event.Kind {
+	case bmf.TraceEval, bmf.TraceBranch:
+		details.WriteString(styles.BaseStyle.Render(event.Source))
+		details.WriteString("\n")
+		details.WriteString(formatTableRow("Result:", bmf.FormatTraceValue(event.Value), true))
+	case bmf.TraceWrite:
+		details.WriteString(formatTableRow(event.Variable+" before:", bmf.FormatTraceValue(event.Before), false))
+		details.WriteString("\n")
+		details.WriteString(formatTableRow(event.Variable+" after:", bmf.FormatTraceValue(event.After), true))
+	case bmf.TraceCall, bmf.TraceReturn:
+		details.WriteString(formatTableRow("Called:", event.Source, true))
+	}
+
+	return details.String()
+}
+
+// All variables in columns, highlighting the one the step writes
+func formatTraceVariables(variables map[string]interface{}, event bmf.TraceEvent, width int) string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const columnWidth = 30
+	columns := max(1, width/columnWidth)
+
+	var table strings.Builder
+	for i, name := range names {
+		cell := truncate(fmt.Sprintf("%s = %s", name, bmf.FormatTraceValue(variables[name])), columnWidth-2)
+		style := styles.BaseStyle
+		if event.Kind == bmf.TraceWrite && event.Variable == name {
+			style = styles.HighlightStyle
+		}
+		table.WriteString(style.Width(columnWidth).Render(cell))
+		if (i+1)%columns == 0 || i == len(names)-1 {
+			table.WriteString("\n")
+		}
+	}
+
+	return table.String()
+}
+
+// Cut a line to a number of characters
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if width <= 1 || len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-1]) + "…"
+}
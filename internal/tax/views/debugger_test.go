@@ -0,0 +1,192 @@
+package views
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tax-calculator/internal/tax/bmf"
+)
+
+// A trace of MAIN calling MPARA, which writes ZVE, then writing LSTLZZ
+func testTrace() *bmf.Trace {
+	trace := &bmf.Trace{}
+	for _, event := range []bmf.TraceEvent{
+		{Kind: bmf.TraceStart, Method: "MAIN", Variables: map[string]interface{}{"ZVE": 0, "LSTLZZ": 0}},
+		{Kind: bmf.TraceCall, Method: "MAIN", Source: "MPARA"},
+		{Kind: bmf.TraceEval, Method: "MPARA", Depth: 1, Source: "ZVE = 100", Value: 100},
+		{Kind: bmf.TraceWrite, Method: "MPARA", Depth: 1, Variable: "ZVE", Before: 0, After: 100},
+		{Kind: bmf.TraceReturn, Method: "MAIN", Source: "MPARA"},
+		{Kind: bmf.TraceWrite, Method: "MAIN", Variable: "LSTLZZ", Before: 0, After: 42},
+	} {
+		trace.Trace(event)
+	}
+	return trace
+}
+
+func newDebuggerApp() *RetroApp {
+	app := NewRetroApp()
+	app.screen = DebuggerScreen
+	app.debugger.start()
+	app.Update(TraceMsg{Trace: testTrace()})
+	return app
+}
+
+func pressKeys(app *RetroApp, keys ...string) {
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		}
+		app.Update(msg)
+	}
+}
+
+func TestDebuggerStepping(t *testing.T) {
+	app := newDebuggerApp()
+
+	if app.debugger.loading || app.debugger.step != 0 {
+		t.Fatalf("Expected the trace at step 0, got step %d loading %v", app.debugger.step, app.debugger.loading)
+	}
+
+	pressKeys(app, "down", "n")
+	if app.debugger.step != 2 {
+		t.Errorf("Expected step 2, got %d", app.debugger.step)
+	}
+
+	pressKeys(app, "up", "up", "up")
+	if app.debugger.step != 0 {
+		t.Errorf("Expected stepping back to stop at 0, got %d", app.debugger.step)
+	}
+
+	// Stepping over the call lands on its return
+	pressKeys(app, "n", "o")
+	if app.debugger.step != 4 {
+		t.Errorf("Expected step over to land on the return at 4, got %d", app.debugger.step)
+	}
+
+	pressKeys(app, "G")
+	if app.debugger.step != 5 {
+		t.Errorf("Expected the last step 5, got %d", app.debugger.step)
+	}
+
+	pressKeys(app, "g")
+	if app.debugger.step != 0 {
+		t.Errorf("Expected the first step, got %d", app.debugger.step)
+	}
+
+	pressKeys(app, "esc")
+	if app.screen != ResultsScreen {
+		t.Errorf("Expected esc to go back to the results, got %v", app.screen)
+	}
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	app := newDebuggerApp()
+
+	// The key focusing the input is not typed into it
+	pressKeys(app, "b", "z", "v", "e", "enter")
+	if len(app.debugger.breakpoints) != 1 || app.debugger.breakpoints[0] != "ZVE" {
+		t.Fatalf("Expected a breakpoint on ZVE, got %v", app.debugger.breakpoints)
+	}
+	if app.debugger.breakpointInput.Focused() || app.debugger.breakpointInput.Value() != "" {
+		t.Error("Expected the breakpoint input to be cleared and blurred")
+	}
+
+	pressKeys(app, "b", "M", "P", "A", "R", "A", "enter")
+
+	pressKeys(app, "c")
+	if app.debugger.step != 1 {
+		t.Errorf("Expected the call of MPARA at 1, got %d", app.debugger.step)
+	}
+	pressKeys(app, "c")
+	if app.debugger.step != 3 {
+		t.Errorf("Expected the write of ZVE at 3, got %d", app.debugger.step)
+	}
+	pressKeys(app, "c")
+	if app.debugger.step != 3 {
+		t.Errorf("Expected to stay at 3 without a further breakpoint, got %d", app.debugger.step)
+	}
+	pressKeys(app, "r")
+	if app.debugger.step != 1 {
+		t.Errorf("Expected the previous breakpoint at 1, got %d", app.debugger.step)
+	}
+
+	// Entering a breakpoint again removes it
+	pressKeys(app, "b", "z", "v", "e", "enter")
+	if len(app.debugger.breakpoints) != 1 || app.debugger.breakpoints[0] != "MPARA" {
+		t.Errorf("Expected only MPARA left, got %v", app.debugger.breakpoints)
+	}
+
+	pressKeys(app, "x")
+	if len(app.debugger.breakpoints) != 0 {
+		t.Errorf("Expected no breakpoints, got %v", app.debugger.breakpoints)
+	}
+
+	// Esc leaves the input but not the debugger
+	pressKeys(app, "b", "esc")
+	if app.screen != DebuggerScreen || app.debugger.breakpointInput.Focused() {
+		t.Error("Expected esc to blur the breakpoint input only")
+	}
+}
+
+func TestDebuggerFailedTrace(t *testing.T) {
+	app := NewRetroApp()
+	app.screen = DebuggerScreen
+	app.debugger.start()
+	app.Update(TraceMsg{Trace: testTrace(), Error: errors.New("division by zero")})
+
+	if app.debugger.step != 5 {
+		t.Errorf("Expected a failed trace to open at its last step, got %d", app.debugger.step)
+	}
+	if view := app.View(); !strings.Contains(view, "division by zero") {
+		t.Error("Expected the error in the view")
+	}
+}
+
+func TestDebuggerView(t *testing.T) {
+	app := newDebuggerApp()
+	pressKeys(app, "n", "n", "n")
+
+	view := app.View()
+	for _, want := range []string{"PAP Debugger", "step 4/6", "→ MPARA", "ZVE: 0 → 100", "ZVE after:"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the view", want)
+		}
+	}
+
+	pressKeys(app, "v")
+	view = app.View()
+	if !strings.Contains(view, "ZVE = 100") || !strings.Contains(view, "LSTLZZ = 0") {
+		t.Error("Expected the variables at the step in the view")
+	}
+}
+
+func TestResultsTraceKey(t *testing.T) {
+	app := NewRetroApp()
+	app.screen = ResultsScreen
+
+	// Nothing to trace before a calculation
+	pressKeys(app, "t")
+	if app.screen != ResultsScreen {
+		t.Errorf("Expected to stay on the results without a result, got %v", app.screen)
+	}
+
+	app.result = &bmf.TaxCalculationResponse{}
+	_, cmd := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if app.screen != DebuggerScreen || !app.debugger.loading {
+		t.Errorf("Expected the debugger to be loading, got screen %v", app.screen)
+	}
+	if cmd == nil {
+		t.Error("Expected a command tracing the calculation")
+	}
+}
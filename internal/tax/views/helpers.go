@@ -2,9 +2,13 @@ package views
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/calculation"
 	"tax-calculator/internal/tax/models"
 	"tax-calculator/internal/tax/views/styles"
 
@@ -135,6 +139,20 @@ func parseFloatWithDefault(s string, defaultVal float64) (float64, error) {
 	return val, nil
 }
 
+// Parse an amount in euros into cents with a default fallback in cents
+func parseCentsWithDefault(s string, defaultVal int) (int, error) {
+	if s == "" {
+		return defaultVal, nil
+	}
+
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return defaultVal, err
+	}
+
+	return int(math.Round(val * 100)), nil
+}
+
 // Parse an int with a default fallback value
 func parseIntWithDefault(s string, defaultVal int) (int, error) {
 	if s == "" {
@@ -149,6 +167,30 @@ func parseIntWithDefault(s string, defaultVal int) (int, error) {
 	return val, nil
 }
 
+// Parse the tax year input; an empty field means the default year
+func parseTaxYear(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tax year %q", s)
+	}
+	return year, nil
+}
+
+// Latest supported tax year that has already started
+func defaultTaxYear(now time.Time) int {
+	years := bmf.DefaultPAPRegistry.Years()
+	for i := len(years) - 1; i >= 0; i-- {
+		if years[i] <= now.Year() {
+			return years[i]
+		}
+	}
+	return bmf.DefaultYear
+}
+
 // Format tax comparison results for display
 func formatComparisonResults(results []models.TaxResult, currentIncome float64, selectedIdx int) string {
 	var sb strings.Builder
@@ -216,6 +258,100 @@ func formatComparisonResults(results []models.TaxResult, currentIncome float64,
 	return sb.String()
 }
 
+// Describe a fallback policy for the mode indicator
+func formatPolicy(policy calculation.FallbackPolicy) string {
+	switch policy {
+	case calculation.APIOnly:
+		return "Remote Calculation Only"
+	case calculation.LocalOnly:
+		return "Local Calculation"
+	case calculation.LocalThenAPI:
+		return "Local Calculation, Remote Fallback"
+	default:
+		return "Remote Calculation, Local Fallback"
+	}
+}
+
+// Format where a result comes from, so it is clear whether it is official
+func formatProvenance(provenance models.Provenance) string {
+	var sb strings.Builder
+
+	source := string(provenance.Engine)
+	if provenance.Official() {
+		source += " (official)"
+	} else {
+		source += " (unofficial)"
+	}
+
+	// Values here are longer than amounts, so they are not right-aligned
+	row := func(label, value string, highlight bool) string {
+		style := styles.BaseStyle
+		if highlight {
+			style = styles.HighlightStyle
+		}
+		return lipgloss.JoinHorizontal(
+			lipgloss.Left,
+			style.Width(22).Render(label),
+			style.Render(value),
+		)
+	}
+
+	sb.WriteString(row("Source:", source, provenance.Official()))
+	sb.WriteString("\n")
+	sb.WriteString(row("PAP Version:", provenance.PAPVersion, false))
+	if !provenance.CalculatedAt.IsZero() {
+		sb.WriteString("\n")
+		sb.WriteString(row("Calculated:", provenance.CalculatedAt.Format("2006-01-02 15:04:05"), false))
+	}
+	if provenance.Fallback() {
+		sb.WriteString("\n\n")
+		sb.WriteString(lipgloss.NewStyle().
+			Foreground(styles.WarningColor).
+			Render("Fallback: " + provenance.FallbackReason))
+	}
+
+	if provenance.Diverged() {
+		sb.WriteString("\n\n")
+		sb.WriteString(formatSubTitle("API vs. Local PAP"))
+		sb.WriteString("\n")
+		for _, d := range provenance.Discrepancies {
+			sb.WriteString("\n")
+			sb.WriteString(row(d.Field+":", fmt.Sprintf("API %s · local %s · %+d ct", orDash(d.API), orDash(d.Local), d.Cents), false))
+		}
+	}
+
+	return sb.String()
+}
+
+// Badge for the results screen telling whether the engines agreed; empty
+// unless both were run
+func formatConsensusBadge(provenance models.Provenance) string {
+	switch {
+	case provenance.Diverged():
+		return lipgloss.NewStyle().
+			Foreground(styles.WarningColor).
+			Bold(true).
+			Render(fmt.Sprintf("⚠ BMF API and local PAP differ in %d field(s)", len(provenance.Discrepancies)))
+	case provenance.Compared:
+		return lipgloss.NewStyle().
+			Foreground(styles.SuccessColor).
+			Render("✓ BMF API and local PAP agree")
+	case provenance.ComparisonError != "":
+		return lipgloss.NewStyle().
+			Foreground(styles.NeutralColor).
+			Render("Cross-check incomplete: " + provenance.ComparisonError)
+	default:
+		return ""
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "–"
+	}
+	return value
+}
+
 // Format tax results for display
 func formatTaxResults(income, incomeTax, solidarityTax, totalTax, netIncome, taxRate float64) string {
 	var sb strings.Builder
@@ -289,6 +425,40 @@ func formatTaxResults(income, incomeTax, solidarityTax, totalTax, netIncome, tax
 	return sb.String()
 }
 
+// formatBonusResults shows the tax on Sonstige Bezüge, a one-off payment
+// such as a bonus, apart from the regular pay and its tax, and the totals
+// of the result, which include both
+func formatBonusResults(result models.TaxResult) string {
+	var sb strings.Builder
+
+	sb.WriteString(formatSubTitle("Sonstige Bezüge"))
+	sb.WriteString("\n\n")
+	sb.WriteString(formatTableRow("One-off Payment:", formatEuro(result.Bonus), false))
+	sb.WriteString("\n")
+	sb.WriteString(formatTableRow("Income Tax:", formatEuro(result.BonusIncomeTax), false))
+	sb.WriteString("\n")
+	sb.WriteString(formatTableRow("Solidarity Tax:", formatEuro(result.BonusSolidarityTax), false))
+	sb.WriteString("\n")
+	sb.WriteString(formatTableRow("Net Payment:", formatEuro(result.Bonus-result.BonusIncomeTax-result.BonusSolidarityTax), false))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(formatSubTitle("Including the Payment"))
+	sb.WriteString("\n\n")
+	sb.WriteString(formatTableRow("Annual Income:", formatEuro(result.Income+result.Bonus), false))
+	sb.WriteString("\n")
+	sb.WriteString(lipgloss.NewStyle().
+		Foreground(styles.NeutralColor).
+		Render(strings.Repeat("─", 45)))
+	sb.WriteString("\n")
+	sb.WriteString(formatTableRow("Total Tax:", formatEuro(result.TotalTax), true))
+	sb.WriteString("\n")
+	sb.WriteString(formatTableRow("Net Income:", formatEuro(result.NetIncome), true))
+	sb.WriteString("\n")
+	sb.WriteString(formatTableRow("Effective Tax Rate:", formatPercent(result.TaxRate), true))
+
+	return sb.String()
+}
+
 // Format detailed breakdown for a selected tax result
 func formatSelectedBreakdown(result models.TaxResult) string {
 	var sb strings.Builder
@@ -2,8 +2,11 @@ package views
 
 import (
 	"strings"
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/calculation"
 	"tax-calculator/internal/tax/models"
 	"testing"
+	"time"
 )
 
 func TestFormatEuro(t *testing.T) {
@@ -311,6 +314,31 @@ func TestFormatTaxResults(t *testing.T) {
 	}
 }
 
+func TestFormatBonusResults(t *testing.T) {
+	result := formatBonusResults(models.TaxResult{
+		Income:             50000.0,
+		IncomeTax:          8000.0,
+		TotalTax:           9582.5,
+		NetIncome:          45417.5,
+		TaxRate:            17.42,
+		Bonus:              5000.0,
+		BonusIncomeTax:     1500.0,
+		BonusSolidarityTax: 82.5,
+	})
+
+	for _, want := range []string{"Sonstige Bezüge", "One-off Payment", "€ 5000.00", "€ 1500.00", "€ 82.50", "€ 3417.50"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("formatBonusResults should contain %q", want)
+		}
+	}
+	// The totals include the regular pay and the payment
+	for _, want := range []string{"€ 55000.00", "€ 9582.50", "€ 45417.50", "17.42%"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("formatBonusResults should contain the total %q", want)
+		}
+	}
+}
+
 func TestMinAndMax(t *testing.T) {
 	minTests := []struct {
 		a, b, expected int
@@ -590,3 +618,135 @@ func TestFormatPercentHelper(t *testing.T) {
 		t.Error("formatPercent should handle 100%")
 	}
 }
+
+func TestParseTaxYear(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		wantErr  bool
+	}{
+		{"2024", 2024, false},
+		{" 2025 ", 2025, false},
+		{"", 0, false},
+		{"20x4", 0, true},
+	}
+
+	for _, tc := range tests {
+		year, err := parseTaxYear(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseTaxYear(%q): unexpected error state: %v", tc.input, err)
+		}
+		if year != tc.expected {
+			t.Errorf("parseTaxYear(%q): expected %d, got %d", tc.input, tc.expected, year)
+		}
+	}
+}
+
+func TestDefaultTaxYear(t *testing.T) {
+	years := bmf.DefaultPAPRegistry.Years()
+	latest := years[len(years)-1]
+
+	if year := defaultTaxYear(time.Date(latest+5, 1, 1, 0, 0, 0, 0, time.UTC)); year != latest {
+		t.Errorf("Expected the latest supported year %d for a future date, got %d", latest, year)
+	}
+	if year := defaultTaxYear(time.Date(years[0], 6, 1, 0, 0, 0, 0, time.UTC)); year != years[0] {
+		t.Errorf("Expected %d, got %d", years[0], year)
+	}
+}
+
+func TestFormatPolicy(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, policy := range calculation.FallbackPolicies {
+		label := formatPolicy(policy)
+		if label == "" || seen[label] {
+			t.Errorf("Expected a distinct label for %s, got %q", policy, label)
+		}
+		seen[label] = true
+	}
+}
+
+func TestFormatProvenance(t *testing.T) {
+	official := formatProvenance(models.Provenance{
+		Engine:       models.EngineAPI,
+		PAPVersion:   "2025Version1",
+		CalculatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	})
+	for _, want := range []string{"BMF API", "(official)", "2025Version1", "2025-03-01 12:00:00"} {
+		if !strings.Contains(official, want) {
+			t.Errorf("Expected %q in %q", want, official)
+		}
+	}
+	if strings.Contains(official, "Fallback") {
+		t.Error("Expected no fallback note for an API result")
+	}
+
+	fallback := formatProvenance(models.Provenance{
+		Engine:         models.EngineLocal,
+		PAPVersion:     "2025Version1",
+		FallbackReason: "BMF API failed: timeout",
+	})
+	for _, want := range []string{"local PAP", "(unofficial)", "Fallback: BMF API failed: timeout"} {
+		if !strings.Contains(fallback, want) {
+			t.Errorf("Expected %q in %q", want, fallback)
+		}
+	}
+}
+
+func TestFormatConsensusBadge(t *testing.T) {
+	if badge := formatConsensusBadge(models.Provenance{Engine: models.EngineAPI}); badge != "" {
+		t.Errorf("Expected no badge without a cross-check, got %q", badge)
+	}
+
+	agreed := formatConsensusBadge(models.Provenance{Compared: true})
+	if !strings.Contains(agreed, "agree") {
+		t.Errorf("Expected an agreement badge, got %q", agreed)
+	}
+
+	diverged := models.Provenance{
+		Compared: true,
+		Discrepancies: []models.Discrepancy{
+			{Field: "LSTLZZ", API: "702100", Local: "702000", Cents: -100},
+		},
+	}
+	if badge := formatConsensusBadge(diverged); !strings.Contains(badge, "differ in 1 field") {
+		t.Errorf("Expected a warning badge, got %q", badge)
+	}
+	if details := formatProvenance(diverged); !strings.Contains(details, "API 702100 · local 702000 · -100 ct") {
+		t.Errorf("Expected the difference per field, got %q", details)
+	}
+
+	failed := formatConsensusBadge(models.Provenance{ComparisonError: "BMF API failed: timeout"})
+	if !strings.Contains(failed, "timeout") {
+		t.Errorf("Expected the reason the check failed, got %q", failed)
+	}
+}
+
+func TestFormatOutputs(t *testing.T) {
+	response := &bmf.TaxCalculationResponse{
+		Year: "2025",
+		Outputs: bmf.Outputs{Output: []bmf.Output{
+			{Name: "LSTLZZ", Value: "800000"},
+			{Name: "SOLZLZZ", Value: "0"},
+			{Name: "STS", Value: "120000"},
+			{Name: "WVFRB", Value: "2500"},
+			{Name: "NEWOUT", Value: "42"},
+		}},
+	}
+
+	output := formatOutputs(response)
+	for _, want := range []string{"Regular pay", "Lohnsteuer:", "€ 8000.00", "Sonstige Bezüge", "€ 1200.00", "DBA income above the Grundfreibetrag", "€ 2500.00", "Other Outputs", "NEWOUT:"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in %q", want, output)
+		}
+	}
+	// Outputs the response does not have are left out
+	if strings.Contains(output, "Multi-year pay") || strings.Contains(output, "Church tax base") {
+		t.Errorf("Expected only the outputs of the response, got %q", output)
+	}
+
+	// An output that cannot be read shows every output as it came
+	response.Outputs.Output[0].Value = "8000.00"
+	if output := formatOutputs(response); !strings.Contains(output, "Other Outputs") || !strings.Contains(output, "8000.00") || strings.Contains(output, "Regular pay") {
+		t.Errorf("Expected the raw outputs, got %q", output)
+	}
+}
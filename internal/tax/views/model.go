@@ -11,6 +11,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/calculation"
 	"tax-calculator/internal/tax/models"
 	"tax-calculator/internal/tax/views/styles"
 )
@@ -22,6 +23,7 @@ const (
 	ResultsScreen
 	ComparisonScreen
 	AdvancedScreen
+	DebuggerScreen
 )
 
 type Tab int
@@ -55,6 +57,10 @@ const (
 	PKPV_Field
 	PKV_Field
 	PVA_Field
+	SONSTB_Field
+	JRE4_Field
+	JVBEZ_Field
+	STERBE_Field
 	BackButtonField
 )
 
@@ -71,6 +77,41 @@ type AdvancedField struct {
 	Field       Field
 }
 
+// The PAP inputs the advanced fields set, to match validation errors
+var advancedInputs = map[Field]string{
+	AJAHR_Field:  "AJAHR",
+	ALTER1_Field: "ALTER1",
+	KRV_Field:    "KRV",
+	KVZ_Field:    "KVZ",
+	PVS_Field:    "PVS",
+	PVZ_Field:    "PVZ",
+	R_Field:      "R",
+	ZKF_Field:    "ZKF",
+	VBEZ_Field:   "VBEZ",
+	VJAHR_Field:  "VJAHR",
+	PKPV_Field:   "PKPV",
+	PKV_Field:    "PKV",
+	PVA_Field:    "PVA",
+	SONSTB_Field: "SONSTB",
+	JRE4_Field:   "JRE4",
+	JVBEZ_Field:  "JVBEZ",
+	STERBE_Field: "STERBE",
+}
+
+// The advanced fields that take amounts in euros and cents, which the PAP
+// takes in cents, rather than whole numbers
+var centFields = map[Field]bool{
+	SONSTB_Field: true,
+	JRE4_Field:   true,
+	JVBEZ_Field:  true,
+	STERBE_Field: true,
+}
+
+// Input returns the name of the PAP input the field sets
+func (f AdvancedField) Input() string {
+	return advancedInputs[f.Field]
+}
+
 type RetroApp struct {
 	screen     Screen
 	activeTab  Tab
@@ -80,7 +121,8 @@ type RetroApp struct {
 	selectedTaxClass int
 	incomeInput      textinput.Model
 	yearInput        textinput.Model
-	useLocalCalc     bool
+	policy           calculation.FallbackPolicy
+	consensus        bool
 
 	// Advanced input fields in a more organized structure
 	advancedFields []AdvancedField
@@ -94,8 +136,18 @@ type RetroApp struct {
 	resultsLoading bool
 	resultsError   string
 	result         *bmf.TaxCalculationResponse
+	summary        models.TaxResult
+	provenance     models.Provenance
+	request        models.TaxRequest
 	showDetails    bool
 
+	// Inputs of the last calculation that were rejected, shown beside the
+	// advanced fields
+	validationErrors models.ValidationErrors
+
+	// Step debugger over a traced local calculation of request
+	debugger debuggerState
+
 	comparisonLoading     bool
 	comparisonError       string
 	comparisonResults     []models.TaxResult
@@ -127,8 +179,8 @@ func NewRetroApp() *RetroApp {
 	incomeInput.TextStyle = lipgloss.NewStyle().Foreground(styles.FgColor)
 	incomeInput.PromptStyle = lipgloss.NewStyle().Foreground(styles.SuccessColor)
 
-	// Current year with properly styled input
-	currentYear := time.Now().Year()
+	// Latest supported year with properly styled input
+	currentYear := defaultTaxYear(time.Now())
 	yearInput := textinput.New()
 	yearInput.Placeholder = fmt.Sprintf("%d", currentYear)
 	yearInput.Width = 6
@@ -205,6 +257,26 @@ func NewRetroApp() *RetroApp {
 			"Children for care insurance",
 			"Number of children for reduced nursing care insurance (0-4)",
 			"0", 5, 1, PVA_Field),
+
+		createAdvancedField(
+			"One-off payment €",
+			"Bonus or Christmas pay paid once this year, taxed as a sonstiger Bezug",
+			"0", 10, 10, SONSTB_Field),
+
+		createAdvancedField(
+			"Annual wage before it €",
+			"Expected annual wage without the one-off payment (empty: the income)",
+			"", 10, 10, JRE4_Field),
+
+		createAdvancedField(
+			"Pensions in that wage €",
+			"Pension payments included in that annual wage (empty: the pension payments)",
+			"", 10, 10, JVBEZ_Field),
+
+		createAdvancedField(
+			"Death benefit in payment €",
+			"Sterbegeld included in the one-off payment, in euros (0 if none)",
+			"0", 10, 10, STERBE_Field),
 	}
 
 	// Create fancy spinner
@@ -234,7 +306,7 @@ func NewRetroApp() *RetroApp {
 		selectedTaxClass: 1, // Default selection
 		incomeInput:      incomeInput,
 		yearInput:        yearInput,
-		useLocalCalc:     false,
+		policy:           calculation.APIThenLocal,
 
 		// Advanced fields
 		advancedFields: advancedFields,
@@ -245,6 +317,8 @@ func NewRetroApp() *RetroApp {
 		comparisonViewport: compVp,
 		advancedViewport:   advVp,
 
+		debugger: newDebuggerState(),
+
 		spinner:               s,
 		selectedComparisonIdx: 0,
 		showBreakdown:         false,
@@ -281,6 +355,42 @@ func (m *RetroApp) getAdvancedField(field Field) *AdvancedField {
 	return nil
 }
 
+// advancedInputErrors lists the advanced fields that do not hold a number,
+// which buildTaxRequest would replace with their defaults
+func (m *RetroApp) advancedInputErrors() models.ValidationErrors {
+	var errs models.ValidationErrors
+	for _, field := range m.advancedFields {
+		value := field.Model.Value()
+		var err error
+		if centFields[field.Field] {
+			_, err = parseCentsWithDefault(value, 0)
+		} else if field.Field == KVZ_Field || field.Field == ZKF_Field {
+			_, err = parseFloatWithDefault(value, 0)
+		} else {
+			_, err = parseIntWithDefault(value, 0)
+		}
+		if err != nil {
+			errs = append(errs, models.ValidationError{Field: field.Input(), Value: value, Message: "not a number"})
+		}
+	}
+	return errs
+}
+
+// hasAdvancedInput reports whether an advanced field sets the named input
+func (m *RetroApp) hasAdvancedInput(name string) bool {
+	for _, field := range m.advancedFields {
+		if field.Input() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validationError returns the error of the input an advanced field sets
+func (m *RetroApp) validationError(field AdvancedField) (models.ValidationError, bool) {
+	return m.validationErrors.Field(field.Input())
+}
+
 // Helper method to build a tax request with all parameters
 func (m *RetroApp) buildTaxRequest() models.TaxRequest {
 	income, _ := parseFloatWithDefault(m.incomeInput.Value(), 0)
@@ -291,6 +401,7 @@ func (m *RetroApp) buildTaxRequest() models.TaxRequest {
 		Income:   int(income * 100),
 		TaxClass: models.TaxClass(m.selectedTaxClass),
 	}
+	request.Year, _ = parseTaxYear(m.yearInput.Value())
 
 	// Add advanced parameters
 	if field := m.getAdvancedField(AJAHR_Field); field != nil {
@@ -345,5 +456,26 @@ func (m *RetroApp) buildTaxRequest() models.TaxRequest {
 		request.PVA, _ = parseIntWithDefault(field.Model.Value(), 0)
 	}
 
+	// Sonstige Bezüge are taxed on the annual wage without them, which is
+	// the income and its pension payments unless the fields say otherwise
+	request.JRE4 = request.Income
+	request.JVBEZ = request.VBEZ * 100
+
+	if field := m.getAdvancedField(SONSTB_Field); field != nil {
+		request.SONSTB, _ = parseCentsWithDefault(field.Model.Value(), 0)
+	}
+
+	if field := m.getAdvancedField(JRE4_Field); field != nil {
+		request.JRE4, _ = parseCentsWithDefault(field.Model.Value(), request.JRE4)
+	}
+
+	if field := m.getAdvancedField(JVBEZ_Field); field != nil {
+		request.JVBEZ, _ = parseCentsWithDefault(field.Model.Value(), request.JVBEZ)
+	}
+
+	if field := m.getAdvancedField(STERBE_Field); field != nil {
+		request.STERBE, _ = parseCentsWithDefault(field.Model.Value(), 0)
+	}
+
 	return request
 }
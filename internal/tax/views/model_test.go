@@ -177,3 +177,27 @@ func TestSortResults(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildTaxRequestSonstigeBezuege(t *testing.T) {
+	app := NewRetroApp()
+	app.incomeInput.SetValue("42000")
+	app.getAdvancedField(VBEZ_Field).Model.SetValue("6000")
+	app.getAdvancedField(SONSTB_Field).Model.SetValue("2500.50")
+
+	// The annual wage and its pensions default to the income and VBEZ
+	req := app.buildTaxRequest()
+	if req.SONSTB != 250050 {
+		t.Errorf("Expected SONSTB 250050 cents, got %d", req.SONSTB)
+	}
+	if req.JRE4 != 4200000 || req.JVBEZ != 600000 {
+		t.Errorf("Expected JRE4 4200000 and JVBEZ 600000, got %d and %d", req.JRE4, req.JVBEZ)
+	}
+
+	app.getAdvancedField(JRE4_Field).Model.SetValue("48000")
+	app.getAdvancedField(JVBEZ_Field).Model.SetValue("0")
+	app.getAdvancedField(STERBE_Field).Model.SetValue("1000")
+	req = app.buildTaxRequest()
+	if req.JRE4 != 4800000 || req.JVBEZ != 0 || req.STERBE != 100000 {
+		t.Errorf("Expected JRE4 4800000, JVBEZ 0 and STERBE 100000, got %d, %d and %d", req.JRE4, req.JVBEZ, req.STERBE)
+	}
+}
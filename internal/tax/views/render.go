@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/models"
 	"tax-calculator/internal/tax/views/styles"
 
 	"github.com/charmbracelet/lipgloss"
@@ -21,6 +22,8 @@ func (m *RetroApp) View() string {
 		return m.renderResultsScreen()
 	case ComparisonScreen:
 		return m.renderComparisonScreen()
+	case DebuggerScreen:
+		return m.renderDebuggerScreen()
 	default:
 		return m.renderMainScreen()
 	}
@@ -104,9 +107,9 @@ func (m *RetroApp) renderMainScreen() string {
 	)
 
 	// Subtle mode indicator
-	modeText := "Remote Calculation"
-	if m.useLocalCalc {
-		modeText = "Local Calculation"
+	modeText := formatPolicy(m.policy)
+	if m.consensus {
+		modeText += " · Cross-Check"
 	}
 
 	modeIndicator := lipgloss.NewStyle().
@@ -124,7 +127,9 @@ func (m *RetroApp) renderMainScreen() string {
 		"  ",
 		formatKeyHint("Enter", "Select"),
 		"  ",
-		formatKeyHint("L", "Toggle Local Mode"),
+		formatKeyHint("L", "Change Engine"),
+		"  ",
+		formatKeyHint("V", "Cross-Check"),
 	)
 
 	// Main content with proper spacing
@@ -190,6 +195,15 @@ func (m *RetroApp) renderAdvancedScreen() string {
 
 	// Clean form layout
 	var formContent strings.Builder
+	errorStyle := lipgloss.NewStyle().Foreground(styles.DangerColor)
+
+	// Rejected inputs without a field of their own, such as the income
+	for _, err := range m.validationErrors {
+		if err.Field == "" || !m.hasAdvancedInput(err.Field) {
+			formContent.WriteString(errorStyle.Render("✗ " + err.Error()))
+			formContent.WriteString("\n\n")
+		}
+	}
 
 	for i, field := range m.advancedFields {
 		isFocused := m.focusField == field.Field
@@ -210,7 +224,11 @@ func (m *RetroApp) renderAdvancedScreen() string {
 		formContent.WriteString("\n")
 		formContent.WriteString(descStyle.Render(field.Description))
 		formContent.WriteString("\n")
-		formContent.WriteString(inputStyle.Render(field.Model.View()))
+		input := inputStyle.Render(field.Model.View())
+		if err, ok := m.validationError(field); ok {
+			input = lipgloss.JoinHorizontal(lipgloss.Center, input, "  ", errorStyle.Render("✗ "+err.Message))
+		}
+		formContent.WriteString(input)
 
 		// Spacing between fields
 		if i < len(m.advancedFields)-1 {
@@ -354,19 +372,14 @@ func (m *RetroApp) renderResultsScreen() string {
 		)
 	}
 
-	// Calculate tax values
-	income, _ := parseFloatWithDefault(m.incomeInput.Value(), 0)
-
-	var incomeTax, solidarityTax float64
-	for _, output := range m.result.Outputs.Output {
-		if output.Name == "LSTLZZ" {
-			incomeTax = float64(bmf.MustParseInt(output.Value)) / 100
-		} else if output.Name == "SOLZLZZ" {
-			solidarityTax = float64(bmf.MustParseInt(output.Value)) / 100
-		}
-	}
+	// The figures as the service summarized them; a malformed output shows
+	// as zero here and as raw text in the details
+	summary := m.summary
+	income := summary.Income
 
-	totalTax := incomeTax + solidarityTax
+	// The regular pay on its own; the summary's totals include the
+	// Sonstige Bezüge, which formatBonusResults shows apart
+	totalTax := summary.IncomeTax + summary.SolidarityTax
 	netIncome := income - totalTax
 	taxRate := 0.0
 	if income > 0 {
@@ -377,7 +390,13 @@ func (m *RetroApp) renderResultsScreen() string {
 	var tabContent string
 	switch m.activeTab {
 	case BasicTab:
-		tabContent = formatTaxResults(income, incomeTax, solidarityTax, totalTax, netIncome, taxRate)
+		tabContent = formatTaxResults(income, summary.IncomeTax, summary.SolidarityTax, totalTax, netIncome, taxRate)
+		if summary.Bonus > 0 || summary.BonusIncomeTax != 0 || summary.BonusSolidarityTax != 0 {
+			tabContent += "\n\n" + formatBonusResults(summary)
+		}
+		if m.provenance.Engine != "" {
+			tabContent += "\n\n" + formatSubTitle("Source") + "\n\n" + formatProvenance(m.provenance)
+		}
 
 	case DetailsTab:
 		// Clean detailed view
@@ -402,15 +421,7 @@ func (m *RetroApp) renderResultsScreen() string {
 			details.WriteString("\n")
 		}
 
-		details.WriteString("\n")
-		details.WriteString(formatSubTitle("Raw Calculation Values"))
-		details.WriteString("\n\n")
-
-		// Show raw output values from the BMF response
-		for _, output := range m.result.Outputs.Output {
-			details.WriteString(formatTableRow(output.Name+":", output.Value, false))
-			details.WriteString("\n")
-		}
+		details.WriteString(formatOutputs(m.result))
 
 		tabContent = details.String()
 
@@ -460,6 +471,8 @@ func (m *RetroApp) renderResultsScreen() string {
 		"  ",
 		formatKeyHint("C", "Compare"),
 		"  ",
+		formatKeyHint("T", "Trace"),
+		"  ",
 		formatKeyHint("B", "Back"),
 	)
 
@@ -476,11 +489,16 @@ func (m *RetroApp) renderResultsScreen() string {
 	// Clean container styling
 	resultContainer := styles.ResultsContainerStyle.Render(m.resultsViewport.View())
 
+	title := formatTitle("Tax Calculation Results")
+	if badge := formatConsensusBadge(m.provenance); badge != "" {
+		title = lipgloss.JoinVertical(lipgloss.Center, title, badge)
+	}
+
 	// Clean layout with proper spacing
 	return lipgloss.JoinVertical(
 		lipgloss.Center,
 		"",
-		formatTitle("Tax Calculation Results"),
+		title,
 		"",
 		lipgloss.NewStyle().
 			Width(width).
@@ -644,3 +662,42 @@ func (m *RetroApp) renderComparisonScreen() string {
 			Render(helpText),
 	)
 }
+
+// formatOutputs lists every output of a response by group, in euros. Outputs
+// the typed model does not know, or cannot read, are shown as they came.
+func formatOutputs(response *bmf.TaxCalculationResponse) string {
+	var b strings.Builder
+
+	outputs, err := response.TaxOutputs()
+	group := ""
+	for _, field := range models.OutputFields {
+		if err != nil || !outputs.Has(field.Name) {
+			continue
+		}
+		if field.Group != group {
+			group = field.Group
+			b.WriteString("\n")
+			b.WriteString(formatSubTitle(group))
+			b.WriteString("\n\n")
+		}
+		b.WriteString(formatTableRow(field.Label+":", formatEuro(field.Euros(outputs)), field.Name == "LSTLZZ"))
+		b.WriteString("\n")
+	}
+
+	var other []bmf.Output
+	for _, output := range response.Outputs.Output {
+		if _, known := models.LookupOutputField(output.Name); !known || err != nil {
+			other = append(other, output)
+		}
+	}
+	if len(other) > 0 {
+		b.WriteString("\n")
+		b.WriteString(formatSubTitle("Other Outputs"))
+		b.WriteString("\n\n")
+		for _, output := range other {
+			b.WriteString(formatTableRow(output.Name+":", output.Value, false))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
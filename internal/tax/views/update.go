@@ -1,6 +1,8 @@
 package views
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -42,6 +44,11 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Debugger breakpoint input
+	if m.screen == DebuggerScreen && m.debugger.breakpointInput.Focused() {
+		inputFocused = true
+	}
+
 	// Handle special key events when an input is focused
 	if inputFocused {
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
@@ -63,6 +70,8 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							m.advancedFields[i].Model = newModel
 						}
 					}
+				} else if m.screen == DebuggerScreen {
+					m.debugger.breakpointInput.Blur()
 				}
 
 			case "tab", "shift+tab":
@@ -81,6 +90,8 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Will be handled by the input update section below
 			}
 		}
+	} else if keyMsg, ok := msg.(tea.KeyMsg); ok && m.screen == DebuggerScreen && m.handleDebuggerKey(keyMsg.String()) {
+		// Stepping keys of the debugger take precedence over the global ones
 	} else {
 		// Regular key handling when no input is focused
 		if keyMsg, ok := msg.(tea.KeyMsg); ok {
@@ -116,8 +127,12 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 			case "l":
-				// Toggle local calculation
-				m.useLocalCalc = !m.useLocalCalc
+				// Cycle through the fallback policies
+				m.policy = m.policy.Next()
+
+			case "v":
+				// Toggle cross-checking both engines
+				m.consensus = !m.consensus
 
 			case "c":
 				// Start comparison mode from results screen
@@ -140,6 +155,14 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.screen == ResultsScreen {
 					m.showDetails = !m.showDetails
 				}
+
+			case "t":
+				// Step through a traced local calculation of the results
+				if m.screen == ResultsScreen && m.result != nil {
+					m.screen = DebuggerScreen
+					m.debugger.start()
+					cmds = append(cmds, TraceCalculationCmd(m.request))
+				}
 			}
 		}
 	}
@@ -161,16 +184,23 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// When calculation starts
 		m.resultsLoading = true
 		m.screen = ResultsScreen
-		m.useLocalCalc = msgType.UseLocalCalculator
+		m.policy = msgType.Policy
 
 	case CalculationMsg:
 		// When calculation completes
 		m.resultsLoading = false
 
+		m.validationErrors = nil
 		if msgType.Error != nil {
 			m.resultsError = msgType.Error.Error()
+			if errors.As(msgType.Error, &m.validationErrors) {
+				m.showInvalidFields()
+			}
 		} else {
 			m.result = msgType.Result
+			m.summary = msgType.Summary
+			m.provenance = msgType.Provenance
+			m.request = msgType.Request
 			m.resultsError = ""
 		}
 
@@ -198,6 +228,10 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.comparisonError = ""
 		}
 
+	case TraceMsg:
+		// When the traced calculation completes
+		m.debugger.finish(msgType)
+
 	case DebugLogMsg:
 		// Skip debug messages in this UI
 	}
@@ -248,6 +282,16 @@ func (m *RetroApp) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	// Breakpoint input of the debugger, once focused, so the key focusing it
+	// is not typed into it
+	if m.screen == DebuggerScreen && inputFocused {
+		newInput, cmd := m.debugger.breakpointInput.Update(msg)
+		m.debugger.breakpointInput = newInput
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -462,6 +506,11 @@ func (m *RetroApp) handleEnterSelection() tea.Cmd {
 		if len(m.comparisonResults) > 0 {
 			m.showBreakdown = !m.showBreakdown
 		}
+
+	case DebuggerScreen:
+		if m.debugger.breakpointInput.Focused() {
+			m.submitBreakpoint()
+		}
 	}
 
 	return nil
@@ -503,19 +552,34 @@ func (m *RetroApp) startCalculationCmd() tea.Cmd {
 
 	year := m.yearInput.Value()
 	if strings.TrimSpace(year) == "" {
-		year = time.Now().Format("2006")
+		year = fmt.Sprintf("%d", defaultTaxYear(time.Now()))
 	}
 
-	// Use the proper calculation mode based on local flag
-	return PerformCalculationWithAdvancedOptionsCmd(
+	// Use the calculation mode picked by the fallback policy
+	return PerformCalculationWithPolicyCmd(
 		m.selectedTaxClass,
 		income,
 		year,
 		models.TaxRequest{}, // Empty tax request with default values
-		m.useLocalCalc,      // Pass the local calculator flag
+		m.policy,
+		m.calculationOptions()...,
 	)
 }
 
+// showInvalidFields returns to the advanced screen with the first field
+// the calculation rejected in focus. Errors of inputs without a field stay
+// on the results screen.
+func (m *RetroApp) showInvalidFields() {
+	for _, field := range m.advancedFields {
+		if _, ok := m.validationError(field); ok {
+			m.screen = AdvancedScreen
+			m.focusField = field.Field
+			m.autoFocusInputField()
+			return
+		}
+	}
+}
+
 // Start advanced tax calculation command
 func (m *RetroApp) startAdvancedCalculationCmd() tea.Cmd {
 	income, err := parseFloatWithDefault(m.incomeInput.Value(), 0)
@@ -527,23 +591,39 @@ func (m *RetroApp) startAdvancedCalculationCmd() tea.Cmd {
 		}
 	}
 
+	// A typo must not quietly become the field's default
+	if errs := m.advancedInputErrors(); len(errs) > 0 {
+		return func() tea.Msg {
+			return CalculationMsg{Error: errs}
+		}
+	}
+
 	year := m.yearInput.Value()
 	if strings.TrimSpace(year) == "" {
-		year = time.Now().Format("2006")
+		year = fmt.Sprintf("%d", defaultTaxYear(time.Now()))
 	}
 
 	// Build the tax request from advanced fields
 	taxRequest := m.buildTaxRequest()
 
-	return PerformCalculationWithAdvancedOptionsCmd(
+	return PerformCalculationWithPolicyCmd(
 		m.selectedTaxClass,
 		income,
 		year,
 		taxRequest,
-		m.useLocalCalc,
+		m.policy,
+		m.calculationOptions()...,
 	)
 }
 
+// Extra service options picked in the main screen
+func (m *RetroApp) calculationOptions() []calculation.Option {
+	if m.consensus {
+		return []calculation.Option{calculation.WithConsensus()}
+	}
+	return nil
+}
+
 // Start comparison command
 func (m *RetroApp) startComparisonCmd() tea.Cmd {
 	income, err := parseFloatWithDefault(m.incomeInput.Value(), 0)
@@ -562,13 +642,11 @@ func (m *RetroApp) startComparisonCmd() tea.Cmd {
 		},
 		func() tea.Msg {
 			// Create the tax service
-			taxService := calculation.NewTaxService()
-			if m.useLocalCalc {
-				taxService.EnableLocalCalculator()
-			}
+			taxService := calculation.NewTaxService(calculation.WithFallbackPolicy(m.policy))
 
 			// Run the calculation with progress updates
-			return FetchComparisonCmd(m.selectedTaxClass, income)()
+			year, _ := parseTaxYear(m.yearInput.Value())
+			return fetchComparisonWithService(taxService, m.selectedTaxClass, income, year)()
 		},
 	)
 }
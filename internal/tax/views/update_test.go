@@ -1,6 +1,10 @@
 package views
 
 import (
+	"errors"
+	"strings"
+	"tax-calculator/internal/tax/bmf"
+	"tax-calculator/internal/tax/calculation"
 	"tax-calculator/internal/tax/models"
 	"testing"
 
@@ -421,6 +425,10 @@ func TestFieldConstants(t *testing.T) {
 		PKPV_Field,
 		PKV_Field,
 		PVA_Field,
+		SONSTB_Field,
+		JRE4_Field,
+		JVBEZ_Field,
+		STERBE_Field,
 	}
 
 	for i, field := range fields {
@@ -452,6 +460,7 @@ func TestScreenConstants(t *testing.T) {
 		ResultsScreen,
 		ComparisonScreen,
 		AdvancedScreen,
+		DebuggerScreen,
 	}
 
 	for i, screen := range screens {
@@ -460,3 +469,107 @@ func TestScreenConstants(t *testing.T) {
 		}
 	}
 }
+
+func TestRetroAppValidationErrors(t *testing.T) {
+	app := NewRetroApp()
+	app.screen = ResultsScreen
+	app.resultsLoading = true
+
+	invalid := models.ValidationErrors{
+		{Field: "KVZ", Value: "13", Message: "Eingabe ist nicht zulässig"},
+		{Message: "Fehlerhafte Eingabe"},
+	}
+	app.Update(CalculationMsg{Error: invalid})
+
+	if app.screen != AdvancedScreen {
+		t.Errorf("Expected the advanced screen, got %v", app.screen)
+	}
+	if app.focusField != KVZ_Field {
+		t.Errorf("Expected focus on the rejected field, got %v", app.focusField)
+	}
+
+	view := app.renderAdvancedScreen()
+	for _, want := range []string{"✗ Eingabe ist nicht zulässig", "✗ Fehlerhafte Eingabe"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the advanced screen to show %q", want)
+		}
+	}
+
+	// A successful calculation clears the errors
+	app.Update(CalculationMsg{Result: &bmf.TaxCalculationResponse{}})
+	if len(app.validationErrors) != 0 {
+		t.Errorf("Expected no validation errors, got %v", app.validationErrors)
+	}
+}
+
+func TestRetroAppValidationErrorWithoutField(t *testing.T) {
+	app := NewRetroApp()
+	app.screen = ResultsScreen
+
+	app.Update(CalculationMsg{Error: models.ValidationErrors{{Field: "STKL", Value: "7", Message: "Eingabe ist nicht zulässig"}}})
+	if app.screen != ResultsScreen {
+		t.Errorf("Expected to stay on the results screen, got %v", app.screen)
+	}
+	if !strings.Contains(app.resultsError, `invalid STKL "7"`) {
+		t.Errorf("Expected the error on the results screen, got %q", app.resultsError)
+	}
+}
+
+func TestRetroAppAdvancedTypo(t *testing.T) {
+	app := NewRetroApp()
+	app.incomeInput.SetValue("50000")
+	app.getAdvancedField(KVZ_Field).Model.SetValue("1,3")
+
+	msg, ok := app.startAdvancedCalculationCmd()().(CalculationMsg)
+	if !ok {
+		t.Fatal("Expected a CalculationMsg without calculating")
+	}
+	var errs models.ValidationErrors
+	if !errors.As(msg.Error, &errs) {
+		t.Fatalf("Expected validation errors, got: %v", msg.Error)
+	}
+	if err, ok := errs.Field("KVZ"); !ok || err.Value != "1,3" {
+		t.Errorf("Expected the typo in KVZ, got %+v", errs)
+	}
+}
+
+func TestRetroAppBonusResults(t *testing.T) {
+	app := NewRetroApp()
+	app.screen = ResultsScreen
+	app.incomeInput.SetValue("50000")
+
+	response := &bmf.TaxCalculationResponse{Outputs: bmf.Outputs{Output: []bmf.Output{
+		{Name: "LSTLZZ", Value: "800000"},
+		{Name: "SOLZLZZ", Value: "0"},
+		{Name: "STS", Value: "150000"},
+		{Name: "SOLZS", Value: "8250"},
+	}}}
+	service := calculation.NewTaxService()
+	req := models.TaxRequest{Income: 5000000, SONSTB: 500000}
+	app.Update(CalculationMsg{Request: req, Result: response, Summary: service.Summarize(response, req)})
+
+	view := app.renderResultsScreen()
+	for _, want := range []string{"Sonstige Bezüge", "€ 5000.00", "€ 1500.00", "€ 82.50", "€ 3417.50", "€ 55000.00", "€ 9582.50"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the results to show %q", want)
+		}
+	}
+
+	// Without a payment the results stay as they were
+	response.Outputs.Output = response.Outputs.Output[:2]
+	req = models.TaxRequest{Income: 5000000}
+	app.Update(CalculationMsg{Request: req, Result: response, Summary: service.Summarize(response, req)})
+	if view := app.renderResultsScreen(); strings.Contains(view, "Sonstige Bezüge") {
+		t.Error("Expected no Sonstige Bezüge without a payment")
+	}
+}
+
+func TestRetroAppAdvancedTypoInAmount(t *testing.T) {
+	app := NewRetroApp()
+	app.getAdvancedField(SONSTB_Field).Model.SetValue("2.500,00")
+
+	errs := app.advancedInputErrors()
+	if err, ok := errs.Field("SONSTB"); !ok || err.Value != "2.500,00" {
+		t.Errorf("Expected the typo in SONSTB, got %+v", errs)
+	}
+}